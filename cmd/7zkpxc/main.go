@@ -19,6 +19,6 @@ func main() {
 
 	if err := app.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(app.ExitCode(err))
 	}
 }
@@ -2,14 +2,21 @@ package app
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/lxstig/7zkpxc/internal/config"
 	"github.com/lxstig/7zkpxc/internal/keepass"
 	"github.com/lxstig/7zkpxc/internal/sevenzip"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var addCmd = &cobra.Command{
@@ -18,7 +25,56 @@ var addCmd = &cobra.Command{
 	Long: `Creates a new encrypted archive with a unique password stored in KeePassXC.
 
 If the archive already exists, retrieves its password from KeePassXC and
-appends the provided files without creating a new entry.`,
+appends the provided files without creating a new entry.
+
+Sources can be narrowed with --exclude and --include. Exclude patterns and
+a .7zkpxcignore file in the root of a source directory use .gitignore
+syntax, relative to that directory; include globs match a file's name or
+path. --files-from reads further paths from a file (one per line, or
+NUL-separated with -0); they are stored as given. 7z receives the files in
+a temporary list file, so there is no limit on their number.
+
+  7zkpxc a project.7z ~/project --exclude node_modules --exclude '*.log'
+  7zkpxc a docs.7z ~/docs --include '*.pdf'
+  7zkpxc a -0 --files-from <(find . -name '*.go' -print0) src.7z
+
+With --tar a new archive holds a single POSIX (PAX) tar stream of the
+sources, built by 7zkpxc and piped into 7z, instead of 7z's own file
+entries. The tar keeps owners, permissions, extended attributes and POSIX
+ACLs, hard links and special files. The entry is marked, so 'x' unpacks
+the tar with that metadata restored. Sparse files are not supported: their
+holes are stored as zeros and they are unpacked as ordinary files.
+
+  sudo 7zkpxc a --tar rootfs.7z /srv/rootfs
+
+Such an archive lists as one .tar member: 'cat', 'grep' and member-level
+'restore' do not see the files inside, and 'u', 'd', 'rn', 'edit' and
+'watch' refuse it. Unpack it with 'x', or re-create it to change it.
+
+--reproducible (with --tar) builds the tar so that the same files always
+give the same stream (only the password and salt differ between runs):
+entries sorted by name, every mtime set to SOURCE_DATE_EPOCH (default 0),
+owners root, modes 0755/0644, no extended attributes; 7z runs
+single-threaded and solid with no timestamps of its own. The stream's
+SHA-256 is recorded on the entry; see '7zkpxc checksum'.
+
+  SOURCE_DATE_EPOCH=$(git log -1 --format=%ct) 7zkpxc a --tar --reproducible release.7z dist/
+
+--stdin-name creates a new archive holding one file of that name, so the
+data never touches the disk unencrypted. The data comes from a producer
+command given after "--", which 7zkpxc runs itself: if the command fails
+(even after writing part of its output), its input cannot be read or is
+empty, or 7zkpxc is interrupted, the partial archive and its KeePassXC
+entry are removed. The database and archive passwords go through the
+terminal.
+
+  7zkpxc a --stdin-name dump.sql db.7z -- pg_dump mydb
+
+Without a command, standard input is read instead. 7zkpxc cannot see the
+exit status of a command piped in, so one that dies mid-stream leaves a
+truncated file that is kept.
+
+  pg_dump mydb | 7zkpxc a --stdin-name dump.sql db.7z`,
 	Args:    cobra.MinimumNArgs(1),
 	RunE:    runAdd,
 	GroupID: "actions",
@@ -33,6 +89,19 @@ func init() {
 	// Volume flag (only meaningful for new archives)
 	addCmd.Flags().String("volume", "", "Create volumes, e.g. 100m, 1g (new archives only)")
 
+	// Source selection
+	addCmd.Flags().StringArray("exclude", nil, "Skip files matching this .gitignore-style pattern (repeatable)")
+	addCmd.Flags().StringArray("include", nil, "Only add files whose name or path matches this glob (repeatable)")
+	addCmd.Flags().String("files-from", "", "Read additional paths from FILE, one per line")
+	addCmd.Flags().BoolP("null", "0", false, "Paths in --files-from are NUL-separated (find -print0)")
+
+	addCmd.Flags().Bool("tar", false, "Store the sources as one tar stream keeping ownership and permissions (new archives only)")
+	addCmd.Flags().Bool("reproducible", false, "With --tar, normalize the tar stream so equal inputs give equal content")
+	addCmd.Flags().String("stdin-name", "", "Store standard input as one file of this name (new archives only)")
+	addCmd.MarkFlagsMutuallyExclusive("stdin-name", "tar")
+	addCmd.MarkFlagsMutuallyExclusive("stdin-name", "reproducible")
+	addCmd.MarkFlagsMutuallyExclusive("stdin-name", "files-from")
+
 	// Pass-through unknown flags to 7z (e.g. -sfx, -m0=lzma2)
 	addCmd.FParseErrWhitelist.UnknownFlags = true
 
@@ -53,12 +122,23 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	kp := keepass.New(cfg.General.KdbxPath, testClientOptions...)
+	kp := newKeePassClient(cfg)
 	defer kp.Close()
 
+	// With --stdin-name everything after "--" is the producer command
+	stdinName, _ := cmd.Flags().GetString("stdin-name")
+	positional := args[1:]
+	var producer []string
+	if dash := cmd.ArgsLenAtDash(); stdinName != "" && dash >= 0 {
+		if dash == 0 {
+			return fmt.Errorf("the archive name must come before \"--\"")
+		}
+		positional, producer = args[1:dash], args[dash:]
+	}
+
 	// Separate positional files from pass-through 7z flags
 	var files, extraFlags []string
-	for _, arg := range args[1:] {
+	for _, arg := range positional {
 		if strings.HasPrefix(arg, "-") {
 			if _, err := os.Stat(arg); err == nil {
 				// File exists but starts with "-" — security: reject to prevent injection
@@ -70,10 +150,36 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	filesFrom, _ := cmd.Flags().GetString("files-from")
+	nul, _ := cmd.Flags().GetBool("null")
+	if nul && filesFrom == "" {
+		return fmt.Errorf("-0 requires --files-from")
+	}
+	tarMode, _ := cmd.Flags().GetBool("tar")
+	if reproducible, _ := cmd.Flags().GetBool("reproducible"); reproducible && !tarMode {
+		return fmt.Errorf("--reproducible requires --tar: the archive then holds one normalized tar stream (see '7zkpxc a --help')")
+	}
+	var listed []string
+	if filesFrom != "" {
+		if listed, err = readFileList(filesFrom, nul); err != nil {
+			return err
+		}
+		if len(listed) == 0 {
+			return fmt.Errorf("'%s' lists no files", filesFrom)
+		}
+	}
+
+	if stdinName != "" {
+		if err := checkStdinSource(cmd, archiveName, stdinName, files, producer); err != nil {
+			return err
+		}
+		return runAddStdin(cmd, cfg, kp, archiveName, stdinName, extraFlags, producer)
+	}
+
 	// Pre-flight check: ensure input files exist before expensive KeePassXC/7z operations.
 	// This prevents generating passwords and leaving "zombie" .7z files on disk
 	// if the user accidentally mistypes a filename (e.g. sysinfo.sf instead of .sh).
-	for _, file := range files {
+	for _, file := range append(append([]string{}, files...), listed...) {
 		// If the shell passed an unexpanded wildcard (e.g. "*.txt"), defer to 7z.
 		if strings.ContainsAny(file, "*?") {
 			continue
@@ -86,11 +192,21 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	excludes, _ := cmd.Flags().GetStringArray("exclude")
+	includes, _ := cmd.Flags().GetStringArray("include")
+	items, err := selectSources(files, listed, excludes, includes)
+	if err != nil {
+		return err
+	}
+
 	// Dispatch based on whether the archive already exists
 	if _, err := os.Stat(archiveName); err == nil {
-		return runAddUpdate(cmd, archiveName, files, extraFlags)
+		if tarMode {
+			return fmt.Errorf("--tar only creates new archives; '%s' already exists", archiveName)
+		}
+		return runAddUpdate(cmd, archiveName, recordedSources(files, listed), items, extraFlags)
 	}
-	return runAddCreate(cmd, cfg, kp, archiveName, files, extraFlags)
+	return runAddCreate(cmd, cfg, kp, archiveName, recordedSources(files, listed), items, extraFlags)
 }
 
 // runAddCreate generates a new password, saves it to KeePassXC, and creates the archive.
@@ -100,13 +216,219 @@ func runAddCreate(
 	cfg *config.Config,
 	kp *keepass.Client,
 	archiveName string,
-	files, extraFlags []string,
+	sources []string,
+	items []sourceFile,
+	extraFlags []string,
 ) error {
+	sevenZipArgs := buildCompressionArgs(cmd, cfg.SevenZip.DefaultArgs)
+	tarMode, _ := cmd.Flags().GetBool("tar")
+	reproducible, _ := cmd.Flags().GetBool("reproducible")
+	if tarMode {
+		var opts tarWriteOptions
+		if reproducible {
+			epoch, err := sourceDateEpoch()
+			if err != nil {
+				return err
+			}
+			opts = tarWriteOptions{Reproducible: true, Epoch: epoch}
+			sevenZipArgs = append(sevenZipArgs, reproducibleSwitches...)
+		}
+		var packed tarPack
+		entryPath, err := createManagedArchive(cfg, kp, cfg.General.DefaultGroup, archiveName, sources,
+			packTar(cfg.SevenZip.BinaryPath, sevenZipArgs, archiveName, items, opts, extraFlags, &packed))
+		if err != nil {
+			return err
+		}
+		recordTarPayload(kp, entryPath, packed, opts)
+		if reproducible {
+			fmt.Printf("Content SHA-256: %s\n", packed.SHA256)
+		}
+		return nil
+	}
+	_, err := createManagedArchive(cfg, kp, cfg.General.DefaultGroup, archiveName, sources,
+		packWithArgs(cfg.SevenZip.BinaryPath, sevenZipArgs, archiveName, items, extraFlags))
+	return err
+}
+
+// checkStdinSource validates 'a --stdin-name' before anything is created.
+// producer is the command after "--", if any.
+func checkStdinSource(cmd *cobra.Command, archiveName, name string, files, producer []string) error {
+	excludes, _ := cmd.Flags().GetStringArray("exclude")
+	includes, _ := cmd.Flags().GetStringArray("include")
+	if len(files) > 0 || len(excludes) > 0 || len(includes) > 0 {
+		return fmt.Errorf("--stdin-name archives standard input only; do not give files, --exclude or --include")
+	}
+	if filepath.IsAbs(name) || slices.Contains(strings.Split(filepath.ToSlash(name), "/"), "..") {
+		return fmt.Errorf("--stdin-name must be a relative file name, got '%s'", name)
+	}
+	if _, err := os.Stat(archiveName); err == nil {
+		return fmt.Errorf("--stdin-name only creates new archives; '%s' already exists", archiveName)
+	}
+	if len(producer) > 0 {
+		if _, err := exec.LookPath(producer[0]); err != nil {
+			return fmt.Errorf("cannot run '%s': %w", producer[0], err)
+		}
+		return nil
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("--stdin-name reads the data from standard input, which is a terminal — pipe the data in or give a command after \"--\"")
+	}
+	return nil
+}
+
+// runAddStdin creates a new archive holding the output of producer (or
+// standard input when there is none) as the file name.
+func runAddStdin(cmd *cobra.Command, cfg *config.Config, kp *keepass.Client, archiveName, name string, extraFlags, producer []string) error {
+	sevenZipArgs := buildCompressionArgs(cmd, cfg.SevenZip.DefaultArgs)
+	pack := packStdin(cfg.SevenZip.BinaryPath, sevenZipArgs, archiveName, name, extraFlags, os.Stdin)
+	if len(producer) > 0 {
+		pack = packProducer(cfg.SevenZip.BinaryPath, sevenZipArgs, archiveName, name, extraFlags, producer)
+	}
+	_, err := createManagedArchive(cfg, kp, cfg.General.DefaultGroup, archiveName, nil, pack)
+	return err
+}
+
+// packFunc writes a new archive encrypted with password.
+type packFunc func(password []byte) error
+
+// packWithArgs returns a packFunc running "7z a": sevenZipArgs ("a" plus
+// compression switches), then "-p", the archive, the items (in list files,
+// see runFileLists) and extraFlags.
+func packWithArgs(binaryPath string, sevenZipArgs []string, archiveName string, items []sourceFile, extraFlags []string) packFunc {
+	return func(password []byte) error {
+		args := append([]string{}, sevenZipArgs...)
+		args = append(args, "-p") // prompt for password (sent via PTY)
+		return runFileLists(binaryPath, password, args, archiveName, items, extraFlags)
+	}
+}
+
+// packStdin returns a packFunc that streams r into "7z a -si<name>". 7z
+// reads r through a pipe of ours, so that a read error, an empty stream or
+// an interrupt (Ctrl-C, SIGTERM) fails the pack — and createManagedArchive
+// rolls back the entry — even where 7z would store the truncated input. The
+// partial archive and its volumes are removed.
+func packStdin(binaryPath string, sevenZipArgs []string, archiveName, name string, extraFlags []string, r io.Reader) packFunc {
+	return func(password []byte) (err error) {
+		absArchive, err := filepath.Abs(archiveName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				_ = removeArchiveVolumes(absArchive)
+			}
+		}()
+
+		args := append([]string{}, sevenZipArgs...)
+		args = append(args, "-p", "-si"+name, absArchive)
+		args = append(args, extraFlags...)
+
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = pr.Close() }()
+
+		var streamed int64
+		copied := make(chan error, 1)
+		go func() {
+			n, err := io.Copy(pw, r)
+			if err == nil && n == 0 {
+				err = fmt.Errorf("no data")
+			}
+			streamed = n
+			_ = pw.Close()
+			copied <- err
+		}()
+
+		// An interrupt ends the stream early; 7z would then finish a
+		// perfectly valid archive of the truncated data.
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		interrupted := make(chan error, 1)
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case sig := <-sigs:
+				interrupted <- fmt.Errorf("interrupted by %v", sig)
+				_ = pw.Close()
+			case <-stop:
+			}
+		}()
+
+		runErr := sevenzip.StreamIn(binaryPath, password, args, pr, os.Stdout)
+		select {
+		case err := <-interrupted:
+			return err
+		default:
+		}
+		if runErr != nil {
+			return runErr
+		}
+		// 7z read to the end, so the copy is over.
+		if err := <-copied; err != nil {
+			return fmt.Errorf("reading standard input: %w", err)
+		}
+		fmt.Printf("Stored %d bytes from standard input as '%s'.\n", streamed, name)
+		return nil
+	}
+}
+
+// packProducer returns a packFunc that runs producer and archives its
+// output as packStdin does. A producer that exits with an error — even after
+// writing all of its output — fails the pack, and the archive is removed.
+func packProducer(binaryPath string, sevenZipArgs []string, archiveName, name string, extraFlags, producer []string) packFunc {
+	return func(password []byte) error {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		c := exec.Command(producer[0], producer[1:]...)
+		c.Stdin = os.Stdin
+		c.Stdout = pw
+		c.Stderr = os.Stderr
+		startErr := c.Start()
+		_ = pw.Close() // the producer holds its own copy
+		if startErr != nil {
+			_ = pr.Close()
+			return fmt.Errorf("cannot run '%s': %w", producer[0], startErr)
+		}
+
+		packErr := packStdin(binaryPath, sevenZipArgs, archiveName, name, extraFlags, pr)(password)
+		// Closing our end stops a producer still writing (SIGPIPE)
+		_ = pr.Close()
+		waitErr := c.Wait()
+		if packErr != nil {
+			return packErr
+		}
+		if waitErr != nil {
+			if absArchive, err := filepath.Abs(archiveName); err == nil {
+				_ = removeArchiveVolumes(absArchive)
+			}
+			return fmt.Errorf("'%s' failed: %w", producer[0], waitErr)
+		}
+		return nil
+	}
+}
+
+// createManagedArchive is the body of runAddCreate with the KeePass group and
+// the way the archive is packed chosen by the caller ('backup' uses it with
+// per-profile groups and presets). sources are recorded in the metadata for
+// 'restore'. Returns the entry path.
+func createManagedArchive(
+	cfg *config.Config,
+	kp *keepass.Client,
+	group, archiveName string,
+	sources []string,
+	pack packFunc,
+) (string, error) {
 	// 1. Generate password
 	fmt.Printf("Generating %d-character secure password...\n", cfg.General.PasswordLength)
 	password, err := kp.GeneratePassword(cfg.General.PasswordLength)
 	if err != nil {
-		return fmt.Errorf("failed to generate password: %w", err)
+		return "", fmt.Errorf("failed to generate password: %w", err)
 	}
 	defer func() {
 		for i := range password {
@@ -117,52 +439,30 @@ func runAddCreate(
 	// 2. Save to KeePassXC BEFORE creating the archive.
 	//    If 7z fails we can roll this back cleanly. The reverse is harder:
 	//    an archive with no KeePass entry is silently lost data.
-	//
-	//    Title = "basename (uuid8)" — path-independent, UUID ensures uniqueness
-	//    even when two archives share the same filename.
 	fmt.Printf("Saving entry to KeePassXC (%s)...\n", cfg.General.KdbxPath)
 	absArchivePath, err := filepath.Abs(archiveName)
 	if err != nil {
-		return fmt.Errorf("failed to resolve archive path: %w", err)
+		return "", fmt.Errorf("failed to resolve archive path: %w", err)
 	}
 
-	uuid8, err := generateUniqueUUID8(kp, cfg.General.DefaultGroup, filepath.Base(absArchivePath))
+	keePassEntryPath, uuid8, err := addArchiveEntry(kp, group, absArchivePath, password)
 	if err != nil {
-		return fmt.Errorf("failed to generate entry UUID: %w", err)
-	}
-	entryTitle := makeEntryTitle(filepath.Base(absArchivePath), uuid8)
-	keePassEntryPath := filepath.ToSlash(filepath.Clean(cfg.General.DefaultGroup + "/" + entryTitle))
-
-	if err := kp.AddEntry(
-		cfg.General.DefaultGroup,
-		entryTitle,
-		password,
-		absArchivePath, // Username — human-readable path for reference
-		"https://github.com/lxstig/7zkpxc",
-	); err != nil {
-		return fmt.Errorf("failed to add entry to KeePassXC: %w", err)
+		return "", err
 	}
 
-	// 3. Build 7z create arguments
+	// 3. Run 7z — rollback KeePass entry on failure
 	fmt.Printf("Creating archive '%s'...\n", archiveName)
-	sevenZipArgs := buildCompressionArgs(cmd, cfg.SevenZip.DefaultArgs)
-	sevenZipArgs = append(sevenZipArgs, "-p") // prompt for password (sent via PTY)
-	sevenZipArgs = append(sevenZipArgs, archiveName)
-	sevenZipArgs = append(sevenZipArgs, files...)
-	sevenZipArgs = append(sevenZipArgs, extraFlags...)
-
-	// 4. Run 7z — rollback KeePass entry on failure
-	if err := sevenzip.Run(cfg.SevenZip.BinaryPath, password, sevenZipArgs); err != nil {
+	if err := pack(password); err != nil {
 		fmt.Println("Archive creation failed, rolling back KeePassXC entry...")
 		if rbErr := kp.DeleteEntry(keePassEntryPath); rbErr != nil {
 			fmt.Printf("Warning: rollback failed — manually delete '%s' from KeePassXC: %v\n", keePassEntryPath, rbErr)
 		} else {
 			fmt.Println("KeePassXC entry rolled back successfully.")
 		}
-		return fmt.Errorf("archive creation failed: %w", err)
+		return "", fmt.Errorf("archive creation failed: %w", err)
 	}
 
-	// 5. Handle split volumes: when --volume is used, 7z creates .7z.001
+	// 4. Handle split volumes: when --volume is used, 7z creates .7z.001
 	//    instead of .7z — fix the entry to point to the real file.
 	realArchivePath := absArchivePath
 	splitPath := absArchivePath + ".001"
@@ -174,24 +474,56 @@ func runAddCreate(
 		if editErr := kp.EditEntryTitle(keePassEntryPath, newTitle, realArchivePath); editErr != nil {
 			fmt.Printf("Note: could not update entry for split archive: %v\n", editErr)
 		} else {
-			keePassEntryPath = filepath.ToSlash(filepath.Clean(cfg.General.DefaultGroup + "/" + newTitle))
+			keePassEntryPath = filepath.ToSlash(filepath.Clean(group + "/" + newTitle))
 		}
 	}
 
-	// 6. Set initial metadata (size + version)
+	// 5. Set initial metadata (size + version)
 	updateMetadata(kp, keePassEntryPath, realArchivePath)
+	recordSources(kp, keePassEntryPath, sources, time.Now())
+	refreshManifest(cfg, kp, password, keePassEntryPath, realArchivePath)
 
-	return nil
+	return keePassEntryPath, nil
+}
+
+// addArchiveEntry stores password in a new UUID-titled entry for the archive
+// at absArchivePath. Returns the entry path and its UUID8.
+//
+// Title = "basename (uuid8)" — path-independent, UUID ensures uniqueness
+// even when two archives share the same filename.
+func addArchiveEntry(kp *keepass.Client, group, absArchivePath string, password []byte) (entryPath, uuid8 string, err error) {
+	uuid8, err = generateUniqueUUID8(kp, group, filepath.Base(absArchivePath))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate entry UUID: %w", err)
+	}
+	entryTitle := makeEntryTitle(filepath.Base(absArchivePath), uuid8)
+
+	if err := kp.AddEntry(
+		group,
+		entryTitle,
+		password,
+		absArchivePath, // Username — human-readable path for reference
+		"https://github.com/lxstig/7zkpxc",
+	); err != nil {
+		return "", "", fmt.Errorf("failed to add entry to KeePassXC: %w", err)
+	}
+
+	return filepath.ToSlash(filepath.Clean(group + "/" + entryTitle)), uuid8, nil
 }
 
 func runAddUpdate(
 	cmd *cobra.Command,
 	archiveName string,
-	files, extraFlags []string,
+	sources []string,
+	items []sourceFile,
+	extraFlags []string,
 ) error {
 	fmt.Printf("Archive '%s' already exists — fetching password from KeePassXC...\n", archiveName)
 
 	return withKeePassArchive(archiveName, false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		if err := refuseTarPayload(kp, entryPath, archiveName); err != nil {
+			return err
+		}
 		// Build 7z update arguments.
 		// Do NOT pass default_args (e.g. -mhe=on) — the archive already has its
 		// encryption settings; re-specifying them may conflict.
@@ -200,15 +532,14 @@ func runAddUpdate(
 		sevenZipArgs := []string{"a"}
 
 		sevenZipArgs = append(sevenZipArgs, getCompressionFlags(cmd)...)
-		sevenZipArgs = append(sevenZipArgs, archiveName)
-		sevenZipArgs = append(sevenZipArgs, files...)
-		sevenZipArgs = append(sevenZipArgs, extraFlags...)
 
-		if err := sevenzip.Run(cfg.SevenZip.BinaryPath, password, sevenZipArgs); err != nil {
+		if err := runFileLists(cfg.SevenZip.BinaryPath, password, sevenZipArgs, archiveName, items, extraFlags); err != nil {
 			return fmt.Errorf("failed to update archive: %w", err)
 		}
 
 		fmt.Println("Files added to existing archive successfully.")
+		recordSources(kp, entryPath, sources, time.Time{})
+		refreshManifest(cfg, kp, password, entryPath, archiveName)
 		return nil
 	})
 }
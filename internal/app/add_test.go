@@ -1,9 +1,13 @@
 package app
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/spf13/cobra"
 )
@@ -198,3 +202,112 @@ func TestUpdateMetadata_NonexistentFile(t *testing.T) {
 	// Should not panic — just return silently
 	updateMetadata(mock, "entry", "/nonexistent/file.7z")
 }
+
+// -------------------------------------------------------------------
+// packStdin
+// -------------------------------------------------------------------
+
+// writeFake7zAddStdin writes a script that mimics "7z a -si": it asks for the
+// password on the terminal and copies stdin to the archive (last argument).
+func writeFake7zAddStdin(t *testing.T) string {
+	t.Helper()
+	script := `#!/bin/sh
+printf 'Enter password (will not be echoed):'
+read -r pw < /dev/tty
+for last; do :; done
+if [ "$pw" != "s3cret" ]; then
+  echo "ERROR: Wrong password"
+  exit 2
+fi
+cat > "$last"
+`
+	path := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPackStdin(t *testing.T) {
+	bin := writeFake7zAddStdin(t)
+	archive := filepath.Join(t.TempDir(), "db.7z")
+
+	pack := packStdin(bin, []string{"a"}, archive, "dump.sql", nil, strings.NewReader("SELECT 1;\n"))
+	if err := pack([]byte("s3cret")); err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	if data, _ := os.ReadFile(archive); string(data) != "SELECT 1;\n" {
+		t.Errorf("archive = %q, want the piped data", data)
+	}
+}
+
+func TestPackStdin_FailureRemovesArchive(t *testing.T) {
+	bin := writeFake7zAddStdin(t)
+	tests := map[string]struct {
+		input    io.Reader
+		password string
+	}{
+		"empty input":    {strings.NewReader(""), "s3cret"},
+		"read error":     {iotest.ErrReader(errors.New("broken pipe")), "s3cret"},
+		"wrong password": {strings.NewReader("data"), "wrong"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "db.7z")
+			pack := packStdin(bin, []string{"a"}, archive, "dump.sql", nil, tt.input)
+			if err := pack([]byte(tt.password)); err == nil {
+				t.Fatal("expected an error")
+			}
+			if _, err := os.Stat(archive); err == nil {
+				t.Error("partial archive was left behind")
+			}
+		})
+	}
+}
+
+func TestPackProducer(t *testing.T) {
+	bin := writeFake7zAddStdin(t)
+
+	archive := filepath.Join(t.TempDir(), "db.7z")
+	pack := packProducer(bin, []string{"a"}, archive, "dump.sql", nil, []string{"printf", "SELECT 1;"})
+	if err := pack([]byte("s3cret")); err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	if data, _ := os.ReadFile(archive); string(data) != "SELECT 1;" {
+		t.Errorf("archive = %q, want the producer's output", data)
+	}
+
+	// A producer that fails after writing its output still rolls back
+	archive = filepath.Join(t.TempDir(), "db.7z")
+	pack = packProducer(bin, []string{"a"}, archive, "dump.sql", nil, []string{"sh", "-c", "printf partial; exit 3"})
+	err := pack([]byte("s3cret"))
+	if err == nil || !strings.Contains(err.Error(), "'sh' failed") {
+		t.Fatalf("expected the producer's failure, got %v", err)
+	}
+	if _, err := os.Stat(archive); err == nil {
+		t.Error("archive of a failed producer was left behind")
+	}
+}
+
+func TestCheckStdinSource(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "old.7z")
+	if err := os.WriteFile(existing, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &cobra.Command{}
+	cmd.Flags().StringArray("exclude", nil, "")
+	cmd.Flags().StringArray("include", nil, "")
+
+	if err := checkStdinSource(cmd, filepath.Join(dir, "new.7z"), "dump.sql", []string{"file.txt"}, nil); err == nil {
+		t.Error("files together with --stdin-name should be refused")
+	}
+	for _, name := range []string{"/etc/passwd", "../dump.sql"} {
+		if err := checkStdinSource(cmd, filepath.Join(dir, "new.7z"), name, nil, nil); err == nil {
+			t.Errorf("name %q should be refused", name)
+		}
+	}
+	if err := checkStdinSource(cmd, existing, "dump.sql", nil, nil); err == nil {
+		t.Error("an existing archive should be refused")
+	}
+}
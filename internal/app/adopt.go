@@ -0,0 +1,396 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <archive_path>",
+	Short: "Store the password of an existing archive in KeePassXC",
+	Long: `Brings an existing password-protected archive under 7zkpxc management.
+
+The archive password is read from the terminal without echo, verified
+against the archive, and stored in a new KeePassXC entry exactly like one
+created by '7zkpxc a'.
+
+Bulk mode reads a manifest of archive paths and passwords from an open file
+descriptor, so the passwords never have to be written to disk:
+
+  7zkpxc adopt --manifest-fd 3 3< <(gpg -d legacy-passwords.csv.gpg)
+
+CSV manifests have two columns (path,password; a header row is optional).
+JSON manifests are either {"path": "password", ...} or
+[{"path": "...", "password": "..."}, ...].`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if fd, _ := cmd.Flags().GetInt("manifest-fd"); fd >= 0 {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE:    runAdopt,
+	GroupID: "actions",
+}
+
+func init() {
+	adoptCmd.Flags().Int("manifest-fd", -1, "Read a path→password manifest from this file descriptor (bulk mode)")
+	adoptCmd.Flags().String("format", "", "Manifest format: csv or json (default: auto-detect)")
+	rootCmd.AddCommand(adoptCmd)
+}
+
+// adoptItem is a single archive/password pair to adopt.
+type adoptItem struct {
+	Path     string
+	Password []byte
+}
+
+// adoptResult tracks the outcome of adopting a single archive.
+type adoptResult struct {
+	Archive string
+	Status  string // "adopted", "managed", "wrong_password", "unencrypted", "error"
+	Detail  string
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	fd, _ := cmd.Flags().GetInt("manifest-fd")
+	if fd >= 0 {
+		format, _ := cmd.Flags().GetString("format")
+		return runAdoptBulk(cfg, fd, format)
+	}
+
+	archivePath := args[0]
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+	if err := ensureArchiveExists(absPath); err != nil {
+		return err
+	}
+	absFirst := resolveFirstVolume(absPath)
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	// Check for an existing entry before asking for the archive password
+	if managed, err := isManagedArchive(cfg.SevenZip.BinaryPath, kp, cfg.General.DefaultGroup, absFirst); err != nil {
+		return err
+	} else if managed {
+		return fmt.Errorf("'%s' already has a KeePassXC entry — nothing to adopt", filepath.Base(archivePath))
+	}
+
+	fmt.Fprintf(os.Stderr, "Archive password for '%s': ", filepath.Base(archivePath))
+	password, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	r := adoptWithPassword(cfg, kp, absFirst, password)
+	if r.Status == "adopted" {
+		fmt.Printf("Success! '%s' adopted as '%s'.\n", r.Archive, r.Detail)
+		return nil
+	}
+	return fmt.Errorf("could not adopt '%s': %s", r.Archive, r.Detail)
+}
+
+// runAdoptBulk adopts every archive listed in the manifest read from fd.
+// Each archive is reported individually; one KeePassXC unlock covers all.
+func runAdoptBulk(cfg *config.Config, fd int, format string) error {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if f == nil {
+		return fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	defer func() { _ = f.Close() }()
+
+	items, err := parseAdoptManifest(f, format)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, item := range items {
+			for i := range item.Password {
+				item.Password[i] = 0
+			}
+		}
+	}()
+	if len(items) == 0 {
+		fmt.Println("Manifest is empty — nothing to adopt.")
+		return nil
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	var results []adoptResult
+	for i, item := range items {
+		fmt.Printf("[%d/%d] %s — ", i+1, len(items), filepath.Base(item.Path))
+		r := adoptArchive(cfg, kp, item)
+		switch r.Status {
+		case "adopted":
+			fmt.Printf("✓ adopted\n")
+		case "managed":
+			fmt.Printf("─ already managed\n")
+		default:
+			fmt.Printf("✗ %s\n", r.Detail)
+		}
+		results = append(results, r)
+	}
+
+	return printAdoptSummary(results)
+}
+
+// adoptArchive verifies item.Password against the archive and, if it is
+// correct, creates the KeePassXC entry and its metadata.
+func adoptArchive(cfg *config.Config, kp *keepass.Client, item adoptItem) adoptResult {
+	basename := filepath.Base(item.Path)
+
+	absPath, err := filepath.Abs(item.Path)
+	if err != nil {
+		absPath = item.Path
+	}
+	if err := ensureArchiveExists(absPath); err != nil {
+		return adoptResult{basename, "error", err.Error()}
+	}
+	absFirst := resolveFirstVolume(absPath)
+
+	if managed, err := isManagedArchive(cfg.SevenZip.BinaryPath, kp, cfg.General.DefaultGroup, absFirst); err != nil {
+		return adoptResult{basename, "error", err.Error()}
+	} else if managed {
+		return adoptResult{basename, "managed", ""}
+	}
+
+	return adoptWithPassword(cfg, kp, absFirst, item.Password)
+}
+
+// adoptWithPassword verifies password against an archive that has no entry
+// yet and creates the entry and its metadata.
+func adoptWithPassword(cfg *config.Config, kp *keepass.Client, absFirst string, password []byte) adoptResult {
+	basename := filepath.Base(absFirst)
+
+	match, _ := verifyArchivePassword(cfg.SevenZip.BinaryPath, password, absFirst)
+	switch match {
+	case sevenzip.MatchFailed:
+		return adoptResult{basename, "wrong_password", "wrong password (or damaged archive)"}
+	case sevenzip.MatchUnencrypted:
+		return adoptResult{basename, "unencrypted", "archive is not encrypted"}
+	}
+
+	entryPath, _, err := addArchiveEntry(kp, cfg.General.DefaultGroup, absFirst, password)
+	if err != nil {
+		return adoptResult{basename, "error", err.Error()}
+	}
+	updateMetadata(kp, entryPath, absFirst)
+
+	return adoptResult{basename, "adopted", entryPath}
+}
+
+// isManagedArchive reports whether absFirst already has a KeePassXC entry:
+// one whose last known path is absFirst, a legacy entry titled with its
+// encoded path, or a same-named entry whose password opens the archive.
+// Entries of same-named archives in other directories do not count, so a
+// second "backup.7z" can be adopted next to the first.
+func isManagedArchive(binaryPath string, kp PasswordProvider, group, absFirst string) (bool, error) {
+	info := AnalyzeArchive(absFirst)
+	absNorm := filepath.Join(filepath.Dir(absFirst), info.NormalizedName)
+	names := []string{filepath.Base(absFirst)}
+	if info.IsSplit {
+		names = append(names, info.NormalizedName)
+	}
+
+	seen := make(map[string]bool)
+	var others []string
+	for _, name := range names {
+		candidates, err := collectCandidates(kp, group, name)
+		if err != nil {
+			return false, err
+		}
+		for _, c := range candidates {
+			if c.LastKnownPath == absFirst || c.LastKnownPath == absNorm {
+				return true, nil
+			}
+			if !seen[c.EntryPath] {
+				seen[c.EntryPath] = true
+				others = append(others, c.EntryPath)
+			}
+		}
+	}
+
+	l := &passwordLookup{kp: kp, prefix: group}
+	for _, p := range []string{absFirst, absNorm} {
+		pass, _, ok, err := l.tryPath(l.buildPath(encodeArchivePath(p)))
+		for i := range pass {
+			pass[i] = 0
+		}
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	for _, entryPath := range others {
+		pass, err := kp.GetPassword(entryPath)
+		if err != nil {
+			return false, err
+		}
+		match, _ := verifyArchivePassword(binaryPath, pass, absFirst)
+		for i := range pass {
+			pass[i] = 0
+		}
+		if match == sevenzip.MatchCorrect {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseAdoptManifest reads a CSV or JSON manifest of path→password pairs.
+// format may be "csv", "json" or empty to detect from the first character.
+func parseAdoptManifest(r io.Reader, format string) ([]adoptItem, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	defer func() {
+		for i := range data {
+			data[i] = 0
+		}
+	}()
+
+	if format == "" {
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			format = "json"
+		} else {
+			format = "csv"
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		return parseAdoptCSV(data)
+	case "json":
+		return parseAdoptJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q (use csv or json)", format)
+	}
+}
+
+func parseAdoptCSV(data []byte) ([]adoptItem, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = 2
+	cr.Comment = '#'
+
+	var items []adoptItem
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(rec[0]), "path") {
+			continue // header row
+		}
+		path := strings.TrimSpace(rec[0])
+		if path == "" || rec[1] == "" {
+			return nil, fmt.Errorf("invalid CSV manifest: empty path or password on record %d", line)
+		}
+		items = append(items, adoptItem{Path: path, Password: []byte(rec[1])})
+	}
+	return items, nil
+}
+
+func parseAdoptJSON(data []byte) ([]adoptItem, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	var items []adoptItem
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var m map[string]string
+		if err := json.Unmarshal(trimmed, &m); err != nil {
+			return nil, fmt.Errorf("invalid JSON manifest: %w", err)
+		}
+		for path, password := range m {
+			items = append(items, adoptItem{Path: path, Password: []byte(password)})
+		}
+	} else {
+		var list []struct {
+			Path     string `json:"path"`
+			Password string `json:"password"`
+		}
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return nil, fmt.Errorf("invalid JSON manifest: %w", err)
+		}
+		for _, e := range list {
+			items = append(items, adoptItem{Path: e.Path, Password: []byte(e.Password)})
+		}
+	}
+
+	for i, item := range items {
+		if item.Path == "" || len(item.Password) == 0 {
+			return nil, fmt.Errorf("invalid JSON manifest: empty path or password in item %d", i+1)
+		}
+	}
+
+	// Maps have no order; keep the report stable
+	sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return items, nil
+}
+
+func printAdoptSummary(results []adoptResult) error {
+	var adopted, managed, failed []string
+	for _, r := range results {
+		switch r.Status {
+		case "adopted":
+			adopted = append(adopted, r.Archive)
+		case "managed":
+			managed = append(managed, r.Archive)
+		default:
+			failed = append(failed, r.Archive)
+		}
+	}
+
+	fmt.Println("─────────────────────────────────")
+	fmt.Println("Adopt Summary:")
+	if len(adopted) > 0 {
+		fmt.Printf("  ✓ Adopted:   %d  (%s)\n", len(adopted), joinWords(adopted))
+	}
+	if len(managed) > 0 {
+		fmt.Printf("  ─ Managed:   %d  (already had an entry)\n", len(managed))
+	}
+	if len(failed) > 0 {
+		fmt.Printf("  ✗ Failed:    %d  (%s)\n", len(failed), joinWords(failed))
+	}
+	fmt.Println("─────────────────────────────────")
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d archive(s) could not be adopted", len(failed))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAdoptManifest_CSV(t *testing.T) {
+	input := "path,password\n/data/a.7z,secret1\n# legacy\n\"/data/b c.zip\",\"pa,ss\"\n"
+	items, err := parseAdoptManifest(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatalf("parseAdoptManifest: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	if items[0].Path != "/data/a.7z" || string(items[0].Password) != "secret1" {
+		t.Errorf("item 0 = %q/%q", items[0].Path, items[0].Password)
+	}
+	if items[1].Path != "/data/b c.zip" || string(items[1].Password) != "pa,ss" {
+		t.Errorf("item 1 = %q/%q", items[1].Path, items[1].Password)
+	}
+}
+
+func TestParseAdoptManifest_CSVWithoutHeader(t *testing.T) {
+	items, err := parseAdoptManifest(strings.NewReader("a.7z, keeps leading space\n"), "csv")
+	if err != nil {
+		t.Fatalf("parseAdoptManifest: %v", err)
+	}
+	if len(items) != 1 || string(items[0].Password) != " keeps leading space" {
+		t.Errorf("passwords must be taken verbatim, got %+v", items)
+	}
+}
+
+func TestParseAdoptManifest_JSONMap(t *testing.T) {
+	input := `{"z.7z": "p2", "a.7z": "p1"}`
+	items, err := parseAdoptManifest(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatalf("parseAdoptManifest: %v", err)
+	}
+	if len(items) != 2 || items[0].Path != "a.7z" || items[1].Path != "z.7z" {
+		t.Errorf("expected items sorted by path, got %+v", items)
+	}
+}
+
+func TestParseAdoptManifest_JSONList(t *testing.T) {
+	input := `[{"path": "b.7z", "password": "x"}, {"path": "a.7z", "password": "y"}]`
+	items, err := parseAdoptManifest(strings.NewReader(input), "json")
+	if err != nil {
+		t.Fatalf("parseAdoptManifest: %v", err)
+	}
+	if len(items) != 2 || items[0].Path != "a.7z" || string(items[0].Password) != "y" {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func TestParseAdoptManifest_Invalid(t *testing.T) {
+	tests := []struct {
+		name, input, format, want string
+	}{
+		{"format", "a,b", "yaml", "unsupported manifest format"},
+		{"csv columns", "a.7z,p,extra\n", "csv", "invalid CSV manifest"},
+		{"csv empty password", "a.7z,\n", "csv", "empty path or password"},
+		{"json syntax", "[{", "", "invalid JSON manifest"},
+		{"json empty password", `[{"path":"a.7z"}]`, "", "empty path or password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAdoptManifest(strings.NewReader(tt.input), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestIsManagedArchive(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	addUUIDEntry(mock, "backups", "archive.7z", "a3b2c1d0", "/home/user/archive.7z", []byte("secret"))
+
+	managed, err := isManagedArchive("7z", mock, "backups", "/home/user/archive.7z")
+	if err != nil || !managed {
+		t.Errorf("isManagedArchive(archive.7z) = %v, %v; want true, nil", managed, err)
+	}
+
+	managed, err = isManagedArchive("7z", mock, "backups", "/home/user/legacy.7z")
+	if err != nil || managed {
+		t.Errorf("isManagedArchive(legacy.7z) = %v, %v; want false, nil", managed, err)
+	}
+}
+
+func TestIsManagedArchive_SameNameElsewhere(t *testing.T) {
+	script := `#!/bin/sh
+printf 'Enter password (will not be echoed):' >&2
+read -r pw
+if [ "$pw" != "pass2" ]; then
+  echo "ERROR: Wrong password" >&2
+  exit 2
+fi
+echo "Everything is Ok"
+`
+	bin := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockPasswordProvider()
+	addUUIDEntry(mock, "backups", "backup.7z", "a3b2c1d0", "/cloud/backup.7z", []byte("pass1"))
+
+	// One same-named entry for another path is not this archive's entry
+	managed, err := isManagedArchive(bin, mock, "backups", "/other/backup.7z")
+	if err != nil || managed {
+		t.Errorf("isManagedArchive with a foreign entry = %v, %v; want false, nil", managed, err)
+	}
+
+	// ...unless its password opens the archive (e.g. the archive was moved)
+	addUUIDEntry(mock, "backups", "backup.7z", "f1e2d3c4", "/local/backup.7z", []byte("pass2"))
+	managed, err = isManagedArchive(bin, mock, "backups", "/other/backup.7z")
+	if err != nil || !managed {
+		t.Errorf("isManagedArchive with a matching password = %v, %v; want true, nil", managed, err)
+	}
+}
@@ -179,18 +179,39 @@ func TestSubcommands_Registered(t *testing.T) {
 	// We need to check if commands are children of root
 
 	found := map[string]bool{
-		"init":    false,
-		"a":       false,
-		"x":       false,
-		"l":       false,
-		"d":       false,
-		"remove":  false,
-		"t":       false,
-		"e":       false,
-		"rn":      false,
-		"u":       false,
-		"mv":      false,
-		"version": false,
+		"init":          false,
+		"a":             false,
+		"x":             false,
+		"l":             false,
+		"d":             false,
+		"remove":        false,
+		"t":             false,
+		"e":             false,
+		"rn":            false,
+		"u":             false,
+		"mv":            false,
+		"rekey":         false,
+		"repack":        false,
+		"adopt":         false,
+		"convert":       false,
+		"share":         false,
+		"grant":         false,
+		"split-secret":  false,
+		"escrow":        false,
+		"export":        false,
+		"import":        false,
+		"exec":          false,
+		"edit":          false,
+		"cat":           false,
+		"grep":          false,
+		"diff":          false,
+		"checksum":      false,
+		"find":          false,
+		"restore":       false,
+		"backup":        false,
+		"prune-backups": false,
+		"watch":         false,
+		"version":       false,
 	}
 
 	for _, cmd := range rootCmd.Commands() {
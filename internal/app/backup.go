@@ -0,0 +1,238 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <profile> | --all",
+	Short: "Create a timestamped archive from a backup profile",
+	Long: `Creates a new managed archive from a profile in the "backups:" section
+of the config file: sources, excludes, destination directory, name template,
+KeePassXC group and 7z arguments.
+
+  backups:
+    home:
+      sources: ["~/docs", "~/projects"]
+      excludes: ["node_modules", "*.tmp"]
+      destination: /mnt/backup/home
+      name: "{profile}-{host}-{date}.7z"     # default: {profile}-{date}-{time}.7z
+      group: Archives/Backups                # default: general.default_group
+      args: ["-mhe=on", "-mx=3"]             # default: sevenzip.default_args
+
+{date} is YYYY-MM-DD and {time} is HHMMSS (local time). With --all every
+profile is run after a single database unlock.
+
+With --incremental only files that are new or changed since the profile's
+newest backup (by size, mtime and CRC32 from its stored manifest) are
+archived; deleted paths are recorded as tombstones. The first backup of a
+chain is always a full one. Use 'restore --chain' to replay a chain.
+
+  7zkpxc backup home
+  7zkpxc backup --all --incremental`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if len(args) > 1 || all == (len(args) == 1) {
+			return fmt.Errorf("specify exactly one profile, or --all")
+		}
+		return nil
+	},
+	RunE:    runBackup,
+	GroupID: "actions",
+}
+
+func init() {
+	backupCmd.Flags().Bool("all", false, "Run every backup profile")
+	backupCmd.Flags().Bool("incremental", false, "Only archive changes since the previous backup")
+	rootCmd.AddCommand(backupCmd)
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	incremental, _ := cmd.Flags().GetBool("incremental")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Backups) == 0 {
+		return fmt.Errorf("no backup profiles configured (add a 'backups:' section to the config file)")
+	}
+
+	names := make([]string, 0, len(cfg.Backups))
+	if len(args) == 1 {
+		name := strings.ToLower(args[0])
+		if _, ok := cfg.Backups[name]; !ok {
+			return fmt.Errorf("unknown backup profile '%s' (configured: %s)", args[0], joinWords(backupProfileNames(cfg)))
+		}
+		names = append(names, name)
+	} else {
+		names = backupProfileNames(cfg)
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	var created, failed []string
+	for _, name := range names {
+		if len(names) > 1 {
+			fmt.Printf("\n=== Backup profile '%s' ===\n", name)
+		}
+		archivePath, err := runBackupProfile(cfg, kp, name, cfg.Backups[name], time.Now(), incremental)
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		if archivePath != "" {
+			created = append(created, archivePath)
+		}
+	}
+
+	if len(names) > 1 {
+		fmt.Println("\n─────────────────────────────────")
+		fmt.Printf("Backups: %d created, %d failed\n", len(created), len(failed))
+		for _, p := range created {
+			fmt.Printf("  ✓ %s\n", p)
+		}
+		for _, name := range failed {
+			fmt.Printf("  ✗ %s\n", name)
+		}
+		fmt.Println("─────────────────────────────────")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("backup failed for %s", joinWords(failed))
+	}
+	return nil
+}
+
+// backupProfileNames returns the configured profile names, sorted.
+func backupProfileNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Backups))
+	for name := range cfg.Backups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runBackupProfile creates one archive for profile and returns its path, or
+// "" when an incremental backup found nothing to do.
+func runBackupProfile(cfg *config.Config, kp *keepass.Client, name string, p config.BackupProfile, now time.Time, incremental bool) (string, error) {
+	archivePath, sources, err := planBackup(name, p, now)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(archivePath); err == nil {
+		return "", fmt.Errorf("'%s' already exists — add {time} to the name template", archivePath)
+	}
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0o755); err != nil {
+		return "", fmt.Errorf("cannot create destination: %w", err)
+	}
+
+	group := p.Group
+	if group == "" {
+		group = cfg.General.DefaultGroup
+	}
+	if !kp.GroupExists(group) {
+		fmt.Printf("Creating KeePassXC group '%s'...\n", group)
+		if err := kp.Mkdir(group); err != nil {
+			return "", err
+		}
+	}
+
+	presets := p.Args
+	if len(presets) == 0 {
+		presets = cfg.SevenZip.DefaultArgs
+	}
+	if incremental {
+		archivePath, err := createIncrement(cfg, kp, name, p, group, archivePath, sources, presets)
+		if !errors.Is(err, errNoBaseline) {
+			return archivePath, err
+		}
+		fmt.Printf("Note: %v — creating a full backup.\n", err)
+	}
+
+	sevenZipArgs := append([]string{"a"}, presets...)
+
+	items := make([]sourceFile, 0, len(sources))
+	for _, src := range sources {
+		items = append(items, sourceFile{Rel: src})
+	}
+	pack := packWithArgs(cfg.SevenZip.BinaryPath, sevenZipArgs, archivePath, items, backupExcludeArgs(p.Excludes))
+	if _, err := createManagedArchive(cfg, kp, group, archivePath, sources, pack); err != nil {
+		return "", err
+	}
+	return archivePath, nil
+}
+
+// planBackup validates a profile and resolves its archive path and sources
+// (with "~" expanded). Every source must exist.
+func planBackup(name string, p config.BackupProfile, now time.Time) (archivePath string, sources []string, err error) {
+	if len(p.Sources) == 0 {
+		return "", nil, fmt.Errorf("profile '%s' has no sources", name)
+	}
+	if p.Destination == "" {
+		return "", nil, fmt.Errorf("profile '%s' has no destination", name)
+	}
+
+	for _, src := range p.Sources {
+		src = expandTilde(src)
+		if _, err := os.Stat(src); err != nil {
+			return "", nil, fmt.Errorf("source '%s': %w", src, err)
+		}
+		sources = append(sources, src)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	archiveName := renderBackupName(p.Name, name, host, now)
+	if strings.ContainsRune(archiveName, os.PathSeparator) || archiveName == "" {
+		return "", nil, fmt.Errorf("profile '%s': name template must yield a file name, got '%s'", name, archiveName)
+	}
+	return filepath.Join(expandTilde(p.Destination), archiveName), sources, nil
+}
+
+// renderBackupName fills in a name template (BackupNameDefault when empty)
+// and makes sure the result ends in ".7z".
+func renderBackupName(template, profile, host string, now time.Time) string {
+	return expandBackupName(template, profile, host, now.Format("2006-01-02"), now.Format("150405"))
+}
+
+func expandBackupName(template, profile, host, date, clock string) string {
+	if template == "" {
+		template = config.BackupNameDefault
+	}
+	name := strings.NewReplacer(
+		"{profile}", profile,
+		"{host}", host,
+		"{date}", date,
+		"{time}", clock,
+	).Replace(template)
+	if !strings.HasSuffix(strings.ToLower(name), ".7z") {
+		name += ".7z"
+	}
+	return name
+}
+
+// backupExcludeArgs turns profile excludes into recursive 7z exclude switches.
+func backupExcludeArgs(excludes []string) []string {
+	args := make([]string, 0, len(excludes))
+	for _, ex := range excludes {
+		args = append(args, "-xr!"+ex)
+	}
+	return args
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+)
+
+func TestRenderBackupName(t *testing.T) {
+	now := time.Date(2024, 3, 9, 7, 5, 3, 0, time.Local)
+	tests := []struct {
+		template, want string
+	}{
+		{"", "home-2024-03-09-070503.7z"},
+		{"{profile}-{host}-{date}.7z", "home-box-2024-03-09.7z"},
+		{"{host}.{profile}", "box.home.7z"},
+		{"nightly-{time}.7Z", "nightly-070503.7Z"},
+	}
+	for _, tt := range tests {
+		if got := renderBackupName(tt.template, "home", "box", now); got != tt.want {
+			t.Errorf("renderBackupName(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestBackupExcludeArgs(t *testing.T) {
+	got := backupExcludeArgs([]string{"node_modules", "*.tmp"})
+	want := []string{"-xr!node_modules", "-xr!*.tmp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backupExcludeArgs = %v, want %v", got, want)
+	}
+}
+
+func TestPlanBackup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.Mkdir(filepath.Join(home, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 9, 7, 5, 3, 0, time.Local)
+
+	p := config.BackupProfile{Sources: []string{"~/docs"}, Destination: "~/backups", Name: "{profile}-{date}"}
+	archivePath, sources, err := planBackup("home", p, now)
+	if err != nil {
+		t.Fatalf("planBackup: %v", err)
+	}
+	if archivePath != filepath.Join(home, "backups", "home-2024-03-09.7z") {
+		t.Errorf("archivePath = %q", archivePath)
+	}
+	if !reflect.DeepEqual(sources, []string{filepath.Join(home, "docs")}) {
+		t.Errorf("sources = %v", sources)
+	}
+}
+
+func TestPlanBackup_Invalid(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Now()
+
+	tests := map[string]struct {
+		profile config.BackupProfile
+		want    string
+	}{
+		"no sources":     {config.BackupProfile{Destination: home}, "no sources"},
+		"no destination": {config.BackupProfile{Sources: []string{home}}, "no destination"},
+		"missing source": {config.BackupProfile{Sources: []string{"~/nope"}, Destination: home}, "nope"},
+		"bad template":   {config.BackupProfile{Sources: []string{home}, Destination: home, Name: "a/{date}"}, "file name"},
+	}
+	for name, tt := range tests {
+		if _, _, err := planBackup("p", tt.profile, now); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want mention of %q", name, err, tt.want)
+		}
+	}
+}
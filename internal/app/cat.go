@@ -0,0 +1,93 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat <archive> <path-in-archive>",
+	Short: "Write a file from an archive to stdout",
+	Long: `Decrypts a single file and writes its bytes to standard output, so it
+can be piped into another program:
+
+  7zkpxc cat db.7z dump.sql | psql mydb
+  7zkpxc cat configs.7z etc/app.conf | less
+
+Only the file data goes to stdout. Prompts, progress and errors go to
+stderr, and 7z's exit code is passed on as the exit status.`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runCat,
+	GroupID: "actions",
+}
+
+func init() {
+	catCmd.Flags().String("identity", "", "age identity file for the archive's grant sidecar, used when KeePassXC has no entry (overrides general.age_identity)")
+	catCmd.Flags().Bool("shares", false, "Recombine the password from Shamir shares typed on the terminal")
+	catCmd.MarkFlagsMutuallyExclusive("identity", "shares")
+	rootCmd.AddCommand(catCmd)
+}
+
+func runCat(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+
+	inner, err := cleanInnerPath(args[1])
+	if err != nil {
+		return err
+	}
+
+	identity, _ := cmd.Flags().GetString("identity")
+	useShares, _ := cmd.Flags().GetBool("shares")
+
+	op := func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		return catFile(cfg.SevenZip.BinaryPath, password, archivePath, inner, os.Stdout)
+	}
+
+	if useShares {
+		return withSecretShares(archivePath, op)
+	}
+	// Read-only: housekeeping would print its notes on stdout
+	return withKeePassArchiveIdentity(archivePath, identity, true, op)
+}
+
+// catFile streams inner from the archive to w. 7z's own messages are only
+// shown (on stderr) when something went wrong.
+func catFile(binaryPath string, password []byte, archivePath, inner string, w io.Writer) error {
+	var msgs bytes.Buffer
+	err := sevenzip.Stream(binaryPath, password, []string{"x", "-so", "-y", archivePath, inner}, w, &msgs)
+	if err != nil {
+		if detail := sevenZipErrorLines(msgs.String()); detail != "" {
+			fmt.Fprintln(os.Stderr, detail)
+		}
+		return fmt.Errorf("cannot read '%s' from '%s': %w", inner, archivePath, err)
+	}
+	// 7z exits 0 when the pattern matches nothing
+	if strings.Contains(msgs.String(), "No files to process") {
+		return fmt.Errorf("'%s' not found in '%s'", inner, archivePath)
+	}
+	return nil
+}
+
+// sevenZipErrorLines returns the lines of 7z output that report errors or
+// warnings, dropping banners and progress.
+func sevenZipErrorLines(output string) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "error") || strings.HasPrefix(lower, "warning") ||
+			strings.Contains(lower, "wrong password") || strings.Contains(lower, "cannot") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFake7z writes a script that mimics "7z x -so": it prompts for the
+// password on stderr and prints the requested file (last argument) to stdout.
+func writeFake7z(t *testing.T) string {
+	t.Helper()
+	script := `#!/bin/sh
+printf 'Enter password (will not be echoed):' >&2
+read -r pw
+for last; do :; done
+if [ "$pw" != "s3cret" ]; then
+  echo "ERROR: Wrong password : $3" >&2
+  exit 2
+fi
+if [ "$last" = "dump.sql" ]; then
+  printf 'SELECT 1;\n'
+else
+  echo "No files to process" >&2
+fi
+`
+	path := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCatFile(t *testing.T) {
+	bin := writeFake7z(t)
+
+	var out bytes.Buffer
+	if err := catFile(bin, []byte("s3cret"), "db.7z", "dump.sql", &out); err != nil {
+		t.Fatalf("catFile: %v", err)
+	}
+	if out.String() != "SELECT 1;\n" {
+		t.Errorf("stdout = %q, want the file bytes only", out.String())
+	}
+
+	out.Reset()
+	err := catFile(bin, []byte("s3cret"), "db.7z", "missing.sql", &out)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+
+	err = catFile(bin, []byte("wrong"), "db.7z", "dump.sql", &out)
+	if got := ExitCode(err); got != 2 {
+		t.Errorf("ExitCode(%v) = %d, want 7z's code 2", err, got)
+	}
+}
+
+func TestSevenZipErrorLines(t *testing.T) {
+	output := "7-Zip 23.01 (x64)\nScanning the drive for archives:\nERROR: Wrong password : dump.sql\nEverything is Ok\n"
+	if got := sevenZipErrorLines(output); got != "ERROR: Wrong password : dump.sql" {
+		t.Errorf("sevenZipErrorLines = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var checksumCmd = &cobra.Command{
+	Use:   "checksum <archive> [sources...]",
+	Short: "Verify the content digest of a tar or reproducible archive",
+	Long: `Reads the tar stream inside an archive created with 'a --tar' (with
+or without --reproducible) back through 7z and prints its SHA-256 (sha256sum
+format), checked against the digest recorded on the entry at creation.
+
+The archive file itself never hashes the same twice — every archive has its
+own password and salt — so this content digest is what audit trails compare.
+For a reproducible archive, sources rebuild the normalized tar in memory
+(with the SOURCE_DATE_EPOCH recorded on the entry) and compare its digest
+too, proving the archive holds exactly those files. Sources are given as to
+'a', without --exclude or --files-from.
+
+Exit status is 0 when every digest matches, 1 on a mismatch.
+
+  7zkpxc checksum release.7z
+  7zkpxc checksum release.7z dist/`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runChecksum,
+	GroupID: "actions",
+}
+
+func init() {
+	rootCmd.AddCommand(checksumCmd)
+}
+
+func runChecksum(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath, sources := args[0], args[1:]
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		notes, _ := kp.GetAttribute(entryPath, "Notes")
+		meta := parseMetadata(notes)
+		if meta.Payload != tarPayload {
+			return fmt.Errorf("'%s' has no content digest: only archives made with 'a --tar' hold a single tar stream", filepath.Base(archivePath))
+		}
+		if len(sources) > 0 && !meta.Reproducible {
+			return fmt.Errorf("'%s' was not made with 'a --tar --reproducible' — its content cannot be rebuilt from sources", filepath.Base(archivePath))
+		}
+
+		digest, err := payloadDigest(cfg, password, archivePath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s  %s\n", digest, filepath.Base(archivePath))
+
+		mismatch := false
+		switch {
+		case meta.SHA256 == "":
+			fmt.Println("  ⚠ no digest was recorded at creation")
+		case meta.SHA256 == digest:
+			fmt.Println("  ✓ matches the digest recorded at creation")
+		default:
+			fmt.Printf("  ✗ differs from the digest recorded at creation (%s)\n", meta.SHA256)
+			mismatch = true
+		}
+
+		if len(sources) > 0 {
+			items := make([]sourceFile, 0, len(sources))
+			for _, src := range sources {
+				items = append(items, sourceFile{Rel: src})
+			}
+			opts := tarWriteOptions{Reproducible: true, Epoch: time.Unix(meta.Epoch, 0).UTC()}
+			rebuilt, err := tarDigest(items, opts)
+			if err != nil {
+				return fmt.Errorf("rebuilding from sources: %w", err)
+			}
+			if rebuilt == digest {
+				fmt.Println("  ✓ matches a reproducible build of the sources")
+			} else {
+				fmt.Printf("  ✗ the sources build to %s\n", rebuilt)
+				mismatch = true
+			}
+		}
+
+		if mismatch {
+			return withExitCode(fmt.Errorf("checksum mismatch for '%s'", filepath.Base(archivePath)), 1)
+		}
+		return nil
+	})
+}
+
+// payloadDigest streams the tar inside an archive through SHA-256.
+func payloadDigest(cfg *config.Config, password []byte, archivePath string) (string, error) {
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+	h := sha256.New()
+	if err := sevenzip.Stream(cfg.SevenZip.BinaryPath, password, []string{"x", "-so", "-y", absPath}, h, nil); err != nil {
+		return "", fmt.Errorf("reading '%s' failed: %w", filepath.Base(archivePath), err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
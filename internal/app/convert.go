@@ -0,0 +1,268 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <input_or_directory>",
+	Short: "Convert a ZIP or tarball into a managed encrypted 7z archive",
+	Long: `Converts an unencrypted foreign archive into an encrypted 7z archive whose
+password is stored in KeePassXC, exactly like one created by '7zkpxc a'.
+
+Supported inputs: .zip, .tar, .tar.gz/.tgz, .tar.bz2/.tbz2,
+.tar.xz/.txz (needs xz) and .tar.zst/.tzst (needs zstd).
+
+The contents are unpacked into a private temp directory (paths and
+modification times preserved), packed into the new archive, and the new
+archive is listed to confirm every file is present with the same size.
+Hard links become regular copies. Symlinks and device nodes are not carried
+over and are reported; --remove-original then leaves such an input alone.
+
+Single file:
+  7zkpxc convert photos.tar.zst                 # → photos.7z
+  7zkpxc convert -o vault/photos.7z photos.zip
+
+All convertible files in a directory:
+  7zkpxc convert --remove-original ~/incoming/`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runConvert,
+	GroupID: "actions",
+}
+
+func init() {
+	convertCmd.Flags().StringP("output", "o", "", "Output archive path (single file only; default: <name>.7z next to the input)")
+	convertCmd.Flags().Bool("remove-original", false, "Securely delete the input after a successful, verified conversion")
+	rootCmd.AddCommand(convertCmd)
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	output, _ := cmd.Flags().GetString("output")
+	removeOriginal, _ := cmd.Flags().GetBool("remove-original")
+
+	absInput, err := filepath.Abs(args[0])
+	if err != nil {
+		absInput = args[0]
+	}
+	info, err := os.Stat(absInput)
+	if err != nil {
+		return fmt.Errorf("cannot access '%s': %w", args[0], err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		if output != "" {
+			return fmt.Errorf("--output cannot be used with a directory")
+		}
+		return convertDirectory(cfg, absInput, removeOriginal)
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	absOutput, err := convertFile(cfg, kp, absInput, output, removeOriginal)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Success! '%s' converted to '%s'.\n", filepath.Base(absInput), absOutput)
+	return nil
+}
+
+// convertFile converts a single foreign archive and returns the path of the
+// new managed archive. output may be empty for "<stem>.7z" next to src.
+func convertFile(cfg *config.Config, kp *keepass.Client, absSrc, output string, removeOriginal bool) (string, error) {
+	format, stem, ok := detectSourceFormat(absSrc)
+	if !ok {
+		return "", fmt.Errorf("'%s' is not a supported input (zip, tar, tar.gz, tar.bz2, tar.xz, tar.zst)", filepath.Base(absSrc))
+	}
+
+	if output == "" {
+		output = filepath.Join(filepath.Dir(absSrc), stem+".7z")
+	} else if filepath.Ext(output) == "" {
+		output += ".7z"
+	}
+	absOut, err := filepath.Abs(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output path: %w", err)
+	}
+	if _, err := os.Stat(absOut); err == nil {
+		return "", fmt.Errorf("output '%s' already exists — refusing to overwrite", absOut)
+	}
+
+	plainDir, err := newPrivateTempDir("7zkpxc-convert-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = wipeDir(plainDir) }()
+
+	fmt.Printf("Unpacking '%s' (%s) into private temp directory...\n", filepath.Base(absSrc), format.Name)
+	unpacked, err := unpackSource(absSrc, format, plainDir)
+	if err != nil {
+		return "", err
+	}
+	if len(unpacked.Skipped) > 0 {
+		// The archive will not hold everything the input does
+		if removeOriginal {
+			return "", fmt.Errorf("'%s' has %d entries that cannot be carried over (%s) — refusing --remove-original; convert without it to keep the input", filepath.Base(absSrc), len(unpacked.Skipped), joinWords(unpacked.Skipped))
+		}
+		fmt.Printf("Warning: %d non-regular entries not carried over: %s\n", len(unpacked.Skipped), joinWords(unpacked.Skipped))
+	}
+	if len(unpacked.Files) == 0 {
+		return "", fmt.Errorf("'%s' contains no regular files — nothing to convert", filepath.Base(absSrc))
+	}
+
+	fmt.Printf("Generating %d-character secure password...\n", cfg.General.PasswordLength)
+	password, err := kp.GeneratePassword(cfg.General.PasswordLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	fmt.Printf("Saving entry to KeePassXC (%s)...\n", cfg.General.KdbxPath)
+	entryPath, _, err := addArchiveEntry(kp, cfg.General.DefaultGroup, absOut, password)
+	if err != nil {
+		return "", err
+	}
+
+	rollback := func() {
+		_ = os.Remove(absOut)
+		fmt.Println("Conversion failed, rolling back KeePassXC entry...")
+		if rbErr := kp.DeleteEntry(entryPath); rbErr != nil {
+			fmt.Printf("Warning: rollback failed — manually delete '%s' from KeePassXC: %v\n", entryPath, rbErr)
+		} else {
+			fmt.Println("KeePassXC entry rolled back successfully.")
+		}
+	}
+
+	fmt.Printf("Creating archive '%s'...\n", filepath.Base(absOut))
+	args := []string{"a"}
+	args = append(args, cfg.SevenZip.DefaultArgs...)
+	args = append(args, "-p", absOut, filepath.Join(plainDir, "*"))
+	if err := sevenzip.RunQuiet(cfg.SevenZip.BinaryPath, password, args); err != nil {
+		rollback()
+		return "", fmt.Errorf("archive creation failed: %w", err)
+	}
+
+	fmt.Println("Verifying file count and sizes...")
+	entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, password, absOut)
+	if err == nil {
+		err = compareUnpacked(unpacked.Files, entries)
+	}
+	if err != nil {
+		rollback()
+		return "", fmt.Errorf("verification of '%s' failed (input left untouched): %w", filepath.Base(absOut), err)
+	}
+	fmt.Printf("  ✓ %d file(s) verified\n", len(unpacked.Files))
+
+	updateMetadata(kp, entryPath, absOut)
+
+	if removeOriginal {
+		fmt.Printf("Securely removing '%s'...\n", filepath.Base(absSrc))
+		if err := wipeFile(absSrc); err != nil {
+			fmt.Printf("Warning: failed to remove original '%s': %v\n", absSrc, err)
+		}
+	}
+
+	return absOut, nil
+}
+
+// compareUnpacked checks that every unpacked regular file appears in the
+// archive listing with the same size, and that the archive holds no extra files.
+func compareUnpacked(files map[string]int64, entries []sevenzip.Entry) error {
+	listed := make(map[string]int64)
+	for _, e := range entries {
+		if !e.IsDir {
+			listed[filepath.ToSlash(e.Path)] = e.Size
+		}
+	}
+
+	var problems []string
+	for rel, size := range files {
+		got, ok := listed[rel]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("missing '%s'", rel))
+		case got != size:
+			problems = append(problems, fmt.Sprintf("'%s' is %d bytes, expected %d", rel, got, size))
+		}
+	}
+	for rel := range listed {
+		if _, ok := files[rel]; !ok {
+			problems = append(problems, fmt.Sprintf("unexpected '%s'", rel))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("archive has %d file(s), expected %d: %s", len(listed), len(files), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// convertDirectory converts every supported file directly inside absDir
+// with a single KeePassXC unlock.
+func convertDirectory(cfg *config.Config, absDir string, removeOriginal bool) error {
+	dirEntries, err := os.ReadDir(absDir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var inputs []string
+	for _, e := range dirEntries {
+		if e.Type().IsRegular() {
+			if _, _, ok := detectSourceFormat(e.Name()); ok {
+				inputs = append(inputs, filepath.Join(absDir, e.Name()))
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		fmt.Printf("No convertible archives found in '%s'.\n", absDir)
+		return nil
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	var converted, failed []string
+	for i, input := range inputs {
+		basename := filepath.Base(input)
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(inputs), basename)
+		if _, err := convertFile(cfg, kp, input, "", removeOriginal); err != nil {
+			fmt.Printf("  ⚡ %v\n", err)
+			failed = append(failed, basename)
+			continue
+		}
+		converted = append(converted, basename)
+	}
+
+	fmt.Println("─────────────────────────────────")
+	fmt.Println("Convert Summary:")
+	fmt.Printf("  ✓ Converted: %d\n", len(converted))
+	if len(failed) > 0 {
+		fmt.Printf("  ⚡ Failed:    %d  (%s)\n", len(failed), joinWords(failed))
+	}
+	fmt.Println("─────────────────────────────────")
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d archive(s) could not be converted", len(failed))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+func TestCompareUnpacked_Match(t *testing.T) {
+	files := map[string]int64{"a.txt": 5, "sub/b.txt": 6}
+	entries := []sevenzip.Entry{
+		{Path: "a.txt", Size: 5},
+		{Path: "sub", IsDir: true},
+		{Path: "sub/b.txt", Size: 6},
+	}
+	if err := compareUnpacked(files, entries); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCompareUnpacked_Mismatch(t *testing.T) {
+	files := map[string]int64{"a.txt": 5, "b.txt": 6}
+	entries := []sevenzip.Entry{
+		{Path: "a.txt", Size: 4},
+		{Path: "c.txt", Size: 1},
+	}
+	err := compareUnpacked(files, entries)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, want := range []string{"missing 'b.txt'", "'a.txt' is 4 bytes, expected 5", "unexpected 'c.txt'"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestConvertFile_RemoveOriginalRefusedWithSkipped(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "in.tar")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	_ = tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	_, _ = tw.Write([]byte("x"))
+	_ = tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "a.txt"})
+	_ = tw.Close()
+	_ = f.Close()
+
+	_, err = convertFile(&config.Config{}, nil, src, "", true)
+	if err == nil || !strings.Contains(err.Error(), "refusing --remove-original") {
+		t.Fatalf("expected refusal, got %v", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("input must be left alone: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(src), "in.7z")); !os.IsNotExist(err) {
+		t.Error("no archive must be created")
+	}
+}
@@ -28,6 +28,9 @@ func runDeleteFile(cmd *cobra.Command, args []string) error {
 	filesToDelete := args[1:]
 
 	return withKeePassArchive(archivePath, false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		if err := refuseTarPayload(kp, entryPath, archivePath); err != nil {
+			return err
+		}
 		fmt.Printf("Deleting %d file(s) from '%s'...\n", len(filesToDelete), archivePath)
 
 		sevenZipArgs := []string{"d", archivePath}
@@ -38,6 +41,7 @@ func runDeleteFile(cmd *cobra.Command, args []string) error {
 		}
 
 		fmt.Println("Success! File(s) deleted from archive.")
+		refreshManifest(cfg, kp, password, entryPath, archivePath)
 		return nil
 	})
 }
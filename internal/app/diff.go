@@ -0,0 +1,364 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <archive> <dir|archive>",
+	Short: "Compare an archive with a directory or another archive",
+	Long: `Compares the member list of an archive (paths, sizes, modification
+times and CRC32 from the technical listing) with a directory on disk or
+with a second managed archive. Files on disk are hashed only when their size
+matches; nothing is extracted.
+
+  +  only in the directory / second archive (added)
+  -  only in the archive (removed)
+  ~  in both, but size, CRC32 or mtime differ (changed)
+
+Archives created with 'a --tar' are compared through the file manifest
+stored on their entry (the tar's files, not the tar itself). Modification
+times are not compared when either side was made with
+'a --tar --reproducible', which normalizes them.
+
+If every member of the archive sits under a folder named like the
+directory (e.g. "photos/..." compared with ~/photos), that folder is
+stripped before comparing.
+
+Exit status is 0 when both sides match, 1 when they differ and 2 on errors.
+
+  7zkpxc diff photos-2024.7z ~/photos
+  7zkpxc diff --json backup.7z backup-copy.7z`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runDiff,
+	GroupID: "actions",
+}
+
+func init() {
+	diffCmd.Flags().Bool("json", false, "Print the report as JSON")
+	diffCmd.Flags().Bool("ignore-mtime", false, "Do not report modification time differences")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// fileState is what diff knows about one file on either side.
+type fileState struct {
+	Size     int64
+	Modified time.Time
+	CRC      string // upper-case hex CRC32; empty if unknown
+}
+
+// diffChange is a file present on both sides whose content or mtime differ.
+type diffChange struct {
+	Path    string   `json:"path"`
+	Reasons []string `json:"reasons"`
+}
+
+// diffReport is the result of comparing an archive with a target.
+type diffReport struct {
+	Archive   string       `json:"archive"`
+	Target    string       `json:"target"`
+	Added     []string     `json:"added"`
+	Removed   []string     `json:"removed"`
+	Changed   []diffChange `json:"changed"`
+	Identical int          `json:"identical"`
+}
+
+// Differs reports whether the two sides are not identical.
+func (r diffReport) Differs() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// diffOptions tunes diffStates. HashTarget, when set, computes the CRC of a
+// target file lazily (directory targets).
+type diffOptions struct {
+	IgnoreMtime bool
+	HashTarget  func(rel string) (string, error)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archive, target := args[0], args[1]
+	asJSON, _ := cmd.Flags().GetBool("json")
+	ignoreMtime, _ := cmd.Flags().GetBool("ignore-mtime")
+
+	report, err := diffArchive(archive, target, ignoreMtime)
+	if err != nil {
+		return withExitCode(err, 2)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return withExitCode(err, 2)
+		}
+	} else {
+		printDiffReport(os.Stdout, report)
+	}
+
+	if report.Differs() {
+		return withExitCode(fmt.Errorf("'%s' and '%s' differ", archive, target), 1)
+	}
+	return nil
+}
+
+func diffArchive(archive, target string, ignoreMtime bool) (diffReport, error) {
+	report := diffReport{Archive: archive, Target: target}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return report, err
+	}
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	left, leftMeta, err := listManagedArchive(cfg, kp, archive)
+	if err != nil {
+		return report, err
+	}
+
+	opts := diffOptions{IgnoreMtime: ignoreMtime}
+	var right map[string]fileState
+	var rightMeta EntryMetadata
+	if info, statErr := os.Stat(target); statErr == nil && info.IsDir() {
+		if right, err = dirStates(target); err != nil {
+			return report, err
+		}
+		left = stripArchiveFolder(left, filepath.Base(filepath.Clean(target)))
+		opts.HashTarget = func(rel string) (string, error) {
+			return fileCRC32(filepath.Join(target, filepath.FromSlash(rel)))
+		}
+	} else if right, rightMeta, err = listManagedArchive(cfg, kp, target); err != nil {
+		return report, err
+	}
+	if (leftMeta.Reproducible || rightMeta.Reproducible) && !opts.IgnoreMtime {
+		fmt.Fprintln(os.Stderr, "Note: reproducible archive — modification times are normalized and not compared.")
+		opts.IgnoreMtime = true
+	}
+
+	return diffStates(report, left, right, opts)
+}
+
+// listManagedArchive returns the file states of an archive whose password
+// is stored in KeePassXC, and the entry's metadata. Tar-stream archives are
+// described by their stored manifest, as the listing only shows the tar.
+func listManagedArchive(cfg *config.Config, kp backupStore, archive string) (map[string]fileState, EntryMetadata, error) {
+	absPath, err := filepath.Abs(archive)
+	if err != nil {
+		absPath = archive
+	}
+	if err := ensureArchiveExists(absPath); err != nil {
+		return nil, EntryMetadata{}, err
+	}
+
+	password, entryPath, _, err := resolvePassword(keePassLookup(cfg, kp, cfg.General.AgeIdentity), cfg.General.DefaultGroup, archive, cfg.General.AgeIdentity)
+	if err != nil {
+		return nil, EntryMetadata{}, fmt.Errorf("%s: %w", archive, err)
+	}
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	var meta EntryMetadata
+	if entryPath != "" {
+		notes, _ := kp.GetAttribute(entryPath, "Notes")
+		meta = parseMetadata(notes)
+	}
+	if meta.Payload == tarPayload {
+		states, err := manifestStates(kp, entryPath)
+		if err != nil {
+			return nil, meta, fmt.Errorf("'%s' holds a tar stream and its file manifest is unavailable: %w", archive, err)
+		}
+		return states, meta, nil
+	}
+
+	entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, password, absPath)
+	if err != nil {
+		return nil, meta, fmt.Errorf("cannot list '%s': %w", archive, err)
+	}
+	return archiveStates(entries), meta, nil
+}
+
+// manifestStates indexes the files of an entry's stored manifest by path.
+func manifestStates(kp manifestReader, entryPath string) (map[string]fileState, error) {
+	data, err := kp.ExportAttachment(entryPath, manifestAttachment)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]fileState, len(m.Files))
+	for _, f := range m.Files {
+		states[f.Path] = fileState{Size: f.Size, Modified: f.Modified, CRC: strings.ToUpper(f.CRC)}
+	}
+	return states, nil
+}
+
+// archiveStates indexes the files (not directories) of a listing by path.
+func archiveStates(entries []sevenzip.Entry) map[string]fileState {
+	states := make(map[string]fileState, len(entries))
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		states[filepath.ToSlash(e.Path)] = fileState{Size: e.Size, Modified: e.Modified, CRC: strings.ToUpper(e.CRC)}
+	}
+	return states
+}
+
+// dirStates indexes the regular files below root by slash-separated
+// relative path. CRCs are left empty and computed on demand.
+func dirStates(root string) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		states[filepath.ToSlash(rel)] = fileState{Size: info.Size(), Modified: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan '%s': %w", root, err)
+	}
+	return states, nil
+}
+
+// stripArchiveFolder removes a leading "<folder>/" from every path if all
+// archive members live under it.
+func stripArchiveFolder(states map[string]fileState, folder string) map[string]fileState {
+	prefix := folder + "/"
+	for p := range states {
+		if !strings.HasPrefix(p, prefix) {
+			return states
+		}
+	}
+	if len(states) == 0 {
+		return states
+	}
+	stripped := make(map[string]fileState, len(states))
+	for p, s := range states {
+		stripped[strings.TrimPrefix(p, prefix)] = s
+	}
+	return stripped
+}
+
+// diffStates compares the archive side with the target side. Sizes are
+// compared first; CRCs only when sizes match; mtimes at one-second
+// precision unless ignored.
+func diffStates(report diffReport, archive, target map[string]fileState, opts diffOptions) (diffReport, error) {
+	paths := make([]string, 0, len(archive)+len(target))
+	for p := range archive {
+		paths = append(paths, p)
+	}
+	for p := range target {
+		if _, ok := archive[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	report.Added, report.Removed, report.Changed = []string{}, []string{}, []diffChange{}
+	for _, p := range paths {
+		a, inArchive := archive[p]
+		t, inTarget := target[p]
+		switch {
+		case !inArchive:
+			report.Added = append(report.Added, p)
+			continue
+		case !inTarget:
+			report.Removed = append(report.Removed, p)
+			continue
+		}
+
+		var reasons []string
+		if a.Size != t.Size {
+			reasons = append(reasons, "size")
+		} else if a.CRC != "" {
+			crc := t.CRC
+			if crc == "" && opts.HashTarget != nil {
+				var err error
+				if crc, err = opts.HashTarget(p); err != nil {
+					return report, err
+				}
+			}
+			if crc != "" && !strings.EqualFold(crc, a.CRC) {
+				reasons = append(reasons, "crc")
+			}
+		}
+		if !opts.IgnoreMtime && !a.Modified.IsZero() && !t.Modified.IsZero() && a.Modified.Unix() != t.Modified.Unix() {
+			reasons = append(reasons, "mtime")
+		}
+
+		if len(reasons) > 0 {
+			report.Changed = append(report.Changed, diffChange{Path: p, Reasons: reasons})
+		} else {
+			report.Identical++
+		}
+	}
+	return report, nil
+}
+
+// fileCRC32 returns the CRC32 of a file in 7z's upper-case hex notation.
+func fileCRC32(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash '%s': %w", filepath.Base(path), err)
+	}
+	return fmt.Sprintf("%08X", h.Sum32()), nil
+}
+
+func printDiffReport(w io.Writer, r diffReport) {
+	for _, p := range r.Added {
+		fmt.Fprintf(w, "+ %s\n", p)
+	}
+	for _, p := range r.Removed {
+		fmt.Fprintf(w, "- %s\n", p)
+	}
+	for _, c := range r.Changed {
+		fmt.Fprintf(w, "~ %s (%s)\n", c.Path, strings.Join(c.Reasons, ", "))
+	}
+
+	fmt.Fprintln(w, "─────────────────────────────────")
+	fmt.Fprintf(w, "Diff: %s ↔ %s\n", r.Archive, r.Target)
+	fmt.Fprintf(w, "  ✓ Identical: %d\n", r.Identical)
+	if r.Differs() {
+		fmt.Fprintf(w, "  + Added:     %d\n", len(r.Added))
+		fmt.Fprintf(w, "  - Removed:   %d\n", len(r.Removed))
+		fmt.Fprintf(w, "  ~ Changed:   %d\n", len(r.Changed))
+	}
+	fmt.Fprintln(w, "─────────────────────────────────")
+}
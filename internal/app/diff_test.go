@@ -0,0 +1,107 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+func TestDiffStates(t *testing.T) {
+	mtime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	archive := map[string]fileState{
+		"same.txt":    {Size: 3, Modified: mtime, CRC: "352441C2"},
+		"resized.txt": {Size: 3, Modified: mtime, CRC: "352441C2"},
+		"edited.txt":  {Size: 3, Modified: mtime, CRC: "352441C2"},
+		"touched.txt": {Size: 3, Modified: mtime, CRC: "352441C2"},
+		"gone.txt":    {Size: 1, Modified: mtime, CRC: "E8B7BE43"},
+	}
+	target := map[string]fileState{
+		"same.txt":    {Size: 3, Modified: mtime.Add(400 * time.Millisecond)},
+		"resized.txt": {Size: 4, Modified: mtime},
+		"edited.txt":  {Size: 3, Modified: mtime},
+		"touched.txt": {Size: 3, Modified: mtime.Add(time.Hour)},
+		"new.txt":     {Size: 1, Modified: mtime},
+	}
+	crcs := map[string]string{"same.txt": "352441C2", "edited.txt": "0A1B2C3D", "touched.txt": "352441C2"}
+	opts := diffOptions{HashTarget: func(rel string) (string, error) { return crcs[rel], nil }}
+
+	r, err := diffStates(diffReport{}, archive, target, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Added, []string{"new.txt"}) || !reflect.DeepEqual(r.Removed, []string{"gone.txt"}) {
+		t.Errorf("added=%v removed=%v", r.Added, r.Removed)
+	}
+	wantChanged := []diffChange{
+		{Path: "edited.txt", Reasons: []string{"crc"}},
+		{Path: "resized.txt", Reasons: []string{"size"}},
+		{Path: "touched.txt", Reasons: []string{"mtime"}},
+	}
+	if !reflect.DeepEqual(r.Changed, wantChanged) {
+		t.Errorf("changed = %+v, want %+v", r.Changed, wantChanged)
+	}
+	if r.Identical != 1 || !r.Differs() {
+		t.Errorf("identical=%d differs=%v", r.Identical, r.Differs())
+	}
+
+	opts.IgnoreMtime = true
+	r, _ = diffStates(diffReport{}, archive, target, opts)
+	if len(r.Changed) != 2 {
+		t.Errorf("--ignore-mtime should drop the mtime-only change, got %+v", r.Changed)
+	}
+}
+
+func TestDiffStates_Identical(t *testing.T) {
+	states := map[string]fileState{"a": {Size: 1, CRC: "E8B7BE43"}}
+	r, err := diffStates(diffReport{}, states, states, diffOptions{})
+	if err != nil || r.Differs() || r.Identical != 1 {
+		t.Errorf("identical sides: %+v, %v", r, err)
+	}
+}
+
+func TestDirStatesAndCRC(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := dirStates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := states["sub/a.txt"]; !ok || s.Size != 3 || len(states) != 1 {
+		t.Errorf("dirStates = %+v", states)
+	}
+
+	// CRC32("abc") as printed by 7z
+	if crc, err := fileCRC32(filepath.Join(dir, "sub", "a.txt")); err != nil || crc != "352441C2" {
+		t.Errorf("fileCRC32 = %q, %v", crc, err)
+	}
+}
+
+func TestArchiveStatesAndStripFolder(t *testing.T) {
+	entries := []sevenzip.Entry{
+		{Path: "photos", IsDir: true},
+		{Path: "photos/a.jpg", Size: 10, CRC: "deadbeef"},
+		{Path: "photos/2024/b.jpg", Size: 20},
+	}
+	states := archiveStates(entries)
+	if len(states) != 2 || states["photos/a.jpg"].CRC != "DEADBEEF" {
+		t.Errorf("archiveStates = %+v", states)
+	}
+
+	stripped := stripArchiveFolder(states, "photos")
+	if _, ok := stripped["2024/b.jpg"]; !ok || len(stripped) != 2 {
+		t.Errorf("stripArchiveFolder = %+v", stripped)
+	}
+	if got := stripArchiveFolder(states, "other"); !reflect.DeepEqual(got, states) {
+		t.Error("paths must be kept when not all members share the folder")
+	}
+}
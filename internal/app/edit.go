@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <archive> <path-in-archive>",
+	Short: "Edit a file inside an archive with $EDITOR",
+	Long: `Extracts a single file into a private temp directory, opens it in
+$EDITOR (default: vi) and, if its content changed, writes it back into the
+archive with the same password. The temp copy is wiped afterwards.
+
+  7zkpxc edit configs.7z etc/app/settings.yaml
+
+Like exec, edit refuses a disk-backed temp directory unless --force is given.`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runEdit,
+	GroupID: "actions",
+}
+
+func init() {
+	editCmd.Flags().Bool("force", false, "Allow a disk-backed temp directory")
+	rootCmd.AddCommand(editCmd)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+
+	inner, err := cleanInnerPath(args[1])
+	if err != nil {
+		return err
+	}
+	if AnalyzeArchive(archivePath).IsSplit {
+		return fmt.Errorf("'%s' is a split archive — 7z cannot update split volumes in place", filepath.Base(archivePath))
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if err := ensurePrivateTempRoot(force); err != nil {
+		return err
+	}
+
+	// Not read-only: housekeeping refreshes the size metadata after the update
+	return withKeePassArchive(archivePath, false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		if err := refuseTarPayload(kp, entryPath, archivePath); err != nil {
+			return err
+		}
+		absPath, err := filepath.Abs(archivePath)
+		if err != nil {
+			absPath = archivePath
+		}
+		return editInArchive(cfg, kp, password, entryPath, absPath, inner)
+	})
+}
+
+// cleanInnerPath normalises a path inside an archive and rejects absolute
+// paths, parent references and wildcards.
+func cleanInnerPath(p string) (string, error) {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	switch {
+	case p == "." || p == "":
+		return "", fmt.Errorf("missing path inside the archive")
+	case path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../"):
+		return "", fmt.Errorf("path '%s' must be relative to the archive root", p)
+	case strings.ContainsAny(p, "*?"):
+		return "", fmt.Errorf("path '%s' must name a single file, not a pattern", p)
+	}
+	return p, nil
+}
+
+func editInArchive(cfg *config.Config, kp manifestStore, password []byte, entryPath, absPath, inner string) error {
+	dir, err := newPrivateTempDir("7zkpxc-edit-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = wipeDir(dir) }()
+
+	if err := sevenzip.RunQuiet(cfg.SevenZip.BinaryPath, password, []string{"x", absPath, "-o" + dir, "-y", inner}); err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+	local := filepath.Join(dir, filepath.FromSlash(inner))
+	if info, err := os.Stat(local); err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("'%s' is not a file in '%s'", inner, filepath.Base(absPath))
+	}
+
+	before, err := fileSHA256(local)
+	if err != nil {
+		return err
+	}
+
+	editor := editorCommand()
+	fmt.Printf("Opening '%s' with %s...\n", inner, editor[0])
+	ed := exec.Command(editor[0], append(editor[1:], local)...)
+	ed.Stdin = os.Stdin
+	ed.Stdout = os.Stdout
+	ed.Stderr = os.Stderr
+	if err := ed.Run(); err != nil {
+		return fmt.Errorf("editor failed, archive left untouched: %w", err)
+	}
+
+	after, err := fileSHA256(local)
+	if err != nil {
+		return err
+	}
+	if after == before {
+		fmt.Println("No changes — archive left untouched.")
+		return nil
+	}
+
+	// Editors may leave backup or swap files next to the file; only the
+	// edited file may go back into the archive.
+	if err := wipeAllExcept(dir, local); err != nil {
+		return err
+	}
+
+	// "<dir>/*" makes 7z store the path relative to dir, i.e. as inner.
+	// Like any update of an existing archive: no default_args (the archive
+	// keeps its own settings) and no -p (7z detects the encryption and
+	// prompts itself).
+	fmt.Printf("Writing '%s' back to '%s'...\n", inner, filepath.Base(absPath))
+	args := []string{"u", absPath, filepath.Join(dir, "*")}
+	if err := sevenzip.RunQuiet(cfg.SevenZip.BinaryPath, password, args); err != nil {
+		return fmt.Errorf("failed to update archive: %w", err)
+	}
+
+	fmt.Println("Archive updated successfully.")
+	refreshManifest(cfg, kp, password, entryPath, absPath)
+	return nil
+}
+
+// wipeAllExcept wipes every file below dir other than keep.
+func wipeAllExcept(dir, keep string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || p == keep {
+			return nil
+		}
+		return wipeFile(p)
+	})
+}
+
+// editorCommand returns $EDITOR split into program and arguments
+// (e.g. "code --wait"), falling back to vi.
+func editorCommand() []string {
+	if fields := strings.Fields(os.Getenv("EDITOR")); len(fields) > 0 {
+		return fields
+	}
+	return []string{"vi"}
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCleanInnerPath(t *testing.T) {
+	valid := map[string]string{
+		"etc/app.conf":      "etc/app.conf",
+		"./etc//app.conf":   "etc/app.conf",
+		`etc\app.conf`:      "etc/app.conf",
+		"a/../b/config.ini": "b/config.ini",
+	}
+	for in, want := range valid {
+		if got, err := cleanInnerPath(in); err != nil || got != want {
+			t.Errorf("cleanInnerPath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", ".", "/etc/passwd", "../secret", "a/../../b", "logs/*.txt"} {
+		if _, err := cleanInnerPath(in); err == nil {
+			t.Errorf("cleanInnerPath(%q) should fail", in)
+		}
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "code --wait")
+	if got := editorCommand(); !reflect.DeepEqual(got, []string{"code", "--wait"}) {
+		t.Errorf("editorCommand() = %v", got)
+	}
+	t.Setenv("EDITOR", "")
+	if got := editorCommand(); !reflect.DeepEqual(got, []string{"vi"}) {
+		t.Errorf("editorCommand() without $EDITOR = %v", got)
+	}
+}
+
+func TestWipeAllExcept(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "etc", "app.conf")
+	backup := filepath.Join(dir, "etc", "app.conf~")
+	swap := filepath.Join(dir, "etc", ".app.conf.swp")
+	if err := os.MkdirAll(filepath.Dir(keep), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{keep, backup, swap} {
+		if err := os.WriteFile(p, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := wipeAllExcept(dir, keep); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("kept file was removed: %v", err)
+	}
+	for _, p := range []string{backup, swap} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should have been wiped", filepath.Base(p))
+		}
+	}
+}
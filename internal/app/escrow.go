@@ -0,0 +1,269 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// escrowWordsDefault is the default diceware length of the recovery
+// passphrase (8 words of the EFF list ≈ 103 bits).
+const escrowWordsDefault = 8
+
+var escrowCmd = &cobra.Command{
+	Use:   "escrow [--verify <escrow.7z>]",
+	Short: "Back up the KeePassXC database under a paper recovery passphrase",
+	Long: `Packs the KeePassXC database (and the key file, if general.key_file is set)
+into a 7z archive with encrypted headers, protected by a freshly generated
+diceware recovery passphrase.
+
+The passphrase is printed exactly once — write it down and store it in a
+safe. It is not saved anywhere else. A SHA-256 checksum of the escrow
+archive is written next to it ("<escrow>.sha256").
+
+  7zkpxc escrow -o /mnt/offsite/vault-escrow.7z
+  7zkpxc escrow --verify /mnt/offsite/vault-escrow.7z
+
+--verify checks the checksum and, after asking for the recovery
+passphrase, tests the archive in place without extracting anything.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    runEscrow,
+	GroupID: "actions",
+}
+
+func init() {
+	escrowCmd.Flags().StringP("output", "o", "", "Escrow archive path (default: <db>-escrow-<date>.7z in the current directory)")
+	escrowCmd.Flags().Int("words", escrowWordsDefault, "Number of diceware words in the recovery passphrase")
+	escrowCmd.Flags().Bool("verify", false, "Verify an existing escrow archive instead of creating one")
+	escrowCmd.Flags().Bool("checksum-only", false, "With --verify: only check the SHA-256 file, do not ask for the passphrase")
+	rootCmd.AddCommand(escrowCmd)
+}
+
+func runEscrow(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if verify, _ := cmd.Flags().GetBool("verify"); verify {
+		if len(args) != 1 {
+			return fmt.Errorf("--verify needs the escrow archive path")
+		}
+		checksumOnly, _ := cmd.Flags().GetBool("checksum-only")
+		return verifyEscrow(cfg, args[0], checksumOnly)
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected argument '%s' (use -o to set the output path)", args[0])
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	words, _ := cmd.Flags().GetInt("words")
+	if words < 6 {
+		return fmt.Errorf("--words must be at least 6 for a recovery passphrase")
+	}
+	return createEscrow(cfg, output, words)
+}
+
+// escrowInputs returns the files that go into the escrow archive.
+func escrowInputs(cfg *config.Config) ([]string, error) {
+	inputs := []string{cfg.General.KdbxPath}
+	if cfg.General.KeyFile != "" {
+		inputs = append(inputs, cfg.General.KeyFile)
+	}
+	for i, in := range inputs {
+		abs, err := filepath.Abs(in)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("cannot access '%s': %w", in, err)
+		}
+		inputs[i] = abs
+	}
+	return inputs, nil
+}
+
+// defaultEscrowName returns "<db>-escrow-<YYYYMMDD>.7z" for the database path.
+func defaultEscrowName(kdbxPath string, now time.Time) string {
+	base := filepath.Base(kdbxPath)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	return stem + "-escrow-" + now.Format("20060102") + ".7z"
+}
+
+func createEscrow(cfg *config.Config, output string, words int) error {
+	inputs, err := escrowInputs(cfg)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = defaultEscrowName(cfg.General.KdbxPath, time.Now())
+	} else if filepath.Ext(output) == "" {
+		output += ".7z"
+	}
+	absOut, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output path: %w", err)
+	}
+	if _, err := os.Stat(absOut); err == nil {
+		return fmt.Errorf("escrow archive '%s' already exists — refusing to overwrite", absOut)
+	}
+
+	// Generating a passphrase does not open the database, so no unlock is needed
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	fmt.Printf("Generating %d-word recovery passphrase...\n", words)
+	passphrase, err := kp.Diceware(words)
+	if err != nil {
+		return fmt.Errorf("failed to generate recovery passphrase: %w", err)
+	}
+	defer func() {
+		for i := range passphrase {
+			passphrase[i] = 0
+		}
+	}()
+
+	fmt.Printf("Creating escrow archive '%s'...\n", absOut)
+	args := []string{"a", "-t7z", "-mhe=on", "-mx=9", "-p", absOut}
+	args = append(args, inputs...)
+	if err := sevenzip.RunQuiet(cfg.SevenZip.BinaryPath, passphrase, args); err != nil {
+		_ = os.Remove(absOut)
+		return fmt.Errorf("failed to create escrow archive: %w", err)
+	}
+	_ = os.Chmod(absOut, 0o600)
+
+	fmt.Println("Verifying escrow archive...")
+	if err := sevenzip.VerifyIntegrity(cfg.SevenZip.BinaryPath, passphrase, absOut); err != nil {
+		_ = os.Remove(absOut)
+		return fmt.Errorf("escrow archive failed verification and was removed: %w", err)
+	}
+
+	sum, err := writeChecksumFile(absOut)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("─────────────────────────────────")
+	fmt.Println("RECOVERY PASSPHRASE — write it down now, it will not be shown again:")
+	fmt.Println()
+	fmt.Printf("    %s\n", passphrase)
+	fmt.Println()
+	fmt.Printf("Escrow:  %s\n", absOut)
+	fmt.Printf("SHA-256: %s\n", sum)
+	fmt.Println("─────────────────────────────────")
+	fmt.Printf("Check it any time with '7zkpxc escrow --verify %s'.\n", filepath.Base(absOut))
+	return nil
+}
+
+// verifyEscrow checks the archive against its .sha256 file and, unless
+// checksumOnly is set, tests it with the recovery passphrase read from the
+// terminal. "7z t" decrypts in memory; nothing is extracted to disk.
+func verifyEscrow(cfg *config.Config, archivePath string, checksumOnly bool) error {
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+
+	if err := verifyChecksumFile(absPath); err != nil {
+		return err
+	}
+	fmt.Println("  ✓ SHA-256 checksum matches")
+	if checksumOnly {
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, "Recovery passphrase: ")
+	passphrase, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	defer func() {
+		for i := range passphrase {
+			passphrase[i] = 0
+		}
+	}()
+
+	entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, passphrase, absPath)
+	if err != nil {
+		return fmt.Errorf("cannot open escrow archive with this passphrase: %w", err)
+	}
+	if err := sevenzip.VerifyIntegrity(cfg.SevenZip.BinaryPath, passphrase, absPath); err != nil {
+		return fmt.Errorf("escrow archive is damaged: %w", err)
+	}
+	fmt.Println("  ✓ Passphrase opens the archive and all data tests OK")
+
+	hasDB := false
+	for _, e := range entries {
+		fmt.Printf("    %s (%d bytes)\n", e.Path, e.Size)
+		hasDB = hasDB || strings.EqualFold(filepath.Ext(e.Path), ".kdbx")
+	}
+	if !hasDB {
+		return fmt.Errorf("escrow archive does not contain a .kdbx database")
+	}
+	return nil
+}
+
+// writeChecksumFile writes "<sha256>  <basename>" (sha256sum format) to
+// path+".sha256" and returns the hex digest.
+func writeChecksumFile(path string) (string, error) {
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err := os.WriteFile(path+".sha256", []byte(line), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write checksum file: %w", err)
+	}
+	return sum, nil
+}
+
+// verifyChecksumFile compares path against the digest in path+".sha256".
+func verifyChecksumFile(path string) error {
+	data, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		return fmt.Errorf("failed to read checksum file: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file '%s.sha256' is empty", filepath.Base(path))
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(fields[0], sum) {
+		return fmt.Errorf("SHA-256 mismatch for '%s': expected %s, got %s", filepath.Base(path), fields[0], sum)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash '%s': %w", filepath.Base(path), err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
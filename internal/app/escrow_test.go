@@ -0,0 +1,87 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+)
+
+func TestDefaultEscrowName(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	if got := defaultEscrowName("/home/u/Passwords.kdbx", now); got != "Passwords-escrow-20250601.7z" {
+		t.Errorf("defaultEscrowName = %q", got)
+	}
+}
+
+func TestChecksumFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault-escrow.7z")
+	if err := os.WriteFile(path, []byte("abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := writeChecksumFile(path)
+	if err != nil {
+		t.Fatalf("writeChecksumFile: %v", err)
+	}
+	// sha256("abc")
+	if sum != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("unexpected digest %s", sum)
+	}
+	line, _ := os.ReadFile(path + ".sha256")
+	if string(line) != sum+"  vault-escrow.7z\n" {
+		t.Errorf("checksum file = %q, want sha256sum format", line)
+	}
+
+	if err := verifyChecksumFile(path); err != nil {
+		t.Errorf("verifyChecksumFile on untouched file: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("abd"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksumFile(path); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("expected mismatch after modification, got %v", err)
+	}
+}
+
+func TestVerifyChecksumFile_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault-escrow.7z")
+	if err := os.WriteFile(path, []byte("abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksumFile(path); err == nil {
+		t.Error("expected error when .sha256 file is missing")
+	}
+}
+
+func TestEscrowInputs(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "vault.kdbx")
+	key := filepath.Join(dir, "vault.keyx")
+	for _, p := range []string{db, key} {
+		if err := os.WriteFile(p, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{General: config.GeneralConfig{KdbxPath: db}}
+	got, err := escrowInputs(cfg)
+	if err != nil || len(got) != 1 || got[0] != db {
+		t.Errorf("escrowInputs without key file = %v, %v", got, err)
+	}
+
+	cfg.General.KeyFile = key
+	got, err = escrowInputs(cfg)
+	if err != nil || len(got) != 2 || got[1] != key {
+		t.Errorf("escrowInputs with key file = %v, %v", got, err)
+	}
+
+	cfg.General.KeyFile = filepath.Join(dir, "missing.keyx")
+	if _, err := escrowInputs(cfg); err == nil {
+		t.Error("expected error for missing key file")
+	}
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <archive> -- <command> [args...]",
+	Short: "Run a command against decrypted contents, then wipe them",
+	Long: `Extracts the archive into a private 0700 directory on tmpfs (under
+$XDG_RUNTIME_DIR), runs the command with that directory, and afterwards
+overwrites and removes everything — also when interrupted with Ctrl-C.
+
+Every "{}" in the command is replaced by the directory; without one, the
+directory is appended as the last argument.
+
+  7zkpxc exec logs.7z -- grep -r ERROR {}
+  7zkpxc exec contracts.7z -- evince {}/2024/offer.pdf
+
+If only a disk-backed temp directory is available, exec refuses to run:
+deleted plaintext may stay recoverable there. --force overrides this.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+			return fmt.Errorf("usage: 7zkpxc exec <archive> -- <command> [args...]")
+		}
+		return nil
+	},
+	RunE:    runExec,
+	GroupID: "actions",
+}
+
+func init() {
+	execCmd.Flags().Bool("force", false, "Allow a disk-backed temp directory")
+	rootCmd.AddCommand(execCmd)
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+	command := args[1:]
+
+	force, _ := cmd.Flags().GetBool("force")
+	if err := ensurePrivateTempRoot(force); err != nil {
+		return err
+	}
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		return execInTempDir(cfg.SevenZip.BinaryPath, password, archivePath, command)
+	})
+}
+
+// ensurePrivateTempRoot refuses disk-backed temp roots unless force is set.
+func ensurePrivateTempRoot(force bool) error {
+	root := privateTempRoot()
+	inMemory, err := isMemoryBacked(root)
+	if err != nil {
+		return fmt.Errorf("cannot inspect temp directory '%s': %w", root, err)
+	}
+	if inMemory {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("temp directory '%s' is not on tmpfs — plaintext could persist on disk (set XDG_RUNTIME_DIR to a tmpfs or use --force)", root)
+	}
+	fmt.Fprintf(os.Stderr, "⚠ Warning: '%s' is disk-backed; wiped files may still be recoverable.\n", root)
+	return nil
+}
+
+// execInTempDir extracts the archive into a private temp directory, runs
+// command there and wipes the directory on every exit path.
+//
+// SIGINT, SIGTERM and SIGHUP are caught for the whole lifetime of the
+// directory so that the deferred wipe always runs. Ctrl-C already reaches
+// the child through the terminal; SIGTERM and SIGHUP sent to 7zkpxc alone
+// are forwarded to it.
+func execInTempDir(binaryPath string, password []byte, archivePath string, command []string) (err error) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	dir, err := newPrivateTempDir("7zkpxc-exec-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if wipeErr := wipeDir(dir); wipeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not wipe '%s': %v\n", dir, wipeErr)
+			if err == nil {
+				err = wipeErr
+			}
+		}
+	}()
+
+	fmt.Printf("Extracting '%s' to a private directory...\n", archivePath)
+	if err := sevenzip.RunQuiet(binaryPath, password, []string{"x", archivePath, "-o" + dir, "-y"}); err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+	select {
+	case sig := <-sigs:
+		return fmt.Errorf("interrupted by %v", sig)
+	default:
+	}
+
+	argv := substituteDir(command, dir)
+	child := exec.Command(argv[0], argv[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to run '%s': %w", argv[0], err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig != os.Interrupt {
+					_ = child.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	waitErr := child.Wait()
+	close(done)
+
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		return &commandExitError{Name: argv[0], Code: exitErr.ExitCode()}
+	}
+	return waitErr
+}
+
+// commandExitError reports a non-zero exit of the command run by exec. Its
+// code becomes the exit status of 7zkpxc (see ExitCode).
+type commandExitError struct {
+	Name string
+	Code int
+}
+
+func (e *commandExitError) Error() string {
+	return fmt.Sprintf("'%s' exited with code %d", e.Name, e.Code)
+}
+
+func (e *commandExitError) ExitCode() int {
+	return e.Code
+}
+
+// substituteDir replaces every "{}" in command with dir, or appends dir
+// when no placeholder is present.
+func substituteDir(command []string, dir string) []string {
+	argv := make([]string, len(command))
+	found := false
+	for i, arg := range command {
+		if strings.Contains(arg, "{}") {
+			found = true
+			arg = strings.ReplaceAll(arg, "{}", dir)
+		}
+		argv[i] = arg
+	}
+	if !found {
+		argv = append(argv, dir)
+	}
+	return argv
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSubstituteDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		want    []string
+	}{
+		{"placeholder", []string{"grep", "-r", "ERROR", "{}"}, []string{"grep", "-r", "ERROR", "/run/x"}},
+		{"inside argument", []string{"evince", "{}/docs/a.pdf"}, []string{"evince", "/run/x/docs/a.pdf"}},
+		{"appended", []string{"ls", "-la"}, []string{"ls", "-la", "/run/x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := substituteDir(tt.command, "/run/x"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("substituteDir(%v) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMemoryBacked_DevShm(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("tmpfs detection is Linux-only")
+	}
+	inMemory, err := isMemoryBacked("/dev/shm")
+	if err != nil {
+		t.Skipf("/dev/shm not available: %v", err)
+	}
+	if !inMemory {
+		t.Error("/dev/shm should be reported as memory-backed")
+	}
+}
+
+func TestEnsurePrivateTempRoot_DiskBacked(t *testing.T) {
+	dir := t.TempDir()
+	if inMemory, _ := isMemoryBacked(dir); inMemory {
+		t.Skip("test temp directory is on tmpfs")
+	}
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	if err := ensurePrivateTempRoot(false); err == nil || !strings.Contains(err.Error(), "not on tmpfs") {
+		t.Errorf("expected refusal for disk-backed root, got %v", err)
+	}
+	if err := ensurePrivateTempRoot(true); err != nil {
+		t.Errorf("--force should allow a disk-backed root: %v", err)
+	}
+}
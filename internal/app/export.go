@@ -0,0 +1,329 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [-o <file>]",
+	Short: "Export the archive↔entry inventory",
+	Long: `Writes an inventory of the KeePassXC entries managed by 7zkpxc: entry
+path, title, UUID8, last known archive path, size and version. Passwords
+are never written to the inventory. Entries are taken from
+general.default_group and from the groups of backup profiles.
+
+  7zkpxc export                       # JSON to stdout
+  7zkpxc export -o inventory.csv      # format from the extension
+
+With --with-secrets the entries (including passwords and notes) are instead
+copied into a freshly created KDBX database, protected by a new master
+password you are asked for. Use '7zkpxc import' to merge either form into
+another database.
+
+  7zkpxc export --with-secrets -o transfer.kdbx`,
+	Args:    cobra.NoArgs,
+	RunE:    runExport,
+	GroupID: "actions",
+}
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default: stdout; required with --with-secrets)")
+	exportCmd.Flags().String("format", "", "Inventory format: json or csv (default: from extension, else json)")
+	exportCmd.Flags().Bool("with-secrets", false, "Copy entries with passwords into a new KDBX database instead")
+	rootCmd.AddCommand(exportCmd)
+}
+
+// inventoryRecord describes one managed entry. It never carries a password.
+type inventoryRecord struct {
+	EntryPath     string `json:"entry_path"`
+	Title         string `json:"title"`
+	UUID8         string `json:"uuid8,omitempty"`
+	LastKnownPath string `json:"last_known_path"`
+	Size          int64  `json:"size,omitempty"`
+	Version       string `json:"version,omitempty"`
+}
+
+var inventoryCSVHeader = []string{"entry_path", "title", "uuid8", "last_known_path", "size", "version"}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	format, _ := cmd.Flags().GetString("format")
+	withSecrets, _ := cmd.Flags().GetBool("with-secrets")
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	records, err := collectManagedInventory(kp, cfg)
+	if err != nil {
+		return err
+	}
+
+	if withSecrets {
+		return exportWithSecrets(kp, records, output)
+	}
+
+	format = inventoryFormat(output, format)
+	if output == "" {
+		return writeInventory(os.Stdout, records, format)
+	}
+
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create '%s': %w", output, err)
+	}
+	if err := writeInventory(f, records, format); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", output, err)
+	}
+	fmt.Printf("Exported %d entries to '%s'.\n", len(records), output)
+	return nil
+}
+
+// exportWithSecrets creates a new KDBX database at output and copies every
+// record (password and notes included) into the same group there.
+func exportWithSecrets(kp *keepass.Client, records []inventoryRecord, output string) error {
+	if output == "" {
+		return fmt.Errorf("--with-secrets needs -o <new.kdbx>")
+	}
+	if !strings.EqualFold(filepath.Ext(output), ".kdbx") {
+		return fmt.Errorf("--with-secrets writes a KDBX database; use a .kdbx output path")
+	}
+	absOut, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output path: %w", err)
+	}
+	if _, err := os.Stat(absOut); err == nil {
+		return fmt.Errorf("'%s' already exists — refusing to overwrite", absOut)
+	}
+
+	password, err := readNewMasterPassword(filepath.Base(absOut))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	fmt.Printf("Creating '%s'...\n", absOut)
+	if err := keepass.CreateDatabase(absOut, password); err != nil {
+		return err
+	}
+
+	dst := keepass.New(absOut, keepass.WithPassword(password))
+	defer dst.Close()
+
+	byGroup := make(map[string][]inventoryRecord)
+	var groups []string
+	for _, rec := range records {
+		group := path.Dir(rec.EntryPath)
+		if _, ok := byGroup[group]; !ok {
+			groups = append(groups, group)
+		}
+		byGroup[group] = append(byGroup[group], rec)
+	}
+
+	var results []importResult
+	for _, group := range groups {
+		results = append(results, mergeInventory(dst, group, nil, byGroup[group], keePassSecrets(kp), false)...)
+	}
+	return printImportSummary(results)
+}
+
+// readNewMasterPassword asks twice for the master password of a new database.
+func readNewMasterPassword(name string) ([]byte, error) {
+	fmt.Fprintf(os.Stderr, "New master password for '%s': ", name)
+	password, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	confirm, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	defer func() {
+		for i := range confirm {
+			confirm[i] = 0
+		}
+	}()
+	if err != nil {
+		clear(password)
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(password) == 0 || !bytes.Equal(password, confirm) {
+		clear(password)
+		return nil, fmt.Errorf("passwords are empty or do not match")
+	}
+	return password, nil
+}
+
+// inventoryGroups returns the groups holding managed entries: the default
+// group, then the groups of backup profiles that set their own.
+func inventoryGroups(cfg *config.Config) []string {
+	groups := []string{cfg.General.DefaultGroup}
+	names := make([]string, 0, len(cfg.Backups))
+	for name := range cfg.Backups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if g := cfg.Backups[name].Group; g != "" && !slices.Contains(groups, g) {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// collectManagedInventory lists the entries of every inventory group.
+// A profile group that does not exist yet (no backup has run) is skipped.
+func collectManagedInventory(kp PasswordProvider, cfg *config.Config) ([]inventoryRecord, error) {
+	var records []inventoryRecord
+	for i, group := range inventoryGroups(cfg) {
+		recs, err := collectInventory(kp, group)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		records = append(records, recs...)
+	}
+	return records, nil
+}
+
+// collectInventory lists the entries directly under group, sorted by title.
+func collectInventory(kp PasswordProvider, group string) ([]inventoryRecord, error) {
+	titles, err := kp.ListEntries(group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entries in '%s': %w", group, err)
+	}
+	sort.Strings(titles)
+
+	records := make([]inventoryRecord, 0, len(titles))
+	for _, title := range titles {
+		entryPath := filepath.ToSlash(filepath.Clean(group + "/" + title))
+		username, _ := kp.GetAttribute(entryPath, "Username")
+		notes, _ := kp.GetAttribute(entryPath, "Notes")
+		meta := parseMetadata(notes)
+
+		rec := inventoryRecord{
+			EntryPath:     entryPath,
+			Title:         title,
+			LastKnownPath: username,
+			Size:          meta.Size,
+			Version:       meta.Ver,
+		}
+		if _, uuid8, ok := parseEntryTitle(title); ok {
+			rec.UUID8 = uuid8
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// inventoryFormat returns the explicit format, or one derived from the file
+// extension (".csv" → csv, anything else → json).
+func inventoryFormat(path, format string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return "csv"
+	}
+	return "json"
+}
+
+func writeInventory(w io.Writer, records []inventoryRecord, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write(inventoryCSVHeader)
+		for _, r := range records {
+			_ = cw.Write([]string{r.EntryPath, r.Title, r.UUID8, r.LastKnownPath, strconv.FormatInt(r.Size, 10), r.Version})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported inventory format %q (use json or csv)", format)
+	}
+}
+
+func readInventory(r io.Reader, format string) ([]inventoryRecord, error) {
+	switch format {
+	case "json":
+		var records []inventoryRecord
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("invalid JSON inventory: %w", err)
+		}
+		return records, nil
+	case "csv":
+		return readInventoryCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported inventory format %q (use json or csv)", format)
+	}
+}
+
+func readInventoryCSV(r io.Reader) ([]inventoryRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(inventoryCSVHeader)
+
+	var records []inventoryRecord
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV inventory: %w", err)
+		}
+		if line == 1 && rec[0] == inventoryCSVHeader[0] {
+			continue // header row
+		}
+		size, err := strconv.ParseInt(rec[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV inventory: bad size %q on record %d", rec[4], line)
+		}
+		records = append(records, inventoryRecord{
+			EntryPath:     rec[0],
+			Title:         rec[1],
+			UUID8:         rec[2],
+			LastKnownPath: rec[3],
+			Size:          size,
+			Version:       rec[5],
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+)
+
+func TestCollectInventory(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	p := addUUIDEntry(mock, "Archives", "b.7z", "bbbbbbbb", "/data/b.7z", []byte("pw-b"))
+	mock.SetAttribute(p, "Notes", "[7zkpxc]\nsize=42\nver=1.2.0\n")
+	addUUIDEntry(mock, "Archives", "a.7z", "aaaaaaaa", "/data/a.7z", []byte("pw-a"))
+	mock.SetPassword("Archives/legacy.7z", []byte("pw-l"))
+
+	got, err := collectInventory(mock, "Archives")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inventoryRecord{
+		{EntryPath: "Archives/a.7z (aaaaaaaa)", Title: "a.7z (aaaaaaaa)", UUID8: "aaaaaaaa", LastKnownPath: "/data/a.7z"},
+		{EntryPath: "Archives/b.7z (bbbbbbbb)", Title: "b.7z (bbbbbbbb)", UUID8: "bbbbbbbb", LastKnownPath: "/data/b.7z", Size: 42, Version: "1.2.0"},
+		{EntryPath: "Archives/legacy.7z", Title: "legacy.7z"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectInventory =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCollectManagedInventory_BackupGroups(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	addUUIDEntry(mock, "Archives", "a.7z", "aaaaaaaa", "/data/a.7z", []byte("pw-a"))
+	addUUIDEntry(mock, "Archives/Backups", "home.7z", "bbbbbbbb", "/backups/home.7z", []byte("pw-b"))
+
+	cfg := &config.Config{
+		General: config.GeneralConfig{DefaultGroup: "Archives"},
+		Backups: map[string]config.BackupProfile{
+			"home": {Group: "Archives/Backups"},
+			"etc":  {},
+		},
+	}
+	if got := inventoryGroups(cfg); !reflect.DeepEqual(got, []string{"Archives", "Archives/Backups"}) {
+		t.Errorf("inventoryGroups = %v", got)
+	}
+
+	records, err := collectManagedInventory(mock, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, r := range records {
+		paths = append(paths, r.EntryPath)
+	}
+	want := []string{"Archives/a.7z (aaaaaaaa)", "Archives/Backups/home.7z (bbbbbbbb)"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("collectManagedInventory = %v, want %v", paths, want)
+	}
+}
+
+func TestInventory_RoundTrip(t *testing.T) {
+	records := []inventoryRecord{
+		{EntryPath: "Archives/a.7z (aaaaaaaa)", Title: "a.7z (aaaaaaaa)", UUID8: "aaaaaaaa", LastKnownPath: "/data/a, b/a.7z", Size: 42, Version: "1.2.0"},
+		{EntryPath: "Archives/legacy.7z", Title: "legacy.7z"},
+	}
+	for _, format := range []string{"json", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeInventory(&buf, records, format); err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(buf.Bytes(), []byte("password")) {
+				t.Errorf("inventory must not mention passwords:\n%s", buf.String())
+			}
+			got, err := readInventory(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, records) {
+				t.Errorf("round trip =\n%+v\nwant\n%+v", got, records)
+			}
+		})
+	}
+}
+
+func TestInventoryFormat(t *testing.T) {
+	tests := []struct{ path, flag, want string }{
+		{"", "", "json"},
+		{"inv.CSV", "", "csv"},
+		{"inv.json", "", "json"},
+		{"inv.txt", "CSV", "csv"},
+	}
+	for _, tt := range tests {
+		if got := inventoryFormat(tt.path, tt.flag); got != tt.want {
+			t.Errorf("inventoryFormat(%q, %q) = %q, want %q", tt.path, tt.flag, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/lxstig/7zkpxc/internal/config"
 	"github.com/lxstig/7zkpxc/internal/keepass"
@@ -10,8 +11,21 @@ import (
 )
 
 var extractCmd = &cobra.Command{
-	Use:     "x <archive_path> [7z_flags...]",
-	Short:   "Extract archive contents",
+	Use:   "x <archive_path> [7z_flags...]",
+	Short: "Extract archive contents",
+	Long: `Extracts an archive with 7z; remaining arguments (flags and member names)
+are passed through.
+
+Archives created with 'a --tar' hold one tar stream, which is unpacked by
+7zkpxc instead, restoring permissions, times, extended attributes, ACLs,
+hard links and special files. Owners are restored with --same-owner, the
+default for root; --numeric-owner uses the archived uid/gid instead of
+looking up the user and group names. setuid/setgid bits are dropped without
+--same-owner. --tar forces this mode where the entry's mark cannot be read
+(age grants, --shares).
+
+  7zkpxc x -o /srv/restore rootfs.7z
+  sudo 7zkpxc x --numeric-owner -o /mnt/rootfs rootfs.7z`,
 	Args:    cobra.MinimumNArgs(1),
 	RunE:    runExtract,
 	GroupID: "actions",
@@ -19,6 +33,14 @@ var extractCmd = &cobra.Command{
 
 func init() {
 	extractCmd.Flags().StringP("output", "o", "", "Output directory for extracted files")
+	extractCmd.Flags().String("identity", "", "age identity file for the archive's grant sidecar, used when KeePassXC has no entry (overrides general.age_identity)")
+	extractCmd.Flags().Bool("shares", false, "Recombine the password from Shamir shares typed on the terminal")
+	extractCmd.MarkFlagsMutuallyExclusive("identity", "shares")
+	extractCmd.Flags().Bool("tar", false, "Unpack the archive as a tar stream ('a --tar') even if the entry is not marked")
+	extractCmd.Flags().Bool("same-owner", false, "Tar streams: restore the archived owners (default when run as root)")
+	extractCmd.Flags().Bool("no-same-owner", false, "Tar streams: extract files as the current user, even as root")
+	extractCmd.Flags().Bool("numeric-owner", false, "Tar streams: restore the archived uid/gid, not the user and group names")
+	extractCmd.MarkFlagsMutuallyExclusive("same-owner", "no-same-owner")
 	extractCmd.Flags().SetInterspersed(false)
 	extractCmd.FParseErrWhitelist.UnknownFlags = true
 	rootCmd.AddCommand(extractCmd)
@@ -31,7 +53,36 @@ func runExtract(cmd *cobra.Command, args []string) error {
 	// Pass all remaining arguments (both flags and specific files) to 7z
 	extraArgs := args[1:]
 
-	return withKeePassArchive(archivePath, false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+	identity, _ := cmd.Flags().GetString("identity")
+	useShares, _ := cmd.Flags().GetBool("shares")
+	forceTar, _ := cmd.Flags().GetBool("tar")
+	tarOpts := tarExtractOptions{SameOwner: os.Geteuid() == 0}
+	if cmd.Flags().Changed("same-owner") {
+		tarOpts.SameOwner, _ = cmd.Flags().GetBool("same-owner")
+	}
+	if noSameOwner, _ := cmd.Flags().GetBool("no-same-owner"); noSameOwner {
+		tarOpts.SameOwner = false
+	}
+	tarOpts.NumericOwner, _ = cmd.Flags().GetBool("numeric-owner")
+
+	op := func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		isTar := forceTar
+		if entryPath != "" {
+			notes, _ := kp.GetAttribute(entryPath, "Notes")
+			isTar = isTar || parseMetadata(notes).Payload == tarPayload
+		}
+		if isTar {
+			if len(extraArgs) > 0 {
+				return fmt.Errorf("7z flags and member names are not supported for tar archives ('a --tar')")
+			}
+			dest, _ := cmd.Flags().GetString("output")
+			if dest == "" {
+				dest = "."
+			}
+			fmt.Printf("Unpacking tar stream of '%s'...\n", archivePath)
+			return extractTarPayload(cfg.SevenZip.BinaryPath, password, archivePath, dest, tarOpts)
+		}
+
 		fmt.Printf("Extracting '%s'...\n", archivePath)
 		sevenZipArgs := []string{"x", archivePath}
 
@@ -48,5 +99,10 @@ func runExtract(cmd *cobra.Command, args []string) error {
 
 		fmt.Println("Success! Archive extracted.")
 		return nil
-	})
+	}
+
+	if useShares {
+		return withSecretShares(archivePath, op)
+	}
+	return withKeePassArchiveIdentity(archivePath, identity, false, op)
 }
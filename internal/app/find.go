@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var findCmd = &cobra.Command{
+	Use:   "find <glob>",
+	Short: "Find files in archives using the stored manifests",
+	Long: `Searches the file manifests that a, u, d and rn store as an attachment
+on each archive's KeePassXC entry. The database is unlocked once; no
+archive is opened, so archives on offline or detached disks are found too.
+
+Entries in general.default_group and in the groups of backup profiles are
+searched. The glob matches either the full path inside the archive or its
+base name:
+
+  7zkpxc find 'invoice-2023-07.pdf'
+  7zkpxc find -i '*.PDF'
+  7zkpxc find 'photos/2024/*'
+
+Archives created before manifests existed are listed as having none; any
+a, u, d or rn on them writes one.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runFind,
+	GroupID: "actions",
+}
+
+func init() {
+	findCmd.Flags().BoolP("ignore-case", "i", false, "Case-insensitive matching")
+	rootCmd.AddCommand(findCmd)
+}
+
+// findMatch is a manifest file matched by find.
+type findMatch struct {
+	Archive string
+	File    manifestFile
+}
+
+func runFind(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+
+	glob := args[0]
+	if _, err := path.Match(glob, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	records, err := collectManagedInventory(kp, cfg)
+	if err != nil {
+		return err
+	}
+
+	matches, missing := findInManifests(kp, records, glob, ignoreCase)
+	printFindMatches(os.Stdout, matches)
+
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d archive(s) have no manifest yet: %s\n", len(missing), joinWords(missing))
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no matches")
+	}
+	return nil
+}
+
+// findInManifests matches glob against the manifest of every record. It
+// returns the matches and the archives without a readable manifest.
+func findInManifests(kp manifestReader, records []inventoryRecord, glob string, ignoreCase bool) ([]findMatch, []string) {
+	if ignoreCase {
+		glob = strings.ToLower(glob)
+	}
+
+	var matches []findMatch
+	var missing []string
+	for _, rec := range records {
+		label := rec.LastKnownPath
+		if label == "" {
+			label = rec.Title
+		}
+
+		data, err := kp.ExportAttachment(rec.EntryPath, manifestAttachment)
+		if err != nil || len(data) == 0 {
+			missing = append(missing, label)
+			continue
+		}
+		m, err := decodeManifest(data)
+		if err != nil {
+			missing = append(missing, label)
+			continue
+		}
+
+		for _, f := range m.Files {
+			if manifestPathMatches(glob, f.Path, ignoreCase) {
+				matches = append(matches, findMatch{Archive: label, File: f})
+			}
+		}
+	}
+	return matches, missing
+}
+
+// manifestPathMatches reports whether glob matches the full path or the
+// base name of p. glob must already be lower-case when ignoreCase is set.
+func manifestPathMatches(glob, p string, ignoreCase bool) bool {
+	if ignoreCase {
+		p = strings.ToLower(p)
+	}
+	if ok, _ := path.Match(glob, p); ok {
+		return true
+	}
+	ok, _ := path.Match(glob, path.Base(p))
+	return ok
+}
+
+// printFindMatches prints one "archive:path" line per match, followed by the
+// file's size and modification time.
+func printFindMatches(w io.Writer, matches []findMatch) {
+	for _, m := range matches {
+		modified := "-"
+		if !m.File.Modified.IsZero() {
+			modified = m.File.Modified.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s:%s\t%d\t%s\n", m.Archive, m.File.Path, m.File.Size, modified)
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAttachments serves manifests by entry path.
+type fakeAttachments map[string][]byte
+
+func (f fakeAttachments) ExportAttachment(entryPath, name string) ([]byte, error) {
+	if name != manifestAttachment {
+		return nil, fmt.Errorf("unexpected attachment %q", name)
+	}
+	data, ok := f[entryPath]
+	if !ok {
+		return nil, fmt.Errorf("no attachment")
+	}
+	return data, nil
+}
+
+func mustEncodeManifest(t *testing.T, paths ...string) []byte {
+	t.Helper()
+	m := archiveManifest{Version: manifestVersion}
+	for _, p := range paths {
+		m.Files = append(m.Files, manifestFile{Path: p, Size: 10})
+	}
+	data, err := encodeManifest(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestManifestPathMatches(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		ignoreCase bool
+		want       bool
+	}{
+		{"invoice-2023-07.pdf", "docs/invoice-2023-07.pdf", false, true},
+		{"*.pdf", "docs/invoice-2023-07.pdf", false, true},
+		{"docs/*", "docs/invoice-2023-07.pdf", false, true},
+		{"other/*", "docs/invoice-2023-07.pdf", false, false},
+		{"*.pdf", "docs/INVOICE.PDF", false, false},
+		{"*.pdf", "docs/INVOICE.PDF", true, true},
+	}
+	for _, tt := range tests {
+		if got := manifestPathMatches(tt.glob, tt.path, tt.ignoreCase); got != tt.want {
+			t.Errorf("manifestPathMatches(%q, %q, %v) = %v, want %v", tt.glob, tt.path, tt.ignoreCase, got, tt.want)
+		}
+	}
+}
+
+func TestFindInManifests(t *testing.T) {
+	kp := fakeAttachments{
+		"7zkpxc/a.7z (11111111)": mustEncodeManifest(t, "2023/invoice-2023-07.pdf", "2023/notes.txt"),
+		"7zkpxc/b.7z (22222222)": mustEncodeManifest(t, "photos/cat.jpg"),
+		"7zkpxc/c.7z (33333333)": []byte("garbage"),
+	}
+	records := []inventoryRecord{
+		{EntryPath: "7zkpxc/a.7z (11111111)", Title: "a.7z (11111111)", LastKnownPath: "/data/a.7z"},
+		{EntryPath: "7zkpxc/b.7z (22222222)", Title: "b.7z (22222222)", LastKnownPath: "/data/b.7z"},
+		{EntryPath: "7zkpxc/c.7z (33333333)", Title: "c.7z (33333333)"},
+		{EntryPath: "7zkpxc/d.7z (44444444)", Title: "d.7z (44444444)", LastKnownPath: "/data/d.7z"},
+	}
+
+	matches, missing := findInManifests(kp, records, "invoice-*.PDF", true)
+	if len(matches) != 1 || matches[0].Archive != "/data/a.7z" || matches[0].File.Path != "2023/invoice-2023-07.pdf" {
+		t.Errorf("unexpected matches: %+v", matches)
+	}
+	if strings.Join(missing, ",") != "c.7z (33333333),/data/d.7z" {
+		t.Errorf("missing = %v", missing)
+	}
+}
+
+func TestPrintFindMatches(t *testing.T) {
+	var buf bytes.Buffer
+	printFindMatches(&buf, []findMatch{
+		{Archive: "/data/a.7z", File: manifestFile{Path: "x.txt", Size: 42}},
+		{Archive: "/data/b.7z", File: manifestFile{Path: "y.txt", Size: 7, Modified: time.Date(2024, 5, 6, 7, 8, 0, 0, time.Local)}},
+	})
+	want := "/data/a.7z:x.txt\t42\t-\n/data/b.7z:y.txt\t7\t2024-05-06 07:08\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
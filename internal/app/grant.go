@@ -0,0 +1,298 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/spf13/cobra"
+)
+
+// grantSuffix is appended to the archive name to form the grant sidecar,
+// e.g. "data.7z" → "data.7z.age".
+const grantSuffix = ".age"
+
+const grantRecipientPrefix = "# recipient: "
+
+var grantCmd = &cobra.Command{
+	Use:   "grant <archive> --to <age_recipient>...",
+	Short: "Encrypt an archive's password to age public keys",
+	Long: `Encrypts the archive's password to one or more age X25519 recipients and
+writes it to a sidecar file next to the archive ("<archive>.age").
+
+Teammates without a KeePassXC entry for the archive can then open it with
+their age identity, given with --identity or as general.age_identity in the
+config (which every command reading archives uses):
+
+  7zkpxc grant data.7z --to age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+  7zkpxc x --identity ~/.age/key.txt data.7z
+
+Recipients already listed in the sidecar are kept unless --replace is given.
+'7zkpxc rekey' re-wraps the sidecar for the same recipients.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runGrant,
+	GroupID: "actions",
+}
+
+func init() {
+	grantCmd.Flags().StringArray("to", nil, "age recipient (age1...); repeatable")
+	grantCmd.Flags().String("recipients-file", "", "File with one age recipient per line")
+	grantCmd.Flags().Bool("replace", false, "Drop recipients already listed in the sidecar")
+	rootCmd.AddCommand(grantCmd)
+}
+
+func runGrant(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+
+	recipients, _ := cmd.Flags().GetStringArray("to")
+	if file, _ := cmd.Flags().GetString("recipients-file"); file != "" {
+		fromFile, err := readRecipientsFile(file)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, fromFile...)
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients given — use --to age1... or --recipients-file")
+	}
+	replace, _ := cmd.Flags().GetBool("replace")
+
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		sidecar := grantSidecarPath(resolveFirstVolume(absPath))
+
+		if !replace {
+			existing, err := readGrantRecipients(sidecar)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			recipients = append(existing, recipients...)
+		}
+
+		all, err := writeGrant(sidecar, password, recipients)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Success! Password of '%s' granted to %d recipient(s):\n", filepath.Base(archivePath), len(all))
+		for _, r := range all {
+			fmt.Printf("  %s\n", r)
+		}
+		fmt.Printf("Sidecar: %s\n", sidecar)
+		return nil
+	})
+}
+
+// grantSidecarPath returns the sidecar path for an archive. Split archives
+// share one sidecar named after the normalized archive name.
+func grantSidecarPath(absFirst string) string {
+	return filepath.Join(filepath.Dir(absFirst), AnalyzeArchive(absFirst).NormalizedName+grantSuffix)
+}
+
+// writeGrant encrypts password to the given recipients and atomically
+// replaces the sidecar. Duplicate recipients are removed; the sorted list
+// that was written is returned.
+//
+// Sidecar layout: "# recipient: age1..." comment lines (age has no way to
+// list recipients of a message, so they are kept in clear for re-wrapping),
+// followed by an ASCII-armored age message.
+func writeGrant(sidecar string, password []byte, recipients []string) ([]string, error) {
+	unique := make(map[string]bool)
+	var parsed []age.Recipient
+	var names []string
+	for _, r := range recipients {
+		r = strings.TrimSpace(r)
+		if r == "" || unique[r] {
+			continue
+		}
+		rcpt, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", r, err)
+		}
+		unique[r] = true
+		parsed = append(parsed, rcpt)
+		names = append(names, r)
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no valid age recipients")
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, r := range names {
+		buf.WriteString(grantRecipientPrefix + r + "\n")
+	}
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, parsed...)
+	if err != nil {
+		return nil, fmt.Errorf("age encryption failed: %w", err)
+	}
+	if _, err := w.Write(password); err != nil {
+		return nil, fmt.Errorf("age encryption failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("age encryption failed: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return nil, fmt.Errorf("age encryption failed: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(sidecar), ".7zkpxc-grant-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write sidecar: %w", err)
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), sidecar)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to write sidecar '%s': %w", sidecar, err)
+	}
+	return names, nil
+}
+
+// readGrantRecipients returns the recipients listed in a sidecar.
+func readGrantRecipients(sidecar string) ([]string, error) {
+	f, err := os.Open(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var recipients []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if r, ok := strings.CutPrefix(sc.Text(), grantRecipientPrefix); ok {
+			recipients = append(recipients, strings.TrimSpace(r))
+		}
+	}
+	return recipients, sc.Err()
+}
+
+// readRecipientsFile reads one recipient per line, ignoring blank lines and
+// "#" comments (the format written by age-keygen -y).
+func readRecipientsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipients file: %w", err)
+	}
+	var recipients []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			recipients = append(recipients, line)
+		}
+	}
+	return recipients, nil
+}
+
+// unwrapGrant decrypts the password in a sidecar with the identities in
+// identityFile (as written by age-keygen).
+func unwrapGrant(sidecar, identityFile string) ([]byte, error) {
+	idf, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file: %w", err)
+	}
+	identities, err := age.ParseIdentities(idf)
+	_ = idf.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file: %w", err)
+	}
+
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	// Skip the recipient comment lines in front of the armored message
+	if i := bytes.Index(data, []byte(armor.Header)); i >= 0 {
+		data = data[i:]
+	}
+
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(data)), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt '%s' with this identity: %w", filepath.Base(sidecar), err)
+	}
+	password, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", filepath.Base(sidecar), err)
+	}
+	return password, nil
+}
+
+// refreshGrant re-wraps an existing sidecar with a new password for the same
+// recipients. It is a no-op when the archive has no sidecar.
+func refreshGrant(absFirst string, password []byte) error {
+	sidecar := grantSidecarPath(absFirst)
+	recipients, err := readGrantRecipients(sidecar)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = writeGrant(sidecar, password, recipients)
+	return err
+}
+
+// grantPassword looks for a grant sidecar next to the archive and decrypts
+// it with identityFile. ok is false when the archive has no sidecar, so the
+// caller can fall back to KeePassXC.
+func grantPassword(archivePath, identityFile string) (password []byte, ok bool, err error) {
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+	sidecar := grantSidecarPath(resolveFirstVolume(absPath))
+	if _, err := os.Stat(sidecar); err != nil {
+		return nil, false, nil
+	}
+	password, err = unwrapGrant(sidecar, identityFile)
+	if err != nil {
+		return nil, true, err
+	}
+	return password, true, nil
+}
+
+// ageIdentity returns the identity file for grant lookups: identityFile (an
+// --identity flag) when set, otherwise general.age_identity.
+func ageIdentity(cfg *config.Config, identityFile string) string {
+	if identityFile != "" {
+		return identityFile
+	}
+	return cfg.General.AgeIdentity
+}
+
+// keePassLookup returns kp for the KeePassXC steps of the password lookup,
+// or nil when identityFile is set and there is no database to open — a
+// teammate with only grants then is not asked for a master password.
+func keePassLookup(cfg *config.Config, kp PasswordProvider, identityFile string) PasswordProvider {
+	if identityFile != "" {
+		if _, err := os.Stat(cfg.General.KdbxPath); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+	}
+	return kp
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/lxstig/7zkpxc/internal/config"
+)
+
+// newTestIdentity writes a fresh age identity file and returns its path
+// together with the matching recipient string.
+func newTestIdentity(t *testing.T) (identityFile, recipient string) {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile = filepath.Join(t.TempDir(), "key.txt")
+	content := "# public key: " + id.Recipient().String() + "\n" + id.String() + "\n"
+	if err := os.WriteFile(identityFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return identityFile, id.Recipient().String()
+}
+
+func TestGrantSidecarPath(t *testing.T) {
+	tests := map[string]string{
+		"/data/backup.7z":     "/data/backup.7z.age",
+		"/data/backup.7z.001": "/data/backup.7z.age",
+	}
+	for in, want := range tests {
+		if got := grantSidecarPath(in); got != want {
+			t.Errorf("grantSidecarPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteGrant_RoundTrip(t *testing.T) {
+	aliceKey, alice := newTestIdentity(t)
+	bobKey, bob := newTestIdentity(t)
+	sidecar := filepath.Join(t.TempDir(), "data.7z.age")
+
+	written, err := writeGrant(sidecar, []byte("s3cret"), []string{bob, alice, bob})
+	if err != nil {
+		t.Fatalf("writeGrant: %v", err)
+	}
+	if len(written) != 2 {
+		t.Errorf("expected duplicates to be removed, got %v", written)
+	}
+
+	for _, key := range []string{aliceKey, bobKey} {
+		pw, err := unwrapGrant(sidecar, key)
+		if err != nil {
+			t.Fatalf("unwrapGrant: %v", err)
+		}
+		if string(pw) != "s3cret" {
+			t.Errorf("unwrapGrant = %q, want %q", pw, "s3cret")
+		}
+	}
+
+	listed, err := readGrantRecipients(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || !strings.HasPrefix(listed[0], "age1") {
+		t.Errorf("readGrantRecipients = %v", listed)
+	}
+}
+
+func TestUnwrapGrant_WrongIdentity(t *testing.T) {
+	_, alice := newTestIdentity(t)
+	malloryKey, _ := newTestIdentity(t)
+	sidecar := filepath.Join(t.TempDir(), "data.7z.age")
+
+	if _, err := writeGrant(sidecar, []byte("s3cret"), []string{alice}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unwrapGrant(sidecar, malloryKey); err == nil {
+		t.Error("expected decryption with a foreign identity to fail")
+	}
+}
+
+func TestWriteGrant_InvalidRecipient(t *testing.T) {
+	sidecar := filepath.Join(t.TempDir(), "data.7z.age")
+	_, err := writeGrant(sidecar, []byte("x"), []string{"ssh-ed25519 AAAA"})
+	if err == nil || !strings.Contains(err.Error(), "invalid age recipient") {
+		t.Fatalf("expected invalid recipient error, got %v", err)
+	}
+	if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+		t.Error("sidecar must not be written on error")
+	}
+}
+
+func TestRefreshGrant(t *testing.T) {
+	key, recipient := newTestIdentity(t)
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "data.7z")
+
+	// No sidecar: nothing to do
+	if err := refreshGrant(archive, []byte("new")); err != nil {
+		t.Fatalf("refreshGrant without sidecar: %v", err)
+	}
+	if _, err := os.Stat(archive + grantSuffix); !os.IsNotExist(err) {
+		t.Fatal("refreshGrant must not create a sidecar")
+	}
+
+	if _, err := writeGrant(archive+grantSuffix, []byte("old"), []string{recipient}); err != nil {
+		t.Fatal(err)
+	}
+	if err := refreshGrant(archive, []byte("new")); err != nil {
+		t.Fatalf("refreshGrant: %v", err)
+	}
+	pw, err := unwrapGrant(archive+grantSuffix, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pw) != "new" {
+		t.Errorf("password after refresh = %q, want %q", pw, "new")
+	}
+}
+
+func TestGrantPassword_NoSidecar(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "data.7z")
+	_, ok, err := grantPassword(archive, "/nonexistent/key.txt")
+	if ok || err != nil {
+		t.Errorf("grantPassword without sidecar = ok %v, err %v; want false, nil", ok, err)
+	}
+}
+
+func TestReadRecipientsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "team.txt")
+	content := "# alice\nage1aaa\n\n  age1bbb  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readRecipientsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "age1aaa" || got[1] != "age1bbb" {
+		t.Errorf("readRecipientsFile = %v", got)
+	}
+}
+
+func TestGetPasswordForArchive_Grant(t *testing.T) {
+	key, recipient := newTestIdentity(t)
+	archive := filepath.Join(t.TempDir(), "data.7z")
+	if _, err := writeGrant(grantSidecarPath(archive), []byte("granted"), []string{recipient}); err != nil {
+		t.Fatal(err)
+	}
+
+	// No KeePassXC entry: the grant is the last provider of the chain
+	pw, entryPath, err := GetPasswordForArchive(NewMockPasswordProvider(), "grp", archive, key)
+	if err != nil || string(pw) != "granted" || entryPath != "" {
+		t.Errorf("without entry = %q, %q, %v; want the granted password", pw, entryPath, err)
+	}
+
+	// No database at all
+	pw, _, err = GetPasswordForArchive(nil, "grp", archive, key)
+	if err != nil || string(pw) != "granted" {
+		t.Errorf("without database = %q, %v; want the granted password", pw, err)
+	}
+
+	// An entry wins over the grant
+	mock := NewMockPasswordProvider()
+	addUUIDEntry(mock, "grp", "data.7z", "aaaaaaaa", archive, []byte("kept"))
+	pw, entryPath, err = GetPasswordForArchive(mock, "grp", archive, key)
+	if err != nil || string(pw) != "kept" || entryPath == "" {
+		t.Errorf("with entry = %q, %q, %v; want the entry's password", pw, entryPath, err)
+	}
+
+	// Without an identity the sidecar is ignored
+	if _, _, err := GetPasswordForArchive(NewMockPasswordProvider(), "grp", archive, ""); !IsPasswordNotFound(err) {
+		t.Errorf("without identity: got %v, want PasswordNotFoundError", err)
+	}
+}
+
+func TestKeePassLookup(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	missing := &config.Config{General: config.GeneralConfig{KdbxPath: "/nonexistent/db.kdbx"}}
+	if keePassLookup(missing, mock, "key.txt") != nil {
+		t.Error("a missing database with an identity set should skip KeePassXC")
+	}
+	if keePassLookup(missing, mock, "") == nil {
+		t.Error("without an identity KeePassXC must be asked")
+	}
+}
+
+func TestAgeIdentity(t *testing.T) {
+	cfg := &config.Config{General: config.GeneralConfig{AgeIdentity: "config.txt"}}
+	if got := ageIdentity(cfg, "flag.txt"); got != "flag.txt" {
+		t.Errorf("ageIdentity with flag = %q, want flag.txt", got)
+	}
+	if got := ageIdentity(cfg, ""); got != "config.txt" {
+		t.Errorf("ageIdentity without flag = %q, want config.txt", got)
+	}
+	if cfg.General.AgeIdentity != "config.txt" {
+		t.Error("ageIdentity must not change the config")
+	}
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+// binarySniffLen is how many leading bytes are checked for NUL to decide
+// that a member is binary (the same heuristic GNU grep uses).
+const binarySniffLen = 8000
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern> <archive|dir>...",
+	Short: "Search inside archive contents without extracting to disk",
+	Long: `Streams every member of the given archives through a Go regular
+expression (RE2 syntax) in memory and prints matches as
+
+  archive:member:line
+
+Directories are searched for .7z archives (not recursively). KeePassXC is
+unlocked once for all archives. Binary members are skipped unless --binary
+is given.
+
+  7zkpxc grep INC-20931 ~/logs/
+  7zkpxc grep -i --include '*.log' 'timeout|refused' app-2023.7z app-2024.7z`,
+	Args:    cobra.MinimumNArgs(2),
+	RunE:    runGrep,
+	GroupID: "actions",
+}
+
+func init() {
+	grepCmd.Flags().BoolP("ignore-case", "i", false, "Case-insensitive matching")
+	grepCmd.Flags().BoolP("line-number", "n", false, "Prefix each match with its line number")
+	grepCmd.Flags().BoolP("files-with-matches", "l", false, "Only print archive:member of matching members")
+	grepCmd.Flags().StringArray("include", nil, "Only search members matching this glob (repeatable)")
+	grepCmd.Flags().StringArray("exclude", nil, "Skip members matching this glob (repeatable)")
+	grepCmd.Flags().Bool("binary", false, "Also search binary members")
+	rootCmd.AddCommand(grepCmd)
+}
+
+// grepOptions controls how members are selected and matched.
+type grepOptions struct {
+	Re          *regexp.Regexp
+	Include     []string
+	Exclude     []string
+	Binary      bool
+	LineNumbers bool
+	FilesOnly   bool
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	pattern := args[0]
+	if ic, _ := cmd.Flags().GetBool("ignore-case"); ic {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	opts := grepOptions{Re: re}
+	opts.Include, _ = cmd.Flags().GetStringArray("include")
+	opts.Exclude, _ = cmd.Flags().GetStringArray("exclude")
+	opts.Binary, _ = cmd.Flags().GetBool("binary")
+	opts.LineNumbers, _ = cmd.Flags().GetBool("line-number")
+	opts.FilesOnly, _ = cmd.Flags().GetBool("files-with-matches")
+	for _, g := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+	}
+
+	archives, err := expandArchiveArgs(args[1:])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	total, failed := 0, 0
+	for _, archive := range archives {
+		n, err := grepArchiveWithKeePass(cfg, kp, archive, opts)
+		total += n
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "7zkpxc grep: %s: %v\n", archive, err)
+		}
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("%d of %d archive(s) could not be searched", failed, len(archives))
+	case total == 0:
+		return fmt.Errorf("no matches")
+	}
+	return nil
+}
+
+// expandArchiveArgs replaces directory arguments by the archives they contain.
+func expandArchiveArgs(args []string) ([]string, error) {
+	var archives []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err == nil && info.IsDir() {
+			found, err := findArchivesInDir(arg)
+			if err != nil {
+				return nil, err
+			}
+			archives = append(archives, found...)
+			continue
+		}
+		archives = append(archives, arg)
+	}
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("no archives found")
+	}
+	return archives, nil
+}
+
+func grepArchiveWithKeePass(cfg *config.Config, kp PasswordProvider, archive string, opts grepOptions) (int, error) {
+	absPath, err := filepath.Abs(archive)
+	if err != nil {
+		absPath = archive
+	}
+	if err := ensureArchiveExists(absPath); err != nil {
+		return 0, err
+	}
+
+	password, _, _, err := resolvePassword(keePassLookup(cfg, kp, cfg.General.AgeIdentity), cfg.General.DefaultGroup, archive, cfg.General.AgeIdentity)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, password, absPath)
+	if err != nil {
+		return 0, fmt.Errorf("cannot list archive: %w", err)
+	}
+
+	var members []sevenzip.Entry
+	files := 0
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		files++
+		if memberSelected(e.Path, opts) {
+			members = append(members, e)
+		}
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+	return grepMembers(cfg.SevenZip.BinaryPath, password, absPath, archive, members, len(members) < files, opts, os.Stdout)
+}
+
+// memberSelected applies the include/exclude globs to a member. A glob
+// matches either the full member path or its base name.
+func memberSelected(member string, opts grepOptions) bool {
+	matches := func(globs []string) bool {
+		for _, g := range globs {
+			if ok, _ := path.Match(g, member); ok {
+				return true
+			}
+			if ok, _ := path.Match(g, path.Base(member)); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if len(opts.Include) > 0 && !matches(opts.Include) {
+		return false
+	}
+	return !matches(opts.Exclude)
+}
+
+// grepMembers streams the members out of the archive with a single
+// "7z x -so" run (one run per member would decompress a solid archive again
+// for every member) and matches them line by line. 7z writes the members
+// back to back in archive order, so their listed sizes split the stream.
+// When only some members are wanted, their names go to 7z in a list file
+// (-spd: no wildcards) in a private temp dir.
+func grepMembers(binaryPath string, password []byte, absPath, label string, members []sevenzip.Entry, subset bool, opts grepOptions, w io.Writer) (int, error) {
+	args := []string{"x", "-so", "-y", absPath}
+	if subset {
+		tmp, err := newPrivateTempDir("7zkpxc-grep-*")
+		if err != nil {
+			return 0, err
+		}
+		defer func() { _ = wipeDir(tmp) }()
+
+		var names bytes.Buffer
+		for _, m := range members {
+			if strings.ContainsAny(m.Path, "\n\r") {
+				return 0, fmt.Errorf("cannot search '%s': member names with line breaks are not supported", m.Path)
+			}
+			names.WriteString(m.Path + "\n")
+		}
+		list := filepath.Join(tmp, "members.txt")
+		if err := os.WriteFile(list, names.Bytes(), 0o600); err != nil {
+			return 0, err
+		}
+		args = []string{"x", "-so", "-y", "-spd", "-scsUTF-8", absPath, "@" + list}
+	}
+
+	pr, pw := io.Pipe()
+	streamErr := make(chan error, 1)
+	go func() {
+		err := sevenzip.Stream(binaryPath, password, args, pw, nil)
+		_ = pw.CloseWithError(err)
+		streamErr <- err
+	}()
+
+	total := 0
+	var scanErr error
+	for _, m := range members {
+		lr := &io.LimitedReader{R: pr, N: m.Size}
+		n, err := grepStream(lr, label+":"+m.Path, opts, w)
+		total += n
+		if err != nil {
+			scanErr = fmt.Errorf("%s: %w", m.Path, err)
+			break
+		}
+		// Skip what was not read (binary member, -l); a short member means
+		// 7z stopped early
+		_, _ = io.Copy(io.Discard, lr)
+		if lr.N > 0 {
+			scanErr = fmt.Errorf("%s: archive stream ended early", m.Path)
+			break
+		}
+	}
+	_, _ = io.Copy(io.Discard, pr) // let 7z finish after an early stop
+	if err := <-streamErr; err != nil {
+		return total, err
+	}
+	return total, scanErr
+}
+
+// grepStream matches r line by line and prints "prefix:line" for each hit.
+// Binary input (NUL in the first binarySniffLen bytes) is skipped unless
+// opts.Binary is set. With opts.FilesOnly only "prefix" is printed, once.
+func grepStream(r io.Reader, prefix string, opts grepOptions, w io.Writer) (int, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	head, _ := br.Peek(binarySniffLen)
+	if !opts.Binary && bytes.IndexByte(head, 0) >= 0 {
+		return 0, nil
+	}
+
+	sc := bufio.NewScanner(br)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	matches := 0
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := sc.Bytes()
+		if !opts.Re.Match(line) {
+			continue
+		}
+		matches++
+		switch {
+		case opts.FilesOnly:
+			fmt.Fprintln(w, prefix)
+			return matches, nil
+		case opts.LineNumbers:
+			fmt.Fprintf(w, "%s:%d:%s\n", prefix, lineNo, line)
+		default:
+			fmt.Fprintf(w, "%s:%s\n", prefix, line)
+		}
+	}
+	return matches, sc.Err()
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+func TestGrepStream(t *testing.T) {
+	input := "started\nINC-20931 opened\nok\ninc-20931 closed\n"
+	re := regexp.MustCompile(`(?i)INC-20931`)
+
+	tests := []struct {
+		name string
+		opts grepOptions
+		want string
+		n    int
+	}{
+		{"plain", grepOptions{Re: re}, "a.7z:app.log:INC-20931 opened\na.7z:app.log:inc-20931 closed\n", 2},
+		{"line numbers", grepOptions{Re: re, LineNumbers: true}, "a.7z:app.log:2:INC-20931 opened\na.7z:app.log:4:inc-20931 closed\n", 2},
+		{"files only", grepOptions{Re: re, FilesOnly: true}, "a.7z:app.log\n", 1},
+		{"no match", grepOptions{Re: regexp.MustCompile("nothing")}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			n, err := grepStream(strings.NewReader(input), "a.7z:app.log", tt.opts, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.n || out.String() != tt.want {
+				t.Errorf("grepStream = %d, %q; want %d, %q", n, out.String(), tt.n, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepStream_Binary(t *testing.T) {
+	input := "PNG\x00\x01\x02 secret-token \n"
+	re := regexp.MustCompile("secret-token")
+
+	var out bytes.Buffer
+	if n, _ := grepStream(strings.NewReader(input), "a.7z:img.png", grepOptions{Re: re}, &out); n != 0 || out.Len() != 0 {
+		t.Errorf("binary member should be skipped, got %d matches: %q", n, out.String())
+	}
+	if n, _ := grepStream(strings.NewReader(input), "a.7z:img.png", grepOptions{Re: re, Binary: true}, &out); n != 1 {
+		t.Errorf("--binary should search binary members, got %d matches", n)
+	}
+}
+
+func TestMemberSelected(t *testing.T) {
+	opts := grepOptions{Include: []string{"*.log", "etc/*"}, Exclude: []string{"debug.log"}}
+	tests := map[string]bool{
+		"app.log":          true,
+		"2024/06/app.log":  true, // base name matches *.log
+		"etc/hosts":        true,
+		"2024/debug.log":   false,
+		"readme.txt":       false,
+		"etc/nested/x.cfg": false,
+	}
+	for member, want := range tests {
+		if got := memberSelected(member, opts); got != want {
+			t.Errorf("memberSelected(%q) = %v, want %v", member, got, want)
+		}
+	}
+	if !memberSelected("anything.bin", grepOptions{}) {
+		t.Error("without globs every member is selected")
+	}
+}
+
+func TestExpandArchiveArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.7z", "b.7z.001", "b.7z.002", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := expandArchiveArgs([]string{dir, "other.7z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.7z"), filepath.Join(dir, "b.7z.001"), "other.7z"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandArchiveArgs = %v, want %v", got, want)
+	}
+
+	if _, err := expandArchiveArgs([]string{t.TempDir()}); err == nil {
+		t.Error("expected error for a directory without archives")
+	}
+}
+
+// All members come out of one 7z run, split by their listed sizes.
+func TestGrepMembers_SingleRun(t *testing.T) {
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	script := `#!/bin/sh
+printf 'Enter password (will not be echoed):' >&2
+read -r pw
+echo "$*" >> "` + runs + `"
+for last; do :; done
+case "$last" in
+@*) cat "${last#@}" >> "` + runs + `" ;;
+esac
+printf 'alpha\nbeta\n'
+printf '\000bin'
+printf 'gamma\n'
+`
+	bin := filepath.Join(dir, "fake7z")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	members := []sevenzip.Entry{
+		{Path: "a.txt", Size: 11},
+		{Path: "blob", Size: 4},
+		{Path: "empty", Size: 0},
+		{Path: "sub/c.txt", Size: 6},
+	}
+	opts := grepOptions{Re: regexp.MustCompile("a")}
+
+	var out bytes.Buffer
+	n, err := grepMembers(bin, []byte("pw"), "/x.7z", "x.7z", members, false, opts, &out)
+	if err != nil {
+		t.Fatalf("grepMembers: %v", err)
+	}
+	want := "x.7z:a.txt:alpha\nx.7z:a.txt:beta\nx.7z:sub/c.txt:gamma\n"
+	if n != 3 || out.String() != want {
+		t.Errorf("got %d matches:\n%s\nwant:\n%s", n, out.String(), want)
+	}
+
+	// A subset is named in a list file; still a single run
+	_ = os.Remove(runs)
+	out.Reset()
+	if _, err := grepMembers(bin, []byte("pw"), "/x.7z", "x.7z", members, true, opts, &out); err != nil {
+		t.Fatalf("grepMembers subset: %v", err)
+	}
+	log, _ := os.ReadFile(runs)
+	if strings.Count(string(log), "-so") != 1 || !strings.Contains(string(log), "a.txt\nblob\nempty\nsub/c.txt\n") {
+		t.Errorf("7z runs:\n%s", log)
+	}
+
+	// Listing and stream disagree
+	short := append(members[:3:3], sevenzip.Entry{Path: "sub/c.txt", Size: 99})
+	if _, err := grepMembers(bin, []byte("pw"), "/x.7z", "x.7z", short, false, opts, &out); err == nil || !strings.Contains(err.Error(), "ended early") {
+		t.Errorf("expected a short-stream error, got %v", err)
+	}
+}
@@ -43,21 +43,42 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 
 // Priority order for commands
 var commandOrder = map[string]int{
-	"init":       1,
-	"a":          2,
-	"l":          3,
-	"x":          4,
-	"e":          5,
-	"u":          6,
-	"d":          7,
-	"rn":         8,
-	"t":          9,
-	"mv":         10,
-	"remove":     11,
-	"relink":     12,
-	"completion": 13,
-	"version":    14,
-	"help":       15,
+	"init":          1,
+	"a":             2,
+	"l":             3,
+	"x":             4,
+	"e":             5,
+	"u":             6,
+	"d":             7,
+	"rn":            8,
+	"t":             9,
+	"mv":            10,
+	"remove":        11,
+	"relink":        12,
+	"rekey":         13,
+	"repack":        14,
+	"adopt":         15,
+	"convert":       16,
+	"share":         17,
+	"grant":         18,
+	"split-secret":  19,
+	"escrow":        20,
+	"export":        21,
+	"import":        22,
+	"exec":          23,
+	"edit":          24,
+	"cat":           25,
+	"grep":          26,
+	"diff":          27,
+	"checksum":      28,
+	"find":          29,
+	"restore":       30,
+	"backup":        31,
+	"prune-backups": 32,
+	"watch":         33,
+	"completion":    34,
+	"version":       35,
+	"help":          36,
 }
 
 // Helper to sort commands based on priority
@@ -0,0 +1,270 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <inventory.kdbx|inventory.json|inventory.csv>",
+	Short: "Merge an exported inventory into the KeePassXC database",
+	Long: `Merges the output of '7zkpxc export' into the configured database.
+Entries are matched by their UUID8, never by title alone.
+
+A KDBX file from 'export --with-secrets' brings passwords along: entries
+whose UUID8 is not yet present are added, entries already present are left
+untouched. Legacy titles without a UUID8, or titles that collide with a
+different entry, get a fresh UUID8 on import.
+
+A JSON or CSV inventory has no passwords, so it can only refresh the last
+known archive path of entries that are already present.
+
+  7zkpxc import transfer.kdbx
+  7zkpxc import --dry-run inventory.json`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runImport,
+	GroupID: "actions",
+}
+
+func init() {
+	importCmd.Flags().String("format", "", "Inventory format: kdbx, json or csv (default: from extension)")
+	importCmd.Flags().String("from-group", "", "Group to read from a KDBX inventory (default: general.default_group)")
+	importCmd.Flags().Bool("dry-run", false, "Show what would be merged without changing the database")
+	rootCmd.AddCommand(importCmd)
+}
+
+// secretSource returns the password and notes for an inventory record.
+// The caller zeroes the password.
+type secretSource func(rec inventoryRecord) (password []byte, notes string, err error)
+
+// keePassSecrets reads secrets from the entry at rec.EntryPath in kp.
+func keePassSecrets(kp PasswordProvider) secretSource {
+	return func(rec inventoryRecord) ([]byte, string, error) {
+		password, err := kp.GetPassword(rec.EntryPath)
+		if err != nil {
+			return nil, "", err
+		}
+		notes, _ := kp.GetAttribute(rec.EntryPath, "Notes")
+		return password, notes, nil
+	}
+}
+
+// importResult tracks the outcome of merging a single inventory record.
+type importResult struct {
+	Entry  string
+	Status string // "imported", "updated", "present", "missing", "error"
+	Detail string
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	input := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	fromGroup, _ := cmd.Flags().GetString("from-group")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if fromGroup == "" {
+		fromGroup = cfg.General.DefaultGroup
+	}
+	if format == "" && strings.EqualFold(filepath.Ext(input), ".kdbx") {
+		format = "kdbx"
+	}
+	format = inventoryFormat(input, format)
+
+	absIn, err := filepath.Abs(input)
+	if err != nil {
+		absIn = input
+	}
+	if _, err := os.Stat(absIn); err != nil {
+		return fmt.Errorf("cannot access '%s': %w", input, err)
+	}
+	if absKdbx, _ := filepath.Abs(cfg.General.KdbxPath); absKdbx == absIn {
+		return fmt.Errorf("'%s' is the configured database — nothing to import", input)
+	}
+
+	var (
+		records []inventoryRecord
+		secrets secretSource
+	)
+	if format == "kdbx" {
+		src := keepass.New(absIn)
+		defer src.Close()
+		if records, err = collectInventory(src, fromGroup); err != nil {
+			return err
+		}
+		secrets = keePassSecrets(src)
+	} else {
+		f, err := os.Open(absIn)
+		if err != nil {
+			return err
+		}
+		records, err = readInventory(f, format)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	var existing []inventoryRecord
+	if kp.GroupExists(cfg.General.DefaultGroup) {
+		if existing, err = collectInventory(kp, cfg.General.DefaultGroup); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		fmt.Println("Dry run — the database is not changed.")
+	}
+	results := mergeInventory(kp, cfg.General.DefaultGroup, existing, records, secrets, dryRun)
+	return printImportSummary(results)
+}
+
+// mergeInventory merges records into group of dst. existing is the current
+// inventory of that group. Records are matched by UUID8: known entries only
+// get their last known path refreshed (when secrets is nil), unknown ones
+// are added when secrets is available. Titles without a UUID8, or that clash
+// with a different entry, are re-titled with a fresh UUID8.
+func mergeInventory(dst EntryMigrator, group string, existing, records []inventoryRecord, secrets secretSource, dryRun bool) []importResult {
+	byUUID := make(map[string]inventoryRecord, len(existing))
+	titles := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		if e.UUID8 != "" {
+			byUUID[e.UUID8] = e
+		}
+		titles[e.Title] = true
+	}
+
+	var results []importResult
+	for _, rec := range records {
+		if cur, ok := byUUID[rec.UUID8]; ok && rec.UUID8 != "" {
+			results = append(results, refreshKnownEntry(dst, cur, rec, secrets == nil, dryRun))
+			continue
+		}
+		if secrets == nil {
+			results = append(results, importResult{Entry: rec.Title, Status: "missing", Detail: "not in this database and the inventory has no password"})
+			continue
+		}
+		results = append(results, importEntry(dst, group, titles, rec, secrets, dryRun))
+	}
+	return results
+}
+
+// refreshKnownEntry updates the last known path of an entry that is already
+// present, if the inventory knows a different one and updates are allowed.
+func refreshKnownEntry(dst EntryMigrator, cur, rec inventoryRecord, updatePath, dryRun bool) importResult {
+	if !updatePath || rec.LastKnownPath == "" || rec.LastKnownPath == cur.LastKnownPath {
+		return importResult{Entry: cur.Title, Status: "present"}
+	}
+	if !dryRun {
+		if err := dst.UpdateEntryUsername(cur.EntryPath, rec.LastKnownPath); err != nil {
+			return importResult{Entry: cur.Title, Status: "error", Detail: err.Error()}
+		}
+	}
+	return importResult{Entry: cur.Title, Status: "updated", Detail: rec.LastKnownPath}
+}
+
+// importEntry adds rec with its password to group, re-titling it with a
+// fresh UUID8 if its title has none or is already taken.
+func importEntry(dst EntryMigrator, group string, titles map[string]bool, rec inventoryRecord, secrets secretSource, dryRun bool) importResult {
+	title := rec.Title
+	if rec.UUID8 == "" || titles[title] {
+		basename, _, ok := parseEntryTitle(title)
+		if !ok {
+			basename = title
+			if rec.LastKnownPath != "" {
+				basename = filepath.Base(rec.LastKnownPath)
+			}
+		}
+		uuid8, err := generateUniqueUUID8(dst, group, basename)
+		if err != nil {
+			return importResult{Entry: rec.Title, Status: "error", Detail: err.Error()}
+		}
+		title = makeEntryTitle(basename, uuid8)
+	}
+	titles[title] = true
+
+	detail := ""
+	if title != rec.Title {
+		detail = "as '" + title + "'"
+	}
+	if dryRun {
+		return importResult{Entry: rec.Title, Status: "imported", Detail: detail}
+	}
+
+	password, notes, err := secrets(rec)
+	if err != nil {
+		return importResult{Entry: rec.Title, Status: "error", Detail: err.Error()}
+	}
+	err = dst.AddEntry(group, title, password, rec.LastKnownPath, "https://github.com/lxstig/7zkpxc")
+	clear(password)
+	if err != nil {
+		return importResult{Entry: rec.Title, Status: "error", Detail: err.Error()}
+	}
+
+	if notes != "" {
+		entryPath := filepath.ToSlash(filepath.Clean(group + "/" + title))
+		if err := dst.UpdateEntryNotes(entryPath, notes); err != nil {
+			fmt.Printf("Warning: could not copy notes of '%s': %v\n", title, err)
+		}
+	}
+	return importResult{Entry: rec.Title, Status: "imported", Detail: detail}
+}
+
+func printImportSummary(results []importResult) error {
+	var imported, updated, present, missing, failed []string
+	for _, r := range results {
+		switch r.Status {
+		case "imported":
+			imported = append(imported, r.Entry)
+		case "updated":
+			updated = append(updated, r.Entry)
+		case "present":
+			present = append(present, r.Entry)
+		case "missing":
+			missing = append(missing, r.Entry)
+		default:
+			failed = append(failed, r.Entry)
+		}
+		if r.Status != "present" && r.Detail != "" {
+			fmt.Printf("  %s: %s (%s)\n", r.Status, r.Entry, r.Detail)
+		}
+	}
+
+	fmt.Println("─────────────────────────────────")
+	fmt.Println("Import Summary:")
+	if len(imported) > 0 {
+		fmt.Printf("  ✓ Imported:  %d  (%s)\n", len(imported), joinWords(imported))
+	}
+	if len(updated) > 0 {
+		fmt.Printf("  ✓ Updated:   %d  (last known path refreshed)\n", len(updated))
+	}
+	if len(present) > 0 {
+		fmt.Printf("  ─ Present:   %d  (already in the database)\n", len(present))
+	}
+	if len(missing) > 0 {
+		fmt.Printf("  ⚠ Missing:   %d  (%s)\n", len(missing), joinWords(missing))
+	}
+	if len(failed) > 0 {
+		fmt.Printf("  ✗ Failed:    %d  (%s)\n", len(failed), joinWords(failed))
+	}
+	fmt.Println("─────────────────────────────────")
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d entries could not be imported", len(failed))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// copyingMock stores a copy of added passwords, like keepassxc-cli does,
+// so zeroing the caller's slice after AddEntry does not affect the entry.
+type copyingMock struct {
+	*MockPasswordProvider
+}
+
+func (m copyingMock) AddEntry(group, title string, password []byte, username, url string) error {
+	return m.MockPasswordProvider.AddEntry(group, title, bytes.Clone(password), username, url)
+}
+
+func TestMergeInventory_WithSecrets(t *testing.T) {
+	src := NewMockPasswordProvider()
+	addUUIDEntry(src, "Archives", "new.7z", "11111111", "/data/new.7z", []byte("pw-new"))
+	src.SetAttribute("Archives/new.7z (11111111)", "Notes", "[7zkpxc]\nsize=7\n")
+	addUUIDEntry(src, "Archives", "same.7z", "22222222", "/data/same.7z", []byte("pw-same"))
+	src.SetPassword("Archives/legacy.7z", []byte("pw-legacy"))
+	src.SetAttribute("Archives/legacy.7z", "Username", "/old/legacy.7z")
+
+	dst := NewMockPasswordProvider()
+	addUUIDEntry(dst, "Archives", "same.7z", "22222222", "/data/same.7z", []byte("pw-same"))
+
+	records, err := collectInventory(src, "Archives")
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing, _ := collectInventory(dst, "Archives")
+
+	results := mergeInventory(copyingMock{dst}, "Archives", existing, records, keePassSecrets(src), false)
+	status := map[string]importResult{}
+	for _, r := range results {
+		status[r.Entry] = r
+	}
+
+	if status["same.7z (22222222)"].Status != "present" {
+		t.Errorf("matching UUID8 must be left alone, got %+v", status["same.7z (22222222)"])
+	}
+	if status["new.7z (11111111)"].Status != "imported" {
+		t.Errorf("new entry not imported: %+v", status["new.7z (11111111)"])
+	}
+	if pw, _ := dst.GetPassword("Archives/new.7z (11111111)"); string(pw) != "pw-new" {
+		t.Errorf("imported password = %q", pw)
+	}
+	if notes, _ := dst.GetAttribute("Archives/new.7z (11111111)", "Notes"); notes != "[7zkpxc]\nsize=7\n" {
+		t.Errorf("notes not copied: %q", notes)
+	}
+
+	// Legacy title gets a fresh UUID8 from the last known path
+	legacy := status["legacy.7z"]
+	if legacy.Status != "imported" || !strings.HasPrefix(legacy.Detail, "as 'legacy.7z (") {
+		t.Errorf("legacy entry = %+v", legacy)
+	}
+}
+
+func TestMergeInventory_TitleCollision(t *testing.T) {
+	// Same title but the source entry has no UUID8 match: re-title instead of overwriting
+	dst := NewMockPasswordProvider()
+	dst.SetPassword("Archives/legacy.7z", []byte("pw-dst"))
+	existing, _ := collectInventory(dst, "Archives")
+
+	src := NewMockPasswordProvider()
+	src.SetPassword("Archives/legacy.7z", []byte("pw-src"))
+	records, _ := collectInventory(src, "Archives")
+
+	results := mergeInventory(copyingMock{dst}, "Archives", existing, records, keePassSecrets(src), false)
+	if len(results) != 1 || results[0].Status != "imported" {
+		t.Fatalf("results = %+v", results)
+	}
+	if pw, _ := dst.GetPassword("Archives/legacy.7z"); string(pw) != "pw-dst" {
+		t.Errorf("existing entry was overwritten: %q", pw)
+	}
+}
+
+func TestMergeInventory_WithoutSecrets(t *testing.T) {
+	dst := NewMockPasswordProvider()
+	addUUIDEntry(dst, "Archives", "a.7z", "aaaaaaaa", "/old/a.7z", []byte("pw"))
+	existing, _ := collectInventory(dst, "Archives")
+
+	records := []inventoryRecord{
+		{Title: "a.7z (aaaaaaaa)", UUID8: "aaaaaaaa", LastKnownPath: "/new/a.7z"},
+		{Title: "b.7z (bbbbbbbb)", UUID8: "bbbbbbbb", LastKnownPath: "/new/b.7z"},
+	}
+
+	// Dry run reports but does not touch the database
+	results := mergeInventory(dst, "Archives", existing, records, nil, true)
+	if results[0].Status != "updated" || results[1].Status != "missing" {
+		t.Fatalf("dry run results = %+v", results)
+	}
+	if u, _ := dst.GetAttribute("Archives/a.7z (aaaaaaaa)", "Username"); u != "/old/a.7z" {
+		t.Errorf("dry run changed the last known path to %q", u)
+	}
+
+	mergeInventory(dst, "Archives", existing, records, nil, false)
+	if u, _ := dst.GetAttribute("Archives/a.7z (aaaaaaaa)", "Username"); u != "/new/a.7z" {
+		t.Errorf("last known path = %q, want /new/a.7z", u)
+	}
+	if _, err := dst.GetPassword("Archives/b.7z (bbbbbbbb)"); err == nil {
+		t.Error("an inventory without passwords must not create entries")
+	}
+}
@@ -0,0 +1,369 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+)
+
+// -------------------------------------------------------------------
+// Incremental backups
+//
+// An increment holds only the files that are new or changed since the
+// previous backup of the profile, plus a tombstone file listing the paths
+// deleted since then. Its entry records the chain (base= and parent= UUID8)
+// and a state attachment: the full file list the chain represents after
+// this increment, which the next increment is compared against.
+// -------------------------------------------------------------------
+
+// stateAttachment holds the cumulative file list of a backup chain, encoded
+// like the manifest. Full backups have none; their manifest is their state.
+const stateAttachment = "7zkpxc-state.json.gz"
+
+// tombstoneFile is the archive member listing deleted paths, one per line.
+const tombstoneFile = ".7zkpxc-tombstones"
+
+// errNoBaseline means there is no usable previous backup to compare against.
+var errNoBaseline = errors.New("no previous backup to build on")
+
+// sourceFile is a file found below a source. Rel is its path inside the
+// archive (relative to Dir, the parent of the source). An empty Dir marks a
+// path handed to 7z verbatim from the current directory.
+type sourceFile struct {
+	Dir  string
+	Rel  string
+	Size int64
+	Mod  int64 // Unix seconds
+}
+
+// createIncrement writes an increment of profile on top of its newest
+// backup. It returns "" when nothing changed, and errNoBaseline when a full
+// backup is needed first.
+func createIncrement(cfg *config.Config, kp *keepass.Client, name string, p config.BackupProfile, group, archivePath string, sources, presets []string) (string, error) {
+	archives, err := collectBackups(kp, pruneSet{Group: group, Match: profileMatcher(name, p)})
+	if err != nil {
+		return "", err
+	}
+	if len(archives) == 0 {
+		return "", errNoBaseline
+	}
+	prev := archives[0]
+	for _, a := range archives[1:] {
+		if a.Time.After(prev.Time) {
+			prev = a
+		}
+	}
+	prevState, err := loadBackupState(kp, prev.EntryPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", errNoBaseline, filepath.Base(prev.Path), err)
+	}
+
+	current, err := scanSources(sources, p.Excludes)
+	if err != nil {
+		return "", err
+	}
+	changed, deleted, err := planIncrement(prevState, current, func(f sourceFile) (string, error) {
+		return fileCRC32(filepath.Join(f.Dir, filepath.FromSlash(f.Rel)))
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(changed) == 0 && len(deleted) == 0 {
+		fmt.Printf("No changes since '%s' — no archive created.\n", filepath.Base(prev.Path))
+		return "", nil
+	}
+	fmt.Printf("Incremental backup on top of '%s': %d new or changed, %d deleted.\n", filepath.Base(prev.Path), len(changed), len(deleted))
+
+	files := make([]sourceFile, 0, len(changed))
+	for _, key := range changed {
+		files = append(files, current[key])
+	}
+	pack := packIncrement(cfg.SevenZip.BinaryPath, presets, archivePath, files, deleted)
+	entryPath, err := createManagedArchive(cfg, kp, group, archivePath, sources, pack)
+	if err != nil {
+		return "", err
+	}
+
+	_, prevUUID, _ := parseEntryTitle(path.Base(prev.EntryPath))
+	prevNotes, _ := kp.GetAttribute(prev.EntryPath, "Notes")
+	base := parseMetadata(prevNotes).Base
+	if base == "" {
+		base = prevUUID
+	}
+	recordChain(kp, entryPath, base, prevUUID)
+
+	if err := storeBackupState(kp, entryPath, prevState, deleted); err != nil {
+		fmt.Printf("Note: could not store the backup state (%v) — the next incremental backup will be a full one.\n", err)
+	}
+	return archivePath, nil
+}
+
+// backupStore is what incremental backups need from KeePassXC.
+type backupStore interface {
+	PasswordProvider
+	manifestStore
+}
+
+// storeBackupState folds the increment's manifest (just stored by
+// createManagedArchive) into the previous state and attaches the result.
+func storeBackupState(kp backupStore, entryPath string, prev archiveManifest, deleted []string) error {
+	data, err := kp.ExportAttachment(entryPath, manifestAttachment)
+	if err != nil {
+		return err
+	}
+	inc, err := decodeManifest(data)
+	if err != nil {
+		return err
+	}
+	encoded, err := encodeManifest(mergeState(prev, deleted, inc))
+	if err != nil {
+		return err
+	}
+	return kp.ImportAttachment(entryPath, stateAttachment, encoded)
+}
+
+// loadBackupState returns the cumulative file list of the chain ending at
+// entryPath: its state attachment, or for a full backup its manifest.
+func loadBackupState(kp backupStore, entryPath string) (archiveManifest, error) {
+	if data, err := kp.ExportAttachment(entryPath, stateAttachment); err == nil && len(data) > 0 {
+		return decodeManifest(data)
+	}
+	if notes, err := kp.GetAttribute(entryPath, "Notes"); err == nil && parseMetadata(notes).Parent != "" {
+		return archiveManifest{}, fmt.Errorf("increment has no stored state")
+	}
+	data, err := kp.ExportAttachment(entryPath, manifestAttachment)
+	if err != nil || len(data) == 0 {
+		return archiveManifest{}, fmt.Errorf("no manifest stored")
+	}
+	return decodeManifest(data)
+}
+
+// recordChain stores the base and parent UUID8 of an increment. Non-fatal,
+// like updateMetadata.
+func recordChain(kp PasswordProvider, entryPath, base, parent string) {
+	notes, _ := kp.GetAttribute(entryPath, "Notes")
+	meta := parseMetadata(notes)
+	meta.Base, meta.Parent = base, parent
+	_ = kp.UpdateEntryNotes(entryPath, mergeMetadataIntoNotes(notes, meta))
+}
+
+// scanSources lists the regular files below sources, keyed by the path 7z
+// stores them under (relative to each source's parent). Files matching an
+// exclude pattern in any path component are skipped, like 7z's -xr!.
+func scanSources(sources, excludes []string) (map[string]sourceFile, error) {
+	files := make(map[string]sourceFile)
+	for _, src := range sources {
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return nil, err
+		}
+		parent := filepath.Dir(abs)
+		err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(parent, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if excludedPath(rel, excludes) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files[rel] = sourceFile{Dir: parent, Rel: rel, Size: info.Size(), Mod: info.ModTime().Unix()}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan '%s': %w", src, err)
+		}
+	}
+	return files, nil
+}
+
+// excludedPath reports whether any component of rel matches an exclude.
+func excludedPath(rel string, excludes []string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		for _, ex := range excludes {
+			if ok, _ := path.Match(ex, part); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// planIncrement compares the source tree with the previous state. A file is
+// changed when it is new, its size differs, or its mtime differs and its
+// CRC32 (computed by hash) does too. Both lists are sorted.
+func planIncrement(prev archiveManifest, current map[string]sourceFile, hash func(sourceFile) (string, error)) (changed, deleted []string, err error) {
+	known := make(map[string]manifestFile, len(prev.Files))
+	for _, f := range prev.Files {
+		known[f.Path] = f
+	}
+
+	for key, cur := range current {
+		old, ok := known[key]
+		switch {
+		case !ok || old.Size != cur.Size:
+			changed = append(changed, key)
+		case old.Modified.Unix() == cur.Mod:
+			// unchanged
+		case old.CRC == "":
+			changed = append(changed, key)
+		default:
+			crc, err := hash(cur)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !strings.EqualFold(crc, old.CRC) {
+				changed = append(changed, key)
+			}
+		}
+	}
+	for key := range known {
+		if _, ok := current[key]; !ok {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted, nil
+}
+
+// mergeState applies an increment to the previous state: deleted paths are
+// dropped and the files of the increment replace or extend the rest.
+func mergeState(prev archiveManifest, deleted []string, inc archiveManifest) archiveManifest {
+	files := make(map[string]manifestFile, len(prev.Files)+len(inc.Files))
+	for _, f := range prev.Files {
+		files[f.Path] = f
+	}
+	for _, p := range deleted {
+		delete(files, p)
+	}
+	for _, f := range inc.Files {
+		if f.Path != tombstoneFile {
+			files[f.Path] = f
+		}
+	}
+
+	state := archiveManifest{Version: manifestVersion, Files: make([]manifestFile, 0, len(files))}
+	for _, f := range files {
+		state.Files = append(state.Files, f)
+	}
+	sort.Slice(state.Files, func(i, j int) bool { return state.Files[i].Path < state.Files[j].Path })
+	return state
+}
+
+// packIncrement returns a packFunc that adds files to a new archive, plus
+// the tombstone file when paths were deleted. A partially written archive,
+// with all of its volumes, is removed on failure.
+func packIncrement(binaryPath string, presets []string, archivePath string, files []sourceFile, deleted []string) packFunc {
+	return func(password []byte) (err error) {
+		defer func() {
+			if err != nil {
+				_ = removeArchiveVolumes(archivePath)
+			}
+		}()
+
+		items := files
+		if len(deleted) > 0 {
+			tmp, err := newPrivateTempDir("7zkpxc-incr-*")
+			if err != nil {
+				return err
+			}
+			defer func() { _ = wipeDir(tmp) }()
+			if err := os.WriteFile(filepath.Join(tmp, tombstoneFile), []byte(strings.Join(deleted, "\n")+"\n"), 0o600); err != nil {
+				return err
+			}
+			items = append(append([]sourceFile{}, files...), sourceFile{Dir: tmp, Rel: tombstoneFile})
+		}
+
+		base := append(append([]string{"a"}, presets...), "-p")
+		return runFileLists(binaryPath, password, base, archivePath, items, nil)
+	}
+}
+
+// applyTombstones deletes the paths listed in dir's tombstone file (left
+// there by extracting an increment) and then the file itself.
+func applyTombstones(dir string) error {
+	list := filepath.Join(dir, tombstoneFile)
+	data, err := os.ReadFile(list)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		p := path.Clean(strings.TrimSpace(line))
+		if line == "" || p == "." || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, filepath.FromSlash(p))); err != nil {
+			return err
+		}
+	}
+	return os.Remove(list)
+}
+
+// chainLink is one archive of a backup chain.
+type chainLink struct {
+	EntryPath string
+	Path      string
+}
+
+// resolveChain follows parent= links from entryPath back to the full
+// backup and returns the chain base first.
+func resolveChain(kp PasswordProvider, entryPath string) ([]chainLink, error) {
+	group := path.Dir(entryPath)
+	titles, err := kp.ListEntries(group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entries in '%s': %w", group, err)
+	}
+	byUUID := make(map[string]string, len(titles))
+	for _, title := range titles {
+		if _, uuid8, ok := parseEntryTitle(title); ok {
+			byUUID[uuid8] = joinEntry(group, title)
+		}
+	}
+
+	var chain []chainLink
+	seen := make(map[string]bool)
+	for cur := entryPath; ; {
+		if seen[cur] {
+			return nil, fmt.Errorf("backup chain loops at '%s'", cur)
+		}
+		seen[cur] = true
+		archive, _ := kp.GetAttribute(cur, "Username")
+		chain = append([]chainLink{{EntryPath: cur, Path: archive}}, chain...)
+
+		notes, _ := kp.GetAttribute(cur, "Notes")
+		parent := parseMetadata(notes).Parent
+		if parent == "" {
+			return chain, nil
+		}
+		next, ok := byUUID[parent]
+		if !ok {
+			return nil, fmt.Errorf("backup chain is broken: parent (%s) of '%s' not found", parent, path.Base(cur))
+		}
+		cur = next
+	}
+}
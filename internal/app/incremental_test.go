@@ -0,0 +1,203 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeBackupStore adds attachments to the mock provider.
+type fakeBackupStore struct {
+	*MockPasswordProvider
+	files map[string][]byte // entryPath + "|" + name
+}
+
+func (f *fakeBackupStore) ExportAttachment(entryPath, name string) ([]byte, error) {
+	data, ok := f.files[entryPath+"|"+name]
+	if !ok {
+		return nil, fmt.Errorf("no attachment")
+	}
+	return data, nil
+}
+
+func (f *fakeBackupStore) ImportAttachment(entryPath, name string, data []byte) error {
+	f.files[entryPath+"|"+name] = data
+	return nil
+}
+
+func TestPlanIncrement(t *testing.T) {
+	mod := time.Unix(1700000000, 0)
+	prev := archiveManifest{Files: []manifestFile{
+		{Path: "docs/same.txt", Size: 3, Modified: mod, CRC: "AAAAAAAA"},
+		{Path: "docs/grown.txt", Size: 3, Modified: mod, CRC: "AAAAAAAA"},
+		{Path: "docs/touched.txt", Size: 3, Modified: mod, CRC: "AAAAAAAA"},
+		{Path: "docs/edited.txt", Size: 3, Modified: mod, CRC: "AAAAAAAA"},
+		{Path: "docs/gone.txt", Size: 3, Modified: mod, CRC: "AAAAAAAA"},
+	}}
+	current := map[string]sourceFile{
+		"docs/same.txt":    {Rel: "docs/same.txt", Size: 3, Mod: mod.Unix()},
+		"docs/grown.txt":   {Rel: "docs/grown.txt", Size: 4, Mod: mod.Unix()},
+		"docs/touched.txt": {Rel: "docs/touched.txt", Size: 3, Mod: mod.Unix() + 60},
+		"docs/edited.txt":  {Rel: "docs/edited.txt", Size: 3, Mod: mod.Unix() + 60},
+		"docs/new.txt":     {Rel: "docs/new.txt", Size: 1, Mod: mod.Unix()},
+	}
+	hashed := 0
+	changed, deleted, err := planIncrement(prev, current, func(f sourceFile) (string, error) {
+		hashed++
+		if f.Rel == "docs/touched.txt" {
+			return "aaaaaaaa", nil
+		}
+		return "BBBBBBBB", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docs/edited.txt", "docs/grown.txt", "docs/new.txt"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	if want := []string{"docs/gone.txt"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+	if hashed != 2 {
+		t.Errorf("hashed %d files, want only the 2 with a new mtime", hashed)
+	}
+}
+
+func TestMergeState(t *testing.T) {
+	prev := archiveManifest{Files: []manifestFile{{Path: "a", Size: 1}, {Path: "b", Size: 1}, {Path: "c", Size: 1}}}
+	inc := archiveManifest{Files: []manifestFile{{Path: "b", Size: 2}, {Path: "d", Size: 1}, {Path: tombstoneFile, Size: 2}}}
+
+	state := mergeState(prev, []string{"c"}, inc)
+	var got []string
+	for _, f := range state.Files {
+		got = append(got, fmt.Sprintf("%s:%d", f.Path, f.Size))
+	}
+	if want := []string{"a:1", "b:2", "d:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("state = %v, want %v", got, want)
+	}
+}
+
+func TestScanSources_Excludes(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "proj")
+	for _, p := range []string{"main.go", "tmp/x.tmp", "node_modules/pkg/index.js", "lib/util.go", "lib/cache.tmp"} {
+		full := filepath.Join(src, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := scanSources([]string{src}, []string{"node_modules", "*.tmp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for key, f := range files {
+		if f.Dir != root {
+			t.Errorf("%s: Dir = %q, want %q", key, f.Dir, root)
+		}
+		got = append(got, key)
+	}
+	if len(got) != 2 || files["proj/main.go"].Size != 1 || files["proj/lib/util.go"].Rel != "proj/lib/util.go" {
+		t.Errorf("files = %v", got)
+	}
+}
+
+func TestApplyTombstones(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"proj/keep.txt", "proj/old.txt", "proj/olddir/x.txt"} {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(filepath.Dir(dir), "outside.txt")
+	list := "proj/old.txt\nproj/olddir\n../outside.txt\n/etc/passwd\n"
+	if err := os.WriteFile(filepath.Join(dir, tombstoneFile), []byte(list), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(outside, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(outside)
+
+	if err := applyTombstones(dir); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]bool{"proj/keep.txt": true, "proj/old.txt": false, "proj/olddir": false, tombstoneFile: false} {
+		if _, err := os.Stat(filepath.Join(dir, p)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", p, err == nil, want)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("path outside the directory was deleted")
+	}
+	if err := applyTombstones(dir); err != nil {
+		t.Errorf("no tombstone file should not be an error: %v", err)
+	}
+}
+
+func TestResolveChain(t *testing.T) {
+	m := NewMockPasswordProvider()
+	full := addUUIDEntry(m, "backups", "home-1.7z", "aaaaaaaa", "/b/home-1.7z", []byte("p1"))
+	inc1 := addUUIDEntry(m, "backups", "home-2.7z", "bbbbbbbb", "/b/home-2.7z", []byte("p2"))
+	inc2 := addUUIDEntry(m, "backups", "home-3.7z", "cccccccc", "/b/home-3.7z", []byte("p3"))
+	m.SetAttribute(inc1, "Notes", buildMetadataSection(EntryMetadata{Base: "aaaaaaaa", Parent: "aaaaaaaa"}))
+	m.SetAttribute(inc2, "Notes", buildMetadataSection(EntryMetadata{Base: "aaaaaaaa", Parent: "bbbbbbbb"}))
+
+	chain, err := resolveChain(m, inc2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []chainLink{{full, "/b/home-1.7z"}, {inc1, "/b/home-2.7z"}, {inc2, "/b/home-3.7z"}}
+	if !reflect.DeepEqual(chain, want) {
+		t.Errorf("chain = %v, want %v", chain, want)
+	}
+
+	m.SetAttribute(inc1, "Notes", buildMetadataSection(EntryMetadata{Parent: "99999999"}))
+	if _, err := resolveChain(m, inc2); err == nil {
+		t.Error("expected an error for a missing parent")
+	}
+	m.SetAttribute(inc1, "Notes", buildMetadataSection(EntryMetadata{Parent: "cccccccc"}))
+	if _, err := resolveChain(m, inc2); err == nil {
+		t.Error("expected an error for a loop")
+	}
+}
+
+func TestLoadBackupState(t *testing.T) {
+	kp := &fakeBackupStore{MockPasswordProvider: NewMockPasswordProvider(), files: map[string][]byte{}}
+	full := addUUIDEntry(kp.MockPasswordProvider, "backups", "home-1.7z", "aaaaaaaa", "/b/home-1.7z", nil)
+	inc := addUUIDEntry(kp.MockPasswordProvider, "backups", "home-2.7z", "bbbbbbbb", "/b/home-2.7z", nil)
+	kp.SetAttribute(inc, "Notes", buildMetadataSection(EntryMetadata{Base: "aaaaaaaa", Parent: "aaaaaaaa"}))
+
+	if _, err := loadBackupState(kp, full); err == nil {
+		t.Error("expected an error without a manifest")
+	}
+	kp.files[full+"|"+manifestAttachment] = mustEncodeManifest(t, "proj/a.txt")
+	state, err := loadBackupState(kp, full)
+	if err != nil || len(state.Files) != 1 {
+		t.Fatalf("full backup state = %+v, %v", state, err)
+	}
+
+	// An increment's manifest only covers its changes, so it is not a state.
+	kp.files[inc+"|"+manifestAttachment] = mustEncodeManifest(t, "proj/b.txt")
+	if _, err := loadBackupState(kp, inc); err == nil {
+		t.Error("expected an error for an increment without stored state")
+	}
+	if err := storeBackupState(kp, inc, state, []string{"proj/a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	state, err = loadBackupState(kp, inc)
+	if err != nil || len(state.Files) != 1 || state.Files[0].Path != "proj/b.txt" {
+		t.Errorf("increment state = %+v, %v", state, err)
+	}
+}
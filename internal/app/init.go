@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/chzyer/readline"
@@ -297,6 +299,11 @@ func runInit() error {
 	// --- Step 5: Test Connection ---
 	testConnectionAndCreateGroup(cfg)
 
+	// Backup profiles are not part of the setup; keep the existing ones
+	if old, err := config.LoadConfig(); err == nil {
+		cfg.Backups = old.Backups
+	}
+
 	// --- Save ---
 	if err := saveConfigWithComments(cfg); err != nil {
 		return fmt.Errorf("error saving config (check directory permissions): %w", err)
@@ -314,7 +321,7 @@ func runInit() error {
 func testConnectionAndCreateGroup(cfg *config.Config) {
 	fmt.Println("\nTesting connection to KeePassXC database...")
 	for {
-		kp := keepass.New(cfg.General.KdbxPath)
+		kp := keepass.New(cfg.General.KdbxPath, keepass.WithKeyFile(cfg.General.KeyFile))
 
 		if err := kp.VerifyConnection(); err != nil {
 			kp.Close()
@@ -525,6 +532,10 @@ func saveConfigWithComments(cfg *config.Config) error {
 	configTpl := `general:
   kdbx_path: "%s"
   default_group: "%s"
+  # optional KeePassXC key file, used together with the master password
+  key_file: "%s"
+  # optional age identity for archives granted to you with '7zkpxc grant'
+  age_identity: "%s"
   use_keyring: %t
   # generated password length (min: %d, max: %d)
   password_length: %d
@@ -542,12 +553,76 @@ sevenzip:
 	content := fmt.Sprintf(configTpl,
 		cfg.General.KdbxPath,
 		cfg.General.DefaultGroup,
+		cfg.General.KeyFile,
+		cfg.General.AgeIdentity,
 		cfg.General.UseKeyring,
 		config.PasswordLengthMin, config.PasswordLengthMax,
 		cfg.General.PasswordLength,
 		cfg.SevenZip.BinaryPath,
 		argsStr,
 	)
+	content += backupsYAML(cfg.Backups)
 
 	return os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0600)
 }
+
+// backupsYAML renders the backup profiles as the "backups:" section of the
+// config file, in profile name order, leaving out unset fields. It returns
+// "" when there are none.
+func backupsYAML(backups map[string]config.BackupProfile) string {
+	if len(backups) == 0 {
+		return ""
+	}
+	list := func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+
+	names := make([]string, 0, len(backups))
+	for name := range backups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("\nbackups:\n")
+	for _, name := range names {
+		p := backups[name]
+		fmt.Fprintf(&b, "  %s:\n", strconv.Quote(name))
+		fmt.Fprintf(&b, "    sources: %s\n", list(p.Sources))
+		if len(p.Excludes) > 0 {
+			fmt.Fprintf(&b, "    excludes: %s\n", list(p.Excludes))
+		}
+		fmt.Fprintf(&b, "    destination: %s\n", strconv.Quote(p.Destination))
+		if p.Name != "" {
+			fmt.Fprintf(&b, "    name: %s\n", strconv.Quote(p.Name))
+		}
+		if p.Group != "" {
+			fmt.Fprintf(&b, "    group: %s\n", strconv.Quote(p.Group))
+		}
+		if len(p.Args) > 0 {
+			fmt.Fprintf(&b, "    args: %s\n", list(p.Args))
+		}
+		if !p.Retention.IsZero() {
+			b.WriteString("    retention:\n")
+			for _, rule := range []struct {
+				key string
+				n   int
+			}{
+				{"keep_last", p.Retention.KeepLast},
+				{"keep_daily", p.Retention.KeepDaily},
+				{"keep_weekly", p.Retention.KeepWeekly},
+				{"keep_monthly", p.Retention.KeepMonthly},
+				{"keep_yearly", p.Retention.KeepYearly},
+			} {
+				if rule.n != 0 {
+					fmt.Fprintf(&b, "      %s: %d\n", rule.key, rule.n)
+				}
+			}
+		}
+	}
+	return b.String()
+}
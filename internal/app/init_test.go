@@ -3,6 +3,7 @@ package app
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -552,3 +553,37 @@ func TestSaveConfigWithComments(t *testing.T) {
 		t.Errorf("config file permissions = %v, want 0600", info.Mode().Perm())
 	}
 }
+
+// Re-running init must not drop the backup profiles.
+func TestSaveConfigWithComments_Backups(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config.ClearCache()
+	t.Cleanup(config.ClearCache)
+
+	cfg := &config.Config{
+		General:  config.GeneralConfig{KdbxPath: "/test/db.kdbx", DefaultGroup: "Archives", PasswordLength: 64},
+		SevenZip: config.SevenZipConfig{BinaryPath: "7z", DefaultArgs: []string{"-mhe=on"}},
+		Backups: map[string]config.BackupProfile{
+			"home": {
+				Sources:     []string{"~/docs", `C:\odd "name"`},
+				Excludes:    []string{"*.tmp"},
+				Destination: "/mnt/backup",
+				Name:        "{profile}-{date}.7z",
+				Args:        []string{"-mx=3"},
+				Retention:   config.RetentionPolicy{KeepLast: 3, KeepMonthly: 12},
+			},
+			"etc": {Sources: []string{"/etc"}, Destination: "/mnt/backup/etc"},
+		},
+	}
+	if err := saveConfigWithComments(cfg); err != nil {
+		t.Fatalf("saveConfigWithComments failed: %v", err)
+	}
+
+	loaded, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Backups, cfg.Backups) {
+		t.Errorf("Backups = %+v, want %+v", loaded.Backups, cfg.Backups)
+	}
+}
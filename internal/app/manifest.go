@@ -0,0 +1,120 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+// manifestAttachment is the name of the KeePass attachment holding the
+// gzip-compressed JSON file manifest of an archive.
+const manifestAttachment = "7zkpxc-manifest.json.gz"
+
+// manifestVersion is bumped when the manifest layout changes.
+const manifestVersion = 1
+
+// manifestFile describes one file inside an archive.
+type manifestFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"mtime"`
+	CRC      string    `json:"crc,omitempty"`
+}
+
+// archiveManifest is the file list of an archive as stored on its entry.
+// Being an attachment, it is encrypted with the rest of the KDBX database.
+type archiveManifest struct {
+	Version int            `json:"version"`
+	Files   []manifestFile `json:"files"`
+}
+
+// manifestReader reads attachments; implemented by *keepass.Client.
+type manifestReader interface {
+	ExportAttachment(entryPath, name string) ([]byte, error)
+}
+
+// manifestStore reads and writes attachments; implemented by *keepass.Client.
+type manifestStore interface {
+	manifestReader
+	ImportAttachment(entryPath, name string, data []byte) error
+}
+
+// buildManifest turns a technical listing into a manifest. Directories are
+// left out.
+func buildManifest(entries []sevenzip.Entry) archiveManifest {
+	m := archiveManifest{Version: manifestVersion, Files: []manifestFile{}}
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		m.Files = append(m.Files, manifestFile{
+			Path:     filepath.ToSlash(e.Path),
+			Size:     e.Size,
+			Modified: e.Modified,
+			CRC:      e.CRC,
+		})
+	}
+	return m
+}
+
+func encodeManifest(m archiveManifest) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(m); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeManifest(data []byte) (archiveManifest, error) {
+	var m archiveManifest
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version > manifestVersion {
+		return m, fmt.Errorf("manifest version %d is newer than this 7zkpxc supports", m.Version)
+	}
+	return m, nil
+}
+
+// refreshManifest lists the archive and stores its manifest on the entry.
+// Like updateMetadata it is non-fatal: the archive operation already
+// succeeded, so a failure only prints a note.
+func refreshManifest(cfg *config.Config, kp manifestStore, password []byte, entryPath, archivePath string) {
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+
+	entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, password, absPath)
+	if err != nil {
+		fmt.Printf("Note: could not update the file manifest: %v\n", err)
+		return
+	}
+	data, err := encodeManifest(buildManifest(entries))
+	if err != nil {
+		fmt.Printf("Note: could not update the file manifest: %v\n", err)
+		return
+	}
+	if err := kp.ImportAttachment(entryPath, manifestAttachment, data); err != nil {
+		fmt.Printf("Note: could not update the file manifest: %v\n", err)
+	}
+}
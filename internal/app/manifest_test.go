@@ -0,0 +1,65 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+func TestBuildManifest_SkipsDirectories(t *testing.T) {
+	mod := time.Date(2023, 7, 14, 9, 30, 0, 0, time.UTC)
+	m := buildManifest([]sevenzip.Entry{
+		{Path: "docs", IsDir: true},
+		{Path: "docs/invoice-2023-07.pdf", Size: 1234, Modified: mod, CRC: "CAFEBABE"},
+	})
+
+	if m.Version != manifestVersion {
+		t.Errorf("Version = %d, want %d", m.Version, manifestVersion)
+	}
+	if len(m.Files) != 1 {
+		t.Fatalf("got %d files, want 1: %+v", len(m.Files), m.Files)
+	}
+	f := m.Files[0]
+	if f.Path != "docs/invoice-2023-07.pdf" || f.Size != 1234 || !f.Modified.Equal(mod) || f.CRC != "CAFEBABE" {
+		t.Errorf("unexpected file: %+v", f)
+	}
+}
+
+func TestManifest_RoundTrip(t *testing.T) {
+	mod := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := archiveManifest{Version: manifestVersion, Files: []manifestFile{
+		{Path: "a.txt", Size: 3, Modified: mod, CRC: "352441C2"},
+		{Path: "sub/b.bin", Size: 0},
+	}}
+
+	data, err := encodeManifest(in)
+	if err != nil {
+		t.Fatalf("encodeManifest: %v", err)
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatalf("manifest is not gzip-compressed")
+	}
+
+	out, err := decodeManifest(data)
+	if err != nil {
+		t.Fatalf("decodeManifest: %v", err)
+	}
+	if len(out.Files) != 2 || out.Files[0].Path != "a.txt" || !out.Files[0].Modified.Equal(mod) || out.Files[1].Path != "sub/b.bin" {
+		t.Errorf("round trip mismatch: %+v", out)
+	}
+}
+
+func TestDecodeManifest_Invalid(t *testing.T) {
+	if _, err := decodeManifest([]byte("not gzip")); err == nil {
+		t.Error("expected error for non-gzip data")
+	}
+
+	data, err := encodeManifest(archiveManifest{Version: manifestVersion + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeManifest(data); err == nil {
+		t.Error("expected error for a newer manifest version")
+	}
+}
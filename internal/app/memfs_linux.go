@@ -0,0 +1,20 @@
+package app
+
+import "syscall"
+
+// Filesystem magic numbers from statfs(2) for RAM-backed filesystems.
+const (
+	tmpfsMagic = 0x01021994
+	ramfsMagic = 0x858458f6
+)
+
+// isMemoryBacked reports whether dir lives on tmpfs or ramfs, i.e. whether
+// files written there never reach a disk (swap aside).
+func isMemoryBacked(dir string) (bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return false, err
+	}
+	magic := uint32(st.Type) // int32 or int64 depending on the architecture
+	return magic == tmpfsMagic || magic == ramfsMagic, nil
+}
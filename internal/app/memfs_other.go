@@ -0,0 +1,10 @@
+//go:build !linux
+
+package app
+
+// isMemoryBacked reports whether dir lives on a RAM-backed filesystem.
+// There is no reliable check outside Linux, so every directory is treated
+// as disk-backed.
+func isMemoryBacked(dir string) (bool, error) {
+	return false, nil
+}
@@ -3,16 +3,31 @@ package app
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const metadataHeader = "[7zkpxc]"
 
 // EntryMetadata holds structured metadata stored in a KeePass entry's Notes field.
 type EntryMetadata struct {
-	Size int64  // archive file size in bytes; 0 means unknown
-	Ver  string // 7zkpxc version that last updated this entry
+	Size    int64     // archive file size in bytes; 0 means unknown
+	Ver     string    // 7zkpxc version that last updated this entry
+	Volumes int       // number of split volumes; 0 means a single file
+	Host    string    // host the archive was created on
+	Sources []string  // absolute source paths given to 'a' (one "source=" line each)
+	Created time.Time // when 'a' created the archive; zero if unknown
+	Base    string    // incremental backups: UUID8 of the full archive of the chain
+	Parent  string    // incremental backups: UUID8 of the previous archive
+	Payload string    // "tar" when the archive holds one tar stream ('a --tar')
+	SHA256  string    // SHA-256 of that tar stream, as written
+	// Reproducible is set for 'a --reproducible' archives, with Epoch the
+	// SOURCE_DATE_EPOCH every mtime was set to (one "reproducible=<epoch>" line).
+	Reproducible bool
+	Epoch        int64
 }
 
 // parseMetadata extracts EntryMetadata from a Notes string.
@@ -50,6 +65,25 @@ func parseMetadata(notes string) EntryMetadata {
 			m.Size, _ = strconv.ParseInt(val, 10, 64)
 		case "ver":
 			m.Ver = val
+		case "volumes":
+			m.Volumes, _ = strconv.Atoi(val)
+		case "host":
+			m.Host = val
+		case "source":
+			m.Sources = append(m.Sources, val)
+		case "created":
+			m.Created, _ = time.Parse(time.RFC3339, val)
+		case "base":
+			m.Base = val
+		case "parent":
+			m.Parent = val
+		case "payload":
+			m.Payload = val
+		case "sha256":
+			m.SHA256 = val
+		case "reproducible":
+			m.Epoch, _ = strconv.ParseInt(val, 10, 64)
+			m.Reproducible = true
 		}
 	}
 
@@ -67,6 +101,33 @@ func buildMetadataSection(m EntryMetadata) string {
 	if m.Ver != "" {
 		fmt.Fprintf(&b, "ver=%s\n", m.Ver)
 	}
+	if m.Volumes > 1 {
+		fmt.Fprintf(&b, "volumes=%d\n", m.Volumes)
+	}
+	if m.Host != "" {
+		fmt.Fprintf(&b, "host=%s\n", m.Host)
+	}
+	for _, src := range m.Sources {
+		fmt.Fprintf(&b, "source=%s\n", src)
+	}
+	if !m.Created.IsZero() {
+		fmt.Fprintf(&b, "created=%s\n", m.Created.UTC().Format(time.RFC3339))
+	}
+	if m.Base != "" {
+		fmt.Fprintf(&b, "base=%s\n", m.Base)
+	}
+	if m.Parent != "" {
+		fmt.Fprintf(&b, "parent=%s\n", m.Parent)
+	}
+	if m.Payload != "" {
+		fmt.Fprintf(&b, "payload=%s\n", m.Payload)
+	}
+	if m.SHA256 != "" {
+		fmt.Fprintf(&b, "sha256=%s\n", m.SHA256)
+	}
+	if m.Reproducible {
+		fmt.Fprintf(&b, "reproducible=%d\n", m.Epoch)
+	}
 	return b.String()
 }
 
@@ -123,13 +184,52 @@ func updateMetadata(kp PasswordProvider, entryPath, absArchivePath string) {
 	currentNotes, _ := kp.GetAttribute(entryPath, "Notes")
 	meta := parseMetadata(currentNotes)
 
+	// Only split archives record a volume count
+	volumes := 0
+	if n := len(archiveVolumes(absArchivePath)); n > 1 {
+		volumes = n
+	}
+
 	// Skip if nothing changed
-	if meta.Size == info.Size() && meta.Ver != "" {
+	if meta.Size == info.Size() && meta.Ver != "" && meta.Volumes == volumes {
 		return
 	}
 
 	meta.Size = info.Size()
+	meta.Volumes = volumes
 	meta.Ver = appVersion
 	newNotes := mergeMetadataIntoNotes(currentNotes, meta)
 	_ = kp.UpdateEntryNotes(entryPath, newNotes) // non-fatal
 }
+
+// recordSources adds the absolute paths of files (as given to 'a') and the
+// current host name to the entry's metadata, so that 'restore' can put the
+// top-level items back where they came from. A non-zero created is stored as
+// the creation time unless one is already recorded ('prune-backups' dates
+// archives by it). Non-fatal, like updateMetadata.
+func recordSources(kp PasswordProvider, entryPath string, files []string, created time.Time) {
+	currentNotes, _ := kp.GetAttribute(entryPath, "Notes")
+	meta := parseMetadata(currentNotes)
+
+	changed := false
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil || slices.Contains(meta.Sources, abs) {
+			continue
+		}
+		meta.Sources = append(meta.Sources, abs)
+		changed = true
+	}
+	if host, err := os.Hostname(); err == nil && meta.Host == "" {
+		meta.Host = host
+		changed = true
+	}
+	if !created.IsZero() && meta.Created.IsZero() {
+		meta.Created = created
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	_ = kp.UpdateEntryNotes(entryPath, mergeMetadataIntoNotes(currentNotes, meta)) // non-fatal
+}
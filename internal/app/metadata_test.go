@@ -195,3 +195,59 @@ func TestMetadata_Roundtrip(t *testing.T) {
 		t.Errorf("Ver roundtrip: %q → %q", original.Ver, parsed.Ver)
 	}
 }
+
+func TestMetadata_VolumesRoundtrip(t *testing.T) {
+	original := EntryMetadata{Size: 1024, Ver: "1.0.0", Volumes: 3}
+	parsed := parseMetadata(buildMetadataSection(original))
+	if parsed.Volumes != 3 {
+		t.Errorf("Volumes = %d, want 3", parsed.Volumes)
+	}
+}
+
+func TestBuildMetadataSection_SingleVolumeOmitted(t *testing.T) {
+	section := buildMetadataSection(EntryMetadata{Size: 10, Volumes: 1})
+	if strings.Contains(section, "volumes=") {
+		t.Errorf("single-volume archives should not record volumes, got %q", section)
+	}
+}
+
+func TestMetadata_SourcesRoundtrip(t *testing.T) {
+	original := EntryMetadata{Size: 10, Ver: "1.0.0", Host: "workstation", Sources: []string{"/home/u/proj", "/etc/app.conf"}}
+	parsed := parseMetadata(mergeMetadataIntoNotes("user notes", original))
+	if parsed.Host != "workstation" {
+		t.Errorf("Host = %q, want %q", parsed.Host, "workstation")
+	}
+	if strings.Join(parsed.Sources, ",") != "/home/u/proj,/etc/app.conf" {
+		t.Errorf("Sources = %v", parsed.Sources)
+	}
+}
+
+func TestMetadata_ChainRoundtrip(t *testing.T) {
+	original := EntryMetadata{Size: 10, Ver: "1.0.0", Base: "a3b2c1d0", Parent: "0f1e2d3c"}
+	parsed := parseMetadata(mergeMetadataIntoNotes("", original))
+	if parsed.Base != "a3b2c1d0" || parsed.Parent != "0f1e2d3c" {
+		t.Errorf("Base, Parent = %q, %q", parsed.Base, parsed.Parent)
+	}
+}
+
+func TestMetadata_PayloadRoundtrip(t *testing.T) {
+	original := EntryMetadata{Size: 10, Ver: "1.0.0", Payload: "tar"}
+	notes := mergeMetadataIntoNotes("user notes", original)
+	if !strings.Contains(notes, "payload=tar\n") {
+		t.Errorf("payload line missing from %q", notes)
+	}
+	if parsed := parseMetadata(notes); parsed.Payload != "tar" {
+		t.Errorf("Payload = %q, want tar", parsed.Payload)
+	}
+}
+
+func TestMetadata_ReproducibleRoundtrip(t *testing.T) {
+	original := EntryMetadata{Payload: "tar", SHA256: "ab12", Reproducible: true, Epoch: 0}
+	parsed := parseMetadata(mergeMetadataIntoNotes("", original))
+	if parsed.SHA256 != "ab12" || !parsed.Reproducible || parsed.Epoch != 0 {
+		t.Errorf("SHA256, Reproducible, Epoch = %q, %v, %d", parsed.SHA256, parsed.Reproducible, parsed.Epoch)
+	}
+	if parseMetadata(mergeMetadataIntoNotes("", EntryMetadata{Payload: "tar"})).Reproducible {
+		t.Error("Reproducible should only be set by its line")
+	}
+}
@@ -0,0 +1,383 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var pruneBackupsCmd = &cobra.Command{
+	Use:   "prune-backups [profile...] | --group <group>",
+	Short: "Delete expired backups and their KeePassXC entries",
+	Long: `Applies a retention policy to the archives of a backup profile (or of
+a KeePassXC group) and deletes the expired ones: every volume of the
+archive together with its entry.
+
+Profiles take their policy from the config; the --keep-* flags override it.
+
+  backups:
+    home:
+      ...
+      retention:
+        keep_last: 3
+        keep_daily: 7
+        keep_weekly: 4
+        keep_monthly: 12
+        keep_yearly: 5
+
+Archives are dated by the creation time 'a' records, or the file's
+modification time for older entries. At least --min-keep archives are
+always kept, whatever the policy says.
+
+  7zkpxc prune-backups --dry-run            # every profile with a policy
+  7zkpxc prune-backups home
+  7zkpxc prune-backups --group Archives/Old --keep-monthly 6 --force`,
+	RunE:    runPruneBackups,
+	GroupID: "actions",
+}
+
+func init() {
+	pruneBackupsCmd.Flags().String("group", "", "Prune the archives of this KeePassXC group instead of a profile")
+	pruneBackupsCmd.Flags().Int("keep-last", 0, "Keep the newest N archives")
+	pruneBackupsCmd.Flags().Int("keep-daily", 0, "Keep the newest archive of each of the last N days")
+	pruneBackupsCmd.Flags().Int("keep-weekly", 0, "Keep the newest archive of each of the last N weeks")
+	pruneBackupsCmd.Flags().Int("keep-monthly", 0, "Keep the newest archive of each of the last N months")
+	pruneBackupsCmd.Flags().Int("keep-yearly", 0, "Keep the newest archive of each of the last N years")
+	pruneBackupsCmd.Flags().Int("min-keep", 1, "Never keep fewer than N archives")
+	pruneBackupsCmd.Flags().Bool("dry-run", false, "Only show what would be deleted")
+	pruneBackupsCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
+	rootCmd.AddCommand(pruneBackupsCmd)
+}
+
+// backupArchive is one archive considered by the retention engine.
+type backupArchive struct {
+	EntryPath string
+	Path      string // last known path of the (first volume of the) archive
+	Time      time.Time
+	UUID8     string   // from the entry title
+	Parent    string   // UUID8 of the previous backup, for increments
+	Reasons   []string // retention rules that keep it; empty means expired
+}
+
+// pruneSet is a group of archives sharing one retention policy.
+type pruneSet struct {
+	Name   string
+	Group  string
+	Policy config.RetentionPolicy
+	Match  func(archivePath string) bool // nil matches every entry in Group
+}
+
+func runPruneBackups(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	group, _ := cmd.Flags().GetString("group")
+	minKeep, _ := cmd.Flags().GetInt("min-keep")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	if group != "" && len(args) > 0 {
+		return fmt.Errorf("use either profiles or --group, not both")
+	}
+	if minKeep < 1 {
+		return fmt.Errorf("--min-keep must be at least 1")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	sets, err := pruneSets(cmd, cfg, group, args)
+	if err != nil {
+		return err
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	failed := 0
+	for _, set := range sets {
+		archives, err := collectBackups(kp, set)
+		if err != nil {
+			return err
+		}
+		keep, prune := applyRetention(archives, set.Policy, minKeep)
+		printRetentionTable(set.Name, keep, prune)
+
+		if dryRun || len(prune) == 0 {
+			continue
+		}
+		if !force && !confirmPrune(len(prune)) {
+			fmt.Println("Aborted.")
+			continue
+		}
+		failed += deleteBackups(kp, prune)
+	}
+
+	if dryRun {
+		fmt.Println("Dry run — nothing was deleted.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d archive(s) could not be deleted", failed)
+	}
+	return nil
+}
+
+// pruneSets builds the sets to prune from --group or the named (default:
+// all) profiles. --keep-* flags override the configured policies.
+func pruneSets(cmd *cobra.Command, cfg *config.Config, group string, profiles []string) ([]pruneSet, error) {
+	override := func(p config.RetentionPolicy) config.RetentionPolicy {
+		for flag, field := range map[string]*int{
+			"keep-last":    &p.KeepLast,
+			"keep-daily":   &p.KeepDaily,
+			"keep-weekly":  &p.KeepWeekly,
+			"keep-monthly": &p.KeepMonthly,
+			"keep-yearly":  &p.KeepYearly,
+		} {
+			if cmd.Flags().Changed(flag) {
+				*field, _ = cmd.Flags().GetInt(flag)
+			}
+		}
+		return p
+	}
+
+	if group != "" {
+		policy := override(config.RetentionPolicy{})
+		if policy.IsZero() {
+			return nil, fmt.Errorf("--group needs at least one --keep-* rule")
+		}
+		return []pruneSet{{Name: "group '" + group + "'", Group: group, Policy: policy}}, nil
+	}
+
+	explicit := len(profiles) > 0
+	if !explicit {
+		profiles = backupProfileNames(cfg)
+	}
+	var sets []pruneSet
+	for _, name := range profiles {
+		name = strings.ToLower(name)
+		p, ok := cfg.Backups[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown backup profile '%s'", name)
+		}
+		policy := override(p.Retention)
+		if policy.IsZero() {
+			if explicit {
+				return nil, fmt.Errorf("profile '%s' has no retention rules", name)
+			}
+			continue
+		}
+		grp := p.Group
+		if grp == "" {
+			grp = cfg.General.DefaultGroup
+		}
+		sets = append(sets, pruneSet{Name: "profile '" + name + "'", Group: grp, Policy: policy, Match: profileMatcher(name, p)})
+	}
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("no backup profile has retention rules")
+	}
+	return sets, nil
+}
+
+// profileMatcher recognises the archives a profile produced: they live in its
+// destination and their name fits its template with {host}, {date} and
+// {time} as wildcards.
+func profileMatcher(name string, p config.BackupProfile) func(string) bool {
+	dest, err := filepath.Abs(expandTilde(p.Destination))
+	if err != nil {
+		dest = p.Destination
+	}
+	glob := expandBackupName(p.Name, name, "*", "*", "*")
+
+	return func(archivePath string) bool {
+		if filepath.Dir(archivePath) != dest {
+			return false
+		}
+		ok, _ := path.Match(glob, AnalyzeArchive(archivePath).NormalizedName)
+		return ok
+	}
+}
+
+// collectBackups lists the entries of set.Group that belong to the set and
+// dates them. Entries that cannot be dated are skipped with a note.
+func collectBackups(kp PasswordProvider, set pruneSet) ([]backupArchive, error) {
+	titles, err := kp.ListEntries(set.Group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entries in '%s': %w", set.Group, err)
+	}
+
+	var archives []backupArchive
+	for _, title := range titles {
+		entryPath := joinEntry(set.Group, title)
+		archivePath, _ := kp.GetAttribute(entryPath, "Username")
+		if archivePath == "" || (set.Match != nil && !set.Match(archivePath)) {
+			continue
+		}
+		notes, _ := kp.GetAttribute(entryPath, "Notes")
+		meta := parseMetadata(notes)
+		created := meta.Created
+		if created.IsZero() {
+			info, err := os.Stat(archivePath)
+			if err != nil {
+				fmt.Printf("Note: skipping '%s' — no creation time recorded and the file is missing.\n", title)
+				continue
+			}
+			created = info.ModTime()
+		}
+		_, uuid8, _ := parseEntryTitle(title)
+		archives = append(archives, backupArchive{EntryPath: entryPath, Path: archivePath, Time: created, UUID8: uuid8, Parent: meta.Parent})
+	}
+	return archives, nil
+}
+
+// applyRetention splits archives into kept and expired ones, both newest
+// first. Each bucket rule keeps the newest archive of each of its last N
+// periods (days, ISO weeks, months, years); minKeep newest archives are kept
+// regardless. The base and earlier increments of a kept increment are kept
+// too ("chain"), since it cannot be restored without them.
+func applyRetention(archives []backupArchive, policy config.RetentionPolicy, minKeep int) (keep, prune []backupArchive) {
+	sorted := append([]backupArchive(nil), archives...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Time.Equal(sorted[j].Time) {
+			return sorted[i].Time.After(sorted[j].Time)
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+	for i := range sorted {
+		sorted[i].Reasons = nil
+	}
+
+	rules := []struct {
+		reason string
+		count  int
+		bucket func(time.Time) string
+	}{
+		{"last", policy.KeepLast, nil},
+		{"daily", policy.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{"weekly", policy.KeepWeekly, func(t time.Time) string {
+			y, w := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", y, w)
+		}},
+		{"monthly", policy.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{"yearly", policy.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+	for _, rule := range rules {
+		seen := make(map[string]bool)
+		for i := range sorted {
+			if len(seen) >= rule.count {
+				break
+			}
+			key := fmt.Sprint(i)
+			if rule.bucket != nil {
+				key = rule.bucket(sorted[i].Time.Local())
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			sorted[i].Reasons = append(sorted[i].Reasons, rule.reason)
+		}
+	}
+	for i := 0; i < len(sorted) && i < minKeep; i++ {
+		if len(sorted[i].Reasons) == 0 {
+			sorted[i].Reasons = []string{"min-keep"}
+		}
+	}
+
+	byUUID := make(map[string]int, len(sorted))
+	for i, a := range sorted {
+		if a.UUID8 != "" {
+			byUUID[a.UUID8] = i
+		}
+	}
+	for i := range sorted {
+		if len(sorted[i].Reasons) == 0 || sorted[i].Parent == "" {
+			continue
+		}
+		for j, ok := byUUID[sorted[i].Parent]; ok; j, ok = byUUID[sorted[j].Parent] {
+			if slices.Contains(sorted[j].Reasons, "chain") {
+				break
+			}
+			sorted[j].Reasons = append(sorted[j].Reasons, "chain")
+		}
+	}
+
+	for _, a := range sorted {
+		if len(a.Reasons) > 0 {
+			keep = append(keep, a)
+		} else {
+			prune = append(prune, a)
+		}
+	}
+	return keep, prune
+}
+
+func printRetentionTable(name string, keep, prune []backupArchive) {
+	all := append(append([]backupArchive(nil), keep...), prune...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.After(all[j].Time) })
+
+	fmt.Printf("\nRetention for %s:\n", name)
+	if len(all) == 0 {
+		fmt.Println("  (no archives)")
+		return
+	}
+	fmt.Printf("  %-6s  %-16s  %-22s  %s\n", "ACTION", "DATE", "REASON", "ARCHIVE")
+	for _, a := range all {
+		action, reason := "keep", strings.Join(a.Reasons, ",")
+		if len(a.Reasons) == 0 {
+			action, reason = "prune", "-"
+		}
+		fmt.Printf("  %-6s  %-16s  %-22s  %s\n", action, a.Time.Local().Format("2006-01-02 15:04"), reason, a.Path)
+	}
+	fmt.Printf("  %d kept, %d to prune\n", len(keep), len(prune))
+}
+
+func confirmPrune(n int) bool {
+	fmt.Printf("Delete %d archive(s) and their KeePassXC entries? [y/N]: ", n)
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return false
+	}
+	answer := strings.TrimSpace(scanner.Text())
+	return answer == "y" || answer == "Y"
+}
+
+// deleteBackups removes every volume of each archive and then its entry. An
+// entry is only deleted once all of its files are gone, so a failure never
+// leaves an archive without its password. Returns the number of failures.
+func deleteBackups(kp EntryMigrator, archives []backupArchive) int {
+	failed := 0
+	for _, a := range archives {
+		if err := removeArchiveVolumes(a.Path); err != nil {
+			fmt.Printf("✗ %s: %v\n", a.Path, err)
+			failed++
+			continue
+		}
+		if err := kp.DeleteEntry(a.EntryPath); err != nil {
+			fmt.Printf("✗ %s: files deleted, but the entry could not be: %v\n", a.Path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✓ Pruned %s\n", a.Path)
+	}
+	return failed
+}
+
+// removeArchiveVolumes deletes an archive and all of its split volumes.
+// archivePath may name the archive without its ".001" suffix. Volumes that
+// are already gone are not an error.
+func removeArchiveVolumes(archivePath string) error {
+	for _, v := range archiveVolumes(resolveFirstVolume(archivePath)) {
+		if err := os.Remove(v); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+)
+
+// dailyBackups returns n archives, one per day at noon, newest first.
+func dailyBackups(n int) []backupArchive {
+	start := time.Date(2024, 3, 31, 12, 0, 0, 0, time.Local)
+	archives := make([]backupArchive, n)
+	for i := range archives {
+		t := start.AddDate(0, 0, -i)
+		archives[i] = backupArchive{Path: "/b/home-" + t.Format("2006-01-02") + ".7z", Time: t}
+	}
+	return archives
+}
+
+func paths(archives []backupArchive) []string {
+	out := make([]string, len(archives))
+	for i, a := range archives {
+		out[i] = filepath.Base(a.Path)
+	}
+	return out
+}
+
+func TestApplyRetention_KeepLast(t *testing.T) {
+	keep, prune := applyRetention(dailyBackups(5), config.RetentionPolicy{KeepLast: 2}, 1)
+	if !reflect.DeepEqual(paths(keep), []string{"home-2024-03-31.7z", "home-2024-03-30.7z"}) {
+		t.Errorf("keep = %v", paths(keep))
+	}
+	if len(prune) != 3 {
+		t.Errorf("prune = %v", paths(prune))
+	}
+}
+
+func TestApplyRetention_Buckets(t *testing.T) {
+	archives := dailyBackups(70) // 2024-03-31 back to 2024-01-22
+	// Two archives on the newest day: only the newer one counts for "daily".
+	extra := backupArchive{Path: "/b/home-early.7z", Time: time.Date(2024, 3, 31, 6, 0, 0, 0, time.Local)}
+	archives = append(archives, extra)
+
+	keep, prune := applyRetention(archives, config.RetentionPolicy{KeepDaily: 3, KeepMonthly: 3}, 1)
+
+	want := []string{
+		"home-2024-03-31.7z", // daily, monthly (March)
+		"home-2024-03-30.7z", // daily
+		"home-2024-03-29.7z", // daily
+		"home-2024-02-29.7z", // monthly (February)
+		"home-2024-01-31.7z", // monthly (January)
+	}
+	if !reflect.DeepEqual(paths(keep), want) {
+		t.Errorf("keep = %v, want %v", paths(keep), want)
+	}
+	if !reflect.DeepEqual(keep[0].Reasons, []string{"daily", "monthly"}) {
+		t.Errorf("reasons = %v", keep[0].Reasons)
+	}
+	if len(keep)+len(prune) != len(archives) {
+		t.Errorf("lost archives: %d + %d != %d", len(keep), len(prune), len(archives))
+	}
+}
+
+func TestApplyRetention_MinKeepFloor(t *testing.T) {
+	// A yearly rule of 1 would keep only the newest; the floor keeps three.
+	keep, prune := applyRetention(dailyBackups(5), config.RetentionPolicy{KeepYearly: 1}, 3)
+	if len(keep) != 3 || len(prune) != 2 {
+		t.Fatalf("keep=%v prune=%v", paths(keep), paths(prune))
+	}
+	if !reflect.DeepEqual(keep[1].Reasons, []string{"min-keep"}) {
+		t.Errorf("reasons = %v", keep[1].Reasons)
+	}
+}
+
+func TestProfileMatcher(t *testing.T) {
+	dest := t.TempDir()
+	match := profileMatcher("home", config.BackupProfile{Destination: dest, Name: "{profile}-{host}-{date}"})
+
+	tests := map[string]bool{
+		filepath.Join(dest, "home-box-2024-03-31.7z"):     true,
+		filepath.Join(dest, "home-box-2024-03-31.7z.001"): true,
+		filepath.Join(dest, "work-box-2024-03-31.7z"):     false,
+		filepath.Join(dest, "sub", "home-box-x.7z"):       false,
+		"/elsewhere/home-box-2024-03-31.7z":               false,
+	}
+	for p, want := range tests {
+		if got := match(p); got != want {
+			t.Errorf("match(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestCollectBackups(t *testing.T) {
+	dir := t.TempDir()
+	onDisk := filepath.Join(dir, "home-old.7z")
+	if err := os.WriteFile(onDisk, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(onDisk, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	mock := NewMockPasswordProvider()
+	recorded := addUUIDEntry(mock, "Backups", "home-new.7z", "11111111", filepath.Join(dir, "home-new.7z"), []byte("pw"))
+	mock.SetAttribute(recorded, "Notes", buildMetadataSection(EntryMetadata{Created: created}))
+	addUUIDEntry(mock, "Backups", "home-old.7z", "22222222", onDisk, []byte("pw"))
+	addUUIDEntry(mock, "Backups", "home-gone.7z", "33333333", filepath.Join(dir, "home-gone.7z"), []byte("pw"))
+	addUUIDEntry(mock, "Backups", "other.7z", "44444444", "/elsewhere/other.7z", []byte("pw"))
+
+	set := pruneSet{Group: "Backups", Match: func(p string) bool { return filepath.Dir(p) == dir }}
+	archives, err := collectBackups(mock, set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]time.Time{}
+	for _, a := range archives {
+		got[filepath.Base(a.Path)] = a.Time
+	}
+	if len(got) != 2 || !got["home-new.7z"].Equal(created) || !got["home-old.7z"].Equal(mtime) {
+		t.Errorf("archives = %+v", archives)
+	}
+}
+
+func TestDeleteBackups(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "home.7z.001")
+	for _, name := range []string{"home.7z.001", "home.7z.002", "keep.7z"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mock := NewMockPasswordProvider()
+	entry := addUUIDEntry(mock, "Backups", "home.7z.001", "11111111", first, []byte("pw"))
+
+	if failed := deleteBackups(mock, []backupArchive{{EntryPath: entry, Path: first}}); failed != 0 {
+		t.Fatalf("failed = %d", failed)
+	}
+	left, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if !reflect.DeepEqual(left, []string{filepath.Join(dir, "keep.7z")}) {
+		t.Errorf("files left: %v", left)
+	}
+	if _, err := mock.GetPassword(entry); err == nil {
+		t.Error("entry should have been deleted")
+	}
+}
+
+func TestApplyRetention_KeepsChain(t *testing.T) {
+	archives := dailyBackups(4)
+	// 03-28 is a full backup; the newer three are increments on top of it.
+	uuids := []string{"dddddddd", "cccccccc", "bbbbbbbb", "aaaaaaaa"}
+	for i := range archives {
+		archives[i].UUID8 = uuids[i]
+		if i < len(archives)-1 {
+			archives[i].Parent = uuids[i+1]
+		}
+	}
+
+	keep, prune := applyRetention(archives, config.RetentionPolicy{KeepLast: 1}, 1)
+	if len(keep) != 4 || len(prune) != 0 {
+		t.Fatalf("keep = %v, prune = %v", paths(keep), paths(prune))
+	}
+	if !reflect.DeepEqual(keep[0].Reasons, []string{"last"}) || !reflect.DeepEqual(keep[3].Reasons, []string{"chain"}) {
+		t.Errorf("reasons = %v, %v", keep[0].Reasons, keep[3].Reasons)
+	}
+}
+
+func TestRemoveArchiveVolumes_SplitByBaseName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"data.7z.001", "data.7z.002", "other.7z.001"} {
+		_ = os.WriteFile(filepath.Join(dir, name), nil, 0o600)
+	}
+
+	if err := removeArchiveVolumes(filepath.Join(dir, "data.7z")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"data.7z.001", "data.7z.002"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.7z.001")); err != nil {
+		t.Errorf("other.7z.001 should be kept: %v", err)
+	}
+}
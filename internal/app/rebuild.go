@@ -0,0 +1,214 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+// -------------------------------------------------------------------
+// Archive rebuild helpers
+//
+// Commands that must re-create an archive (rekey, repack, ...) share one
+// flow: decrypt into a private temp directory, re-pack into a staging
+// directory next to the original (same filesystem, so the final swap is a
+// rename), test the staged copy, then swap it over the original.
+// -------------------------------------------------------------------
+
+// resolveFirstVolume returns the path of the file 7z should be pointed at:
+// absPath itself, or absPath+".001" when only split volumes exist.
+func resolveFirstVolume(absPath string) string {
+	if _, err := os.Stat(absPath); err != nil {
+		if _, errSplit := os.Stat(absPath + ".001"); errSplit == nil {
+			return absPath + ".001"
+		}
+	}
+	return absPath
+}
+
+// archiveVolumes returns every on-disk volume of the archive starting at
+// absFirst, in order. Non-split archives return a single element.
+func archiveVolumes(absFirst string) []string {
+	info := AnalyzeArchive(absFirst)
+	if info.Type != ArchiveSplitStandard {
+		return []string{absFirst}
+	}
+	pattern := filepath.Join(filepath.Dir(absFirst), info.NormalizedName+".[0-9]*")
+	matches, err := filepath.Glob(pattern)
+	if err != nil || len(matches) == 0 {
+		return []string{absFirst}
+	}
+	return matches
+}
+
+// splitVolumeArgs returns a "-v<size>b" switch reproducing the volume size
+// of an existing split archive, or nil when the archive is a single file.
+func splitVolumeArgs(absFirst string) []string {
+	if AnalyzeArchive(absFirst).Type != ArchiveSplitStandard {
+		return nil
+	}
+	info, err := os.Stat(absFirst)
+	if err != nil || info.Size() == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("-v%db", info.Size())}
+}
+
+// ensureRebuildable rejects archives that cannot be re-created as 7z.
+func ensureRebuildable(absFirst string) error {
+	name := AnalyzeArchive(absFirst).NormalizedName
+	if !strings.EqualFold(filepath.Ext(name), ".7z") {
+		return fmt.Errorf("'%s' is not a 7z archive — only .7z archives can be rebuilt", filepath.Base(absFirst))
+	}
+	return nil
+}
+
+// rebuildArchive decrypts absFirst with oldPassword and re-packs its contents
+// with newPassword and packArgs (switches placed between "a" and "-p").
+// The result is written to a staging directory next to the original and is
+// tested before returning. The caller must remove stagingDir.
+func rebuildArchive(binaryPath, absFirst string, oldPassword, newPassword []byte, packArgs []string) (stagedFirst, stagingDir string, err error) {
+	plainDir, err := newPrivateTempDir("7zkpxc-rebuild-")
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = wipeDir(plainDir) }()
+
+	fmt.Println("Decrypting into private temp directory...")
+	if err := sevenzip.RunQuiet(binaryPath, oldPassword, []string{"x", "-y", "-o" + plainDir, absFirst}); err != nil {
+		return "", "", fmt.Errorf("failed to decrypt archive: %w", err)
+	}
+
+	stagingDir, err = os.MkdirTemp(filepath.Dir(absFirst), ".7zkpxc-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	staged := filepath.Join(stagingDir, AnalyzeArchive(absFirst).NormalizedName)
+	args := []string{"a"}
+	args = append(args, packArgs...)
+	args = append(args, "-p", staged, filepath.Join(plainDir, "*"))
+
+	fmt.Println("Re-packing archive...")
+	if err := sevenzip.RunQuiet(binaryPath, newPassword, args); err != nil {
+		_ = os.RemoveAll(stagingDir)
+		return "", "", fmt.Errorf("failed to re-pack archive: %w", err)
+	}
+	stagedFirst = resolveFirstVolume(staged)
+
+	fmt.Println("Verifying new archive...")
+	if err := sevenzip.VerifyIntegrity(binaryPath, newPassword, stagedFirst); err != nil {
+		_ = os.RemoveAll(stagingDir)
+		return "", "", fmt.Errorf("verification of re-packed archive failed: %w", err)
+	}
+
+	return stagedFirst, stagingDir, nil
+}
+
+// errSwapIncomplete marks a failed replaceArchive that could not put the
+// original volumes back: new volumes may be in place, and the originals are
+// still in the staging directory.
+var errSwapIncomplete = errors.New("archive swap incomplete")
+
+// replaceArchive moves the staged volumes over the original archive and
+// removes original volumes that have no counterpart (e.g. when a split
+// archive is joined). Returns the path of the new first volume.
+//
+// Each original volume is first linked (or, where links are unsupported,
+// moved) into the staging directory, so a failure part-way through a split
+// archive can restore the original set. A single-file archive is still
+// swapped atomically by rename(2). When restoring fails too, the returned
+// error wraps errSwapIncomplete and the caller must keep the staging
+// directory.
+func replaceArchive(stagedFirst, absFirst string) (string, error) {
+	dir := filepath.Dir(absFirst)
+	oldVolumes := archiveVolumes(absFirst)
+
+	// Keep the original permissions (archives are often 0600)
+	var mode os.FileMode
+	if info, err := os.Stat(absFirst); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	asideDir := filepath.Join(filepath.Dir(stagedFirst), "original")
+	if err := os.Mkdir(asideDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to prepare swap: %w", err)
+	}
+	aside := make(map[string]string) // original volume → its copy in asideDir
+	var placed []string              // new volumes moved into dir
+
+	rollback := func(cause error) error {
+		var failed bool
+		for _, dst := range placed {
+			if _, ok := aside[dst]; !ok {
+				if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+					failed = true
+				}
+			}
+		}
+		for orig, saved := range aside {
+			if err := os.Rename(saved, orig); err != nil {
+				failed = true
+			}
+		}
+		if failed {
+			return fmt.Errorf("%w: %v — the original volumes are kept in '%s'", errSwapIncomplete, cause, asideDir)
+		}
+		return fmt.Errorf("%w (original archive restored)", cause)
+	}
+
+	for _, v := range oldVolumes {
+		saved := filepath.Join(asideDir, filepath.Base(v))
+		if err := os.Link(v, saved); err != nil {
+			if err := os.Rename(v, saved); err != nil {
+				return "", rollback(fmt.Errorf("failed to set '%s' aside: %w", filepath.Base(v), err))
+			}
+		}
+		aside[v] = saved
+	}
+
+	for _, v := range archiveVolumes(stagedFirst) {
+		dst := filepath.Join(dir, filepath.Base(v))
+		if mode != 0 {
+			_ = os.Chmod(v, mode)
+		}
+		if err := os.Rename(v, dst); err != nil {
+			return "", rollback(fmt.Errorf("failed to move '%s' into place: %w", filepath.Base(v), err))
+		}
+		placed = append(placed, dst)
+	}
+
+	for _, v := range oldVolumes {
+		if !slices.Contains(placed, v) {
+			if err := os.Remove(v); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("Warning: failed to delete old volume '%s': %v\n", v, err)
+			}
+		}
+	}
+
+	return filepath.Join(dir, filepath.Base(stagedFirst)), nil
+}
+
+// retitleEntry updates an entry's title and Username after the archive's
+// first-volume name changed (e.g. data.7z → data.7z.001 when splitting).
+// The UUID8 is preserved. Returns the (possibly new) entry path.
+func retitleEntry(kp PasswordProvider, entryPath, newAbsPath string) (string, error) {
+	group, title := path.Split(entryPath)
+	basename, uuid8, ok := parseEntryTitle(title)
+	newBasename := filepath.Base(newAbsPath)
+	if !ok || basename == newBasename {
+		return entryPath, nil
+	}
+
+	newTitle := makeEntryTitle(newBasename, uuid8)
+	if err := kp.EditEntryTitle(entryPath, newTitle, newAbsPath); err != nil {
+		return entryPath, err
+	}
+	return group + newTitle, nil
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveFirstVolume(t *testing.T) {
+	dir := t.TempDir()
+	single := filepath.Join(dir, "single.7z")
+	split := filepath.Join(dir, "split.7z")
+	_ = os.WriteFile(single, []byte("x"), 0o644)
+	_ = os.WriteFile(split+".001", []byte("x"), 0o644)
+
+	if got := resolveFirstVolume(single); got != single {
+		t.Errorf("resolveFirstVolume(single) = %q, want %q", got, single)
+	}
+	if got := resolveFirstVolume(split); got != split+".001" {
+		t.Errorf("resolveFirstVolume(split) = %q, want %q", got, split+".001")
+	}
+	missing := filepath.Join(dir, "missing.7z")
+	if got := resolveFirstVolume(missing); got != missing {
+		t.Errorf("resolveFirstVolume(missing) = %q, want %q", got, missing)
+	}
+}
+
+func TestArchiveVolumes_Split(t *testing.T) {
+	dir := t.TempDir()
+	for _, ext := range []string{".001", ".002", ".003"} {
+		_ = os.WriteFile(filepath.Join(dir, "data.7z"+ext), []byte("x"), 0o644)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "other.7z.001"), []byte("x"), 0o644)
+
+	vols := archiveVolumes(filepath.Join(dir, "data.7z.001"))
+	if len(vols) != 3 {
+		t.Fatalf("archiveVolumes returned %d volumes, want 3: %v", len(vols), vols)
+	}
+	if filepath.Base(vols[0]) != "data.7z.001" || filepath.Base(vols[2]) != "data.7z.003" {
+		t.Errorf("unexpected volume order: %v", vols)
+	}
+}
+
+func TestArchiveVolumes_Single(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.7z")
+	vols := archiveVolumes(path)
+	if len(vols) != 1 || vols[0] != path {
+		t.Errorf("archiveVolumes(single) = %v, want [%s]", vols, path)
+	}
+}
+
+func TestSplitVolumeArgs(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "data.7z.001")
+	_ = os.WriteFile(first, make([]byte, 1024), 0o644)
+
+	args := splitVolumeArgs(first)
+	if len(args) != 1 || args[0] != "-v1024b" {
+		t.Errorf("splitVolumeArgs = %v, want [-v1024b]", args)
+	}
+	if args := splitVolumeArgs(filepath.Join(dir, "plain.7z")); args != nil {
+		t.Errorf("splitVolumeArgs(non-split) = %v, want nil", args)
+	}
+}
+
+func TestEnsureRebuildable(t *testing.T) {
+	if err := ensureRebuildable("/a/b.7z"); err != nil {
+		t.Errorf("b.7z should be rebuildable: %v", err)
+	}
+	if err := ensureRebuildable("/a/b.7z.001"); err != nil {
+		t.Errorf("b.7z.001 should be rebuildable: %v", err)
+	}
+	err := ensureRebuildable("/a/b.zip")
+	if err == nil || !strings.Contains(err.Error(), "not a 7z archive") {
+		t.Errorf("b.zip should be rejected, got %v", err)
+	}
+}
+
+func TestReplaceArchive_SingleFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "data.7z")
+	_ = os.WriteFile(target, []byte("old"), 0o600)
+
+	staging := filepath.Join(dir, ".staging")
+	_ = os.Mkdir(staging, 0o700)
+	staged := filepath.Join(staging, "data.7z")
+	_ = os.WriteFile(staged, []byte("new"), 0o644)
+
+	got, err := replaceArchive(staged, target)
+	if err != nil {
+		t.Fatalf("replaceArchive: %v", err)
+	}
+	if got != target {
+		t.Errorf("replaceArchive returned %q, want %q", got, target)
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "new" {
+		t.Errorf("target content = %q, want %q", data, "new")
+	}
+	info, _ := os.Stat(target)
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("permissions = %o, want 600 (preserved from original)", perm)
+	}
+}
+
+func TestReplaceArchive_JoinRemovesOldVolumes(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "data.7z.001")
+	_ = os.WriteFile(first, []byte("v1"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "data.7z.002"), []byte("v2"), 0o644)
+
+	staging := filepath.Join(dir, ".staging")
+	_ = os.Mkdir(staging, 0o700)
+	staged := filepath.Join(staging, "data.7z")
+	_ = os.WriteFile(staged, []byte("joined"), 0o644)
+
+	got, err := replaceArchive(staged, first)
+	if err != nil {
+		t.Fatalf("replaceArchive: %v", err)
+	}
+	if got != filepath.Join(dir, "data.7z") {
+		t.Errorf("replaceArchive returned %q", got)
+	}
+	for _, old := range []string{first, filepath.Join(dir, "data.7z.002")} {
+		if _, err := os.Stat(old); !os.IsNotExist(err) {
+			t.Errorf("old volume %s should be removed", filepath.Base(old))
+		}
+	}
+}
+
+func TestReplaceArchive_FailureRestoresOriginal(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "data.7z")
+	_ = os.WriteFile(target, []byte("old"), 0o600)
+
+	staging := filepath.Join(dir, ".staging")
+	_ = os.Mkdir(staging, 0o700)
+	_ = os.WriteFile(filepath.Join(staging, "data.7z.001"), []byte("new1"), 0o644)
+	_ = os.WriteFile(filepath.Join(staging, "data.7z.002"), []byte("new2"), 0o644)
+
+	// A non-empty directory in the way makes the second rename fail
+	blocker := filepath.Join(dir, "data.7z.002")
+	_ = os.Mkdir(blocker, 0o700)
+	_ = os.WriteFile(filepath.Join(blocker, "keep"), nil, 0o600)
+
+	_, err := replaceArchive(filepath.Join(staging, "data.7z.001"), target)
+	if err == nil {
+		t.Fatal("replaceArchive should fail")
+	}
+	if errors.Is(err, errSwapIncomplete) {
+		t.Fatalf("original should have been restored, got %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old" {
+		t.Errorf("target content = %q, want %q", data, "old")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.7z.001")); !os.IsNotExist(err) {
+		t.Error("new first volume should have been removed")
+	}
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey <archive_or_directory>",
+	Short: "Rotate an archive's password",
+	Long: `Re-encrypts an archive with a freshly generated password and stores the
+new password in the same KeePassXC entry. The previous password is kept in
+the entry history by KeePassXC.
+
+The archive is decrypted into a private temp directory, re-packed next to
+the original, verified with the new password and then swapped into place.
+If anything fails before the swap, the original archive is left untouched.
+
+Re-packing uses sevenzip.default_args, as 'repack' without flags does, so
+compression, solid mode and header encryption are reset to the current
+defaults. The volume layout of a split archive is kept. Use 'repack'
+afterwards to choose other settings.
+
+Single archive:
+  7zkpxc rekey archive.7z
+
+All archives in a directory:
+  7zkpxc rekey ~/archives/`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runRekey,
+	GroupID: "actions",
+}
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+}
+
+func runRekey(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	target := args[0]
+
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		absTarget = target
+	}
+
+	if info, err := os.Stat(absTarget); err == nil && info.IsDir() {
+		return rekeyDirectory(absTarget)
+	}
+
+	return withKeePassArchive(target, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		return rekeyArchive(cfg, kp, resolveFirstVolume(absTarget), password, entryPath)
+	})
+}
+
+// verifyArchivePassword checks password against the archive, falling back
+// to a full test when the headers are not encrypted (ZIP, or 7z without
+// -mhe=on): only reading the file data then shows whether it is encrypted.
+func verifyArchivePassword(binaryPath string, password []byte, absFirst string) (sevenzip.PasswordMatch, error) {
+	match, err := sevenzip.VerifyPassword(binaryPath, password, absFirst)
+	if match == sevenzip.MatchUnencrypted {
+		match, err = sevenzip.VerifyPasswordFull(binaryPath, password, absFirst)
+	}
+	return match, err
+}
+
+// rekeyArchive re-encrypts a single archive with a new password.
+//
+// Order of operations keeps the archive recoverable at every step:
+//  1. Rebuild into a staging copy with the new password and verify it.
+//  2. Store the new password in KeePassXC (old one moves to entry history).
+//  3. Swap the staging copy over the original; when the swap fails and the
+//     original volumes are back in place, restore the old password in
+//     KeePassXC so entry and archive stay in sync.
+func rekeyArchive(cfg *config.Config, kp *keepass.Client, absFirst string, oldPassword []byte, entryPath string) error {
+	if err := ensureRebuildable(absFirst); err != nil {
+		return err
+	}
+
+	match, verifyErr := verifyArchivePassword(cfg.SevenZip.BinaryPath, oldPassword, absFirst)
+	switch match {
+	case sevenzip.MatchUnencrypted:
+		return fmt.Errorf("archive '%s' is not encrypted — nothing to rekey", filepath.Base(absFirst))
+	case sevenzip.MatchFailed:
+		return fmt.Errorf("current password does not open '%s': %w", filepath.Base(absFirst), verifyErr)
+	}
+
+	fmt.Printf("Generating %d-character secure password...\n", cfg.General.PasswordLength)
+	newPassword, err := kp.GeneratePassword(cfg.General.PasswordLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate password: %w", err)
+	}
+	defer func() {
+		for i := range newPassword {
+			newPassword[i] = 0
+		}
+	}()
+
+	packArgs := append([]string{}, cfg.SevenZip.DefaultArgs...)
+	packArgs = append(packArgs, splitVolumeArgs(absFirst)...)
+
+	fmt.Printf("Re-packing '%s' with: %s\n", filepath.Base(absFirst), strings.Join(packArgs, " "))
+	stagedFirst, stagingDir, err := rebuildArchive(cfg.SevenZip.BinaryPath, absFirst, oldPassword, newPassword, packArgs)
+	if err != nil {
+		return err
+	}
+	keepStaging := false
+	defer func() {
+		if !keepStaging {
+			_ = os.RemoveAll(stagingDir)
+		}
+	}()
+
+	fmt.Printf("Updating password in KeePassXC entry '%s'...\n", entryPath)
+	if err := kp.UpdateEntryPassword(entryPath, newPassword); err != nil {
+		return fmt.Errorf("failed to store new password (archive left unchanged): %w", err)
+	}
+
+	newFirst, err := replaceArchive(stagedFirst, absFirst)
+	if errors.Is(err, errSwapIncomplete) {
+		// Some volumes already need the new password: keep it in the entry
+		// (the old one is in its history) and keep the original volumes.
+		keepStaging = true
+		return err
+	}
+	if err != nil {
+		fmt.Println("Swapping archive failed, restoring previous password in KeePassXC...")
+		if rbErr := kp.UpdateEntryPassword(entryPath, oldPassword); rbErr != nil {
+			fmt.Printf("Warning: rollback failed — the old password is still in the entry history of '%s': %v\n", entryPath, rbErr)
+		} else {
+			fmt.Println("KeePassXC entry rolled back successfully.")
+		}
+		return err
+	}
+
+	updatePathIfMoved(kp, entryPath, newFirst)
+	updateMetadata(kp, entryPath, newFirst)
+
+	if err := refreshGrant(newFirst, newPassword); err != nil {
+		fmt.Printf("Warning: failed to re-wrap grant sidecar — run '7zkpxc grant' again: %v\n", err)
+	}
+
+	fmt.Printf("Success! '%s' re-encrypted with a new password.\n", filepath.Base(newFirst))
+	return nil
+}
+
+// rekeyDirectory rekeys every managed archive in a directory with a single
+// KeePassXC unlock. Archives without an entry are skipped and reported.
+func rekeyDirectory(absDir string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	archives, err := findArchivesInDir(absDir)
+	if err != nil {
+		return err
+	}
+	if len(archives) == 0 {
+		fmt.Printf("No .7z archives found in '%s'.\n", absDir)
+		return nil
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	var rekeyed, skipped, failed []string
+	for i, archivePath := range archives {
+		basename := filepath.Base(archivePath)
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(archives), basename)
+
+		password, entryPath, _, err := resolvePassword(kp, cfg.General.DefaultGroup, archivePath, "")
+		if err != nil {
+			if IsPasswordNotFound(err) {
+				fmt.Println("  ✗ No KeePassXC entry — skipped (try '7zkpxc relink')")
+				skipped = append(skipped, basename)
+				continue
+			}
+			fmt.Printf("  ⚡ %v\n", err)
+			failed = append(failed, basename)
+			continue
+		}
+
+		err = rekeyArchive(cfg, kp, archivePath, password, entryPath)
+		for j := range password {
+			password[j] = 0
+		}
+		if err != nil {
+			fmt.Printf("  ⚡ %v\n", err)
+			failed = append(failed, basename)
+			continue
+		}
+		rekeyed = append(rekeyed, basename)
+	}
+
+	fmt.Println("─────────────────────────────────")
+	fmt.Println("Rekey Summary:")
+	fmt.Printf("  ✓ Rekeyed:  %d\n", len(rekeyed))
+	if len(skipped) > 0 {
+		fmt.Printf("  ✗ Skipped:  %d  (%s)\n", len(skipped), joinWords(skipped))
+	}
+	if len(failed) > 0 {
+		fmt.Printf("  ⚡ Failed:   %d  (%s)\n", len(failed), joinWords(failed))
+	}
+	fmt.Println("─────────────────────────────────")
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d archive(s) could not be rekeyed", len(failed))
+	}
+	return nil
+}
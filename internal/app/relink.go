@@ -68,7 +68,7 @@ func runRelink(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("cannot access '%s': %w", target, err)
 	}
 
-	kp := keepass.New(cfg.General.KdbxPath, testClientOptions...)
+	kp := newKeePassClient(cfg)
 	defer kp.Close()
 
 	if info.IsDir() {
@@ -36,6 +36,9 @@ func runRenameFile(cmd *cobra.Command, args []string) error {
 	renamePairs := args[1:]
 
 	return withKeePassArchive(archivePath, false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		if err := refuseTarPayload(kp, entryPath, archivePath); err != nil {
+			return err
+		}
 		fmt.Printf("Renaming %d file(s) inside '%s'...\n", len(renamePairs)/2, archivePath)
 
 		sevenZipArgs := []string{"rn", archivePath}
@@ -46,6 +49,7 @@ func runRenameFile(cmd *cobra.Command, args []string) error {
 		}
 
 		fmt.Println("Success! File(s) renamed inside archive.")
+		refreshManifest(cfg, kp, password, entryPath, archivePath)
 		return nil
 	})
 }
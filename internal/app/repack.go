@@ -0,0 +1,216 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+var repackCmd = &cobra.Command{
+	Use:   "repack <archive_path>",
+	Short: "Re-create an archive with new compression or encryption settings",
+	Long: `Re-creates a managed archive with new settings while keeping the same
+password and KeePassXC entry.
+
+Settings that are not given are taken from sevenzip.default_args, so running
+repack without flags applies the current defaults (e.g. -mhe=on) to an older
+archive. The existing volume layout is kept unless --volume or --join is used.
+
+Examples:
+  7zkpxc repack old.7z                         # apply current default_args
+  7zkpxc repack --level 9 --solid on old.7z
+  7zkpxc repack --volume 1g big.7z             # split into 1 GB volumes
+  7zkpxc repack --join big.7z.001              # join volumes into one file`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runRepack,
+	GroupID: "actions",
+}
+
+func init() {
+	repackCmd.Flags().String("method", "", "Compression method: lzma2, lzma, ppmd, bzip2, deflate or copy")
+	repackCmd.Flags().Int("level", -1, "Compression level 0-9")
+	repackCmd.Flags().String("solid", "", "Solid mode: on, off or a block size (e.g. 4g)")
+	repackCmd.Flags().String("header-encryption", "", "Encrypt file names: on or off")
+	repackCmd.Flags().String("volume", "", "Split into volumes of this size, e.g. 100m, 1g")
+	repackCmd.Flags().Bool("join", false, "Join a split archive into a single file")
+	repackCmd.MarkFlagsMutuallyExclusive("volume", "join")
+	rootCmd.AddCommand(repackCmd)
+}
+
+// repackOptions holds the settings requested on the command line.
+// Zero values mean "not specified".
+type repackOptions struct {
+	Method           string
+	Level            int // -1 = not specified
+	Solid            string
+	HeaderEncryption string
+	Volume           string
+	Join             bool
+}
+
+var (
+	repackMethods   = map[string]bool{"lzma2": true, "lzma": true, "ppmd": true, "bzip2": true, "deflate": true, "copy": true}
+	solidBlockRe    = regexp.MustCompile(`^[0-9]+[bkmgtf]?$`)
+	volumeSizeRe    = regexp.MustCompile(`^[0-9]+[bkmg]?$`)
+	switchKeyPrefix = []string{"-mx", "-mhe", "-ms", "-m0", "-v"}
+)
+
+func runRepack(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+
+	var opts repackOptions
+	opts.Method, _ = cmd.Flags().GetString("method")
+	opts.Level, _ = cmd.Flags().GetInt("level")
+	opts.Solid, _ = cmd.Flags().GetString("solid")
+	opts.HeaderEncryption, _ = cmd.Flags().GetString("header-encryption")
+	opts.Volume, _ = cmd.Flags().GetString("volume")
+	opts.Join, _ = cmd.Flags().GetBool("join")
+
+	overrides, err := repackSwitches(opts)
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		absFirst := resolveFirstVolume(absPath)
+		if err := ensureRebuildable(absFirst); err != nil {
+			return err
+		}
+
+		match, verifyErr := verifyArchivePassword(cfg.SevenZip.BinaryPath, password, absFirst)
+		switch match {
+		case sevenzip.MatchUnencrypted:
+			return fmt.Errorf("archive '%s' is not encrypted — use '7zkpxc convert' instead", filepath.Base(absFirst))
+		case sevenzip.MatchFailed:
+			return fmt.Errorf("password does not open '%s': %w", filepath.Base(absFirst), verifyErr)
+		}
+
+		// Keep the current volume layout unless told otherwise
+		if opts.Volume == "" && !opts.Join {
+			overrides = append(overrides, splitVolumeArgs(absFirst)...)
+		}
+		packArgs := mergeSwitches(cfg.SevenZip.DefaultArgs, overrides)
+
+		fmt.Printf("Repacking '%s' with: %s\n", filepath.Base(absFirst), strings.Join(packArgs, " "))
+		stagedFirst, stagingDir, err := rebuildArchive(cfg.SevenZip.BinaryPath, absFirst, password, password, packArgs)
+		if err != nil {
+			return err
+		}
+		keepStaging := false
+		defer func() {
+			if !keepStaging {
+				_ = os.RemoveAll(stagingDir)
+			}
+		}()
+
+		newFirst, err := replaceArchive(stagedFirst, absFirst)
+		if err != nil {
+			keepStaging = errors.Is(err, errSwapIncomplete)
+			return err
+		}
+
+		if newFirst != absFirst {
+			fmt.Printf("Archive is now '%s' — updating entry...\n", filepath.Base(newFirst))
+			if entryPath, err = retitleEntry(kp, entryPath, newFirst); err != nil {
+				fmt.Printf("Note: could not update entry title: %v\n", err)
+			}
+		}
+		updatePathIfMoved(kp, entryPath, newFirst)
+		updateMetadata(kp, entryPath, newFirst)
+
+		fmt.Printf("Success! '%s' repacked.\n", filepath.Base(newFirst))
+		return nil
+	})
+}
+
+// repackSwitches validates opts and converts them to 7z switches.
+func repackSwitches(opts repackOptions) ([]string, error) {
+	var args []string
+
+	if opts.Method != "" {
+		method := strings.ToLower(opts.Method)
+		if !repackMethods[method] {
+			return nil, fmt.Errorf("unsupported compression method %q", opts.Method)
+		}
+		args = append(args, "-m0="+method)
+	}
+
+	if opts.Level != -1 {
+		if opts.Level < 0 || opts.Level > 9 {
+			return nil, fmt.Errorf("invalid compression level %d (must be between 0 and 9)", opts.Level)
+		}
+		args = append(args, fmt.Sprintf("-mx=%d", opts.Level))
+	}
+
+	if opts.Solid != "" {
+		solid := strings.ToLower(opts.Solid)
+		if solid != "on" && solid != "off" && !solidBlockRe.MatchString(solid) {
+			return nil, fmt.Errorf("invalid solid mode %q (use on, off or a block size such as 4g)", opts.Solid)
+		}
+		args = append(args, "-ms="+solid)
+	}
+
+	if opts.HeaderEncryption != "" {
+		he := strings.ToLower(opts.HeaderEncryption)
+		if he != "on" && he != "off" {
+			return nil, fmt.Errorf("invalid header encryption %q (use on or off)", opts.HeaderEncryption)
+		}
+		args = append(args, "-mhe="+he)
+	}
+
+	if opts.Volume != "" {
+		volume := strings.ToLower(opts.Volume)
+		if !volumeSizeRe.MatchString(volume) {
+			return nil, fmt.Errorf("invalid volume size %q (e.g. 100m, 1g)", opts.Volume)
+		}
+		args = append(args, "-v"+volume)
+	}
+
+	return args, nil
+}
+
+// switchKey returns the part of a 7z switch that identifies the setting,
+// e.g. "-mx=9" → "-mx", "-v100m" → "-v". Unknown switches return themselves.
+func switchKey(arg string) string {
+	for _, prefix := range switchKeyPrefix {
+		if strings.HasPrefix(arg, prefix) {
+			rest := strings.TrimPrefix(arg, prefix)
+			if prefix == "-v" || rest == "" || strings.HasPrefix(rest, "=") || (prefix == "-mx" && len(rest) == 1) {
+				return prefix
+			}
+		}
+	}
+	return arg
+}
+
+// mergeSwitches returns defaults with every switch that is overridden
+// removed, followed by the overrides. This avoids passing 7z two
+// conflicting values for the same setting.
+func mergeSwitches(defaults, overrides []string) []string {
+	overridden := make(map[string]bool, len(overrides))
+	for _, o := range overrides {
+		overridden[switchKey(o)] = true
+	}
+
+	merged := make([]string, 0, len(defaults)+len(overrides))
+	for _, d := range defaults {
+		if !overridden[switchKey(d)] {
+			merged = append(merged, d)
+		}
+	}
+	return append(merged, overrides...)
+}
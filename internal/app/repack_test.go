@@ -0,0 +1,148 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+func TestRepackSwitches_AllSettings(t *testing.T) {
+	opts := repackOptions{
+		Method:           "LZMA2",
+		Level:            9,
+		Solid:            "4g",
+		HeaderEncryption: "on",
+		Volume:           "100m",
+	}
+	got, err := repackSwitches(opts)
+	if err != nil {
+		t.Fatalf("repackSwitches: %v", err)
+	}
+	want := []string{"-m0=lzma2", "-mx=9", "-ms=4g", "-mhe=on", "-v100m"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("repackSwitches = %v, want %v", got, want)
+	}
+}
+
+func TestRepackSwitches_NoSettings(t *testing.T) {
+	got, err := repackSwitches(repackOptions{Level: -1})
+	if err != nil {
+		t.Fatalf("repackSwitches: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("repackSwitches with no settings = %v, want empty", got)
+	}
+}
+
+func TestRepackSwitches_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts repackOptions
+		want string
+	}{
+		{"method", repackOptions{Level: -1, Method: "zstd"}, "unsupported compression method"},
+		{"level", repackOptions{Level: 10}, "invalid compression level"},
+		{"solid", repackOptions{Level: -1, Solid: "maybe"}, "invalid solid mode"},
+		{"header", repackOptions{Level: -1, HeaderEncryption: "yes"}, "invalid header encryption"},
+		{"volume", repackOptions{Level: -1, Volume: "-1"}, "invalid volume size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repackSwitches(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSwitchKey(t *testing.T) {
+	tests := map[string]string{
+		"-mx=9":     "-mx",
+		"-mx9":      "-mx",
+		"-mhe=on":   "-mhe",
+		"-ms=off":   "-ms",
+		"-m0=lzma2": "-m0",
+		"-v100m":    "-v",
+		"-mmt=4":    "-mmt=4",
+		"-sdel":     "-sdel",
+	}
+	for in, want := range tests {
+		if got := switchKey(in); got != want {
+			t.Errorf("switchKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMergeSwitches_OverridesReplaceDefaults(t *testing.T) {
+	defaults := []string{"-mhe=on", "-mx=9", "-mmt=4"}
+	overrides := []string{"-mx=1", "-v1g"}
+
+	got := mergeSwitches(defaults, overrides)
+	want := []string{"-mhe=on", "-mmt=4", "-mx=1", "-v1g"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeSwitches = %v, want %v", got, want)
+	}
+}
+
+func TestRetitleEntry_SplitRename(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	entryPath := "Archives/data.7z (deadbeef)"
+	mock.SetPassword(entryPath, []byte("pw"))
+
+	got, err := retitleEntry(mock, entryPath, "/home/user/data.7z.001")
+	if err != nil {
+		t.Fatalf("retitleEntry: %v", err)
+	}
+	if got != "Archives/data.7z.001 (deadbeef)" {
+		t.Errorf("retitleEntry = %q, want %q", got, "Archives/data.7z.001 (deadbeef)")
+	}
+}
+
+func TestRetitleEntry_SameName(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	entryPath := "Archives/data.7z (deadbeef)"
+
+	got, err := retitleEntry(mock, entryPath, "/home/user/data.7z")
+	if err != nil || got != entryPath {
+		t.Errorf("retitleEntry = %q, %v; want unchanged", got, err)
+	}
+	for _, c := range mock.GetCalls() {
+		if strings.HasPrefix(c, "edit-entry:") {
+			t.Errorf("unexpected edit call: %s", c)
+		}
+	}
+}
+
+// A 7z archive made without -mhe=on lists without a password and only asks
+// for one when file data is read.
+func TestVerifyArchivePassword_ClearHeaders(t *testing.T) {
+	script := `#!/bin/sh
+if [ "$1" = "l" ]; then
+  echo "Path = notes.txt"
+  exit 0
+fi
+printf 'Enter password (will not be echoed):' >&2
+read -r pw
+if [ "$pw" != "s3cret" ]; then
+  echo "ERROR: Wrong password : notes.txt" >&2
+  exit 2
+fi
+echo "Everything is Ok"
+`
+	bin := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if match, err := verifyArchivePassword(bin, []byte("s3cret"), "data.7z"); match != sevenzip.MatchCorrect {
+		t.Errorf("right password: got %v (%v), want MatchCorrect", match, err)
+	}
+	if match, _ := verifyArchivePassword(bin, []byte("wrong"), "data.7z"); match != sevenzip.MatchFailed {
+		t.Errorf("wrong password: got %v, want MatchFailed", match)
+	}
+}
@@ -0,0 +1,272 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+// conflictSwitches maps the --conflict policies to 7z's -ao switches.
+// "abort" refuses up front, so nothing exists when 7z runs.
+var conflictSwitches = map[string]string{
+	"abort":     "-aos",
+	"skip":      "-aos",
+	"overwrite": "-aoa",
+	"rename":    "-aou",
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Extract archived items back to their original locations",
+	Long: `Extracts each top-level item of an archive to the path it was archived
+from. 'a' records the absolute source paths (and the host name) in the
+entry's metadata; archives created before that cannot be restored this way.
+
+Existing files are handled by --conflict:
+
+  abort      refuse if any file already exists (default)
+  skip       keep existing files, restore the rest
+  overwrite  replace existing files
+  rename     restore next to existing files under a new name
+
+  7zkpxc restore --dry-run projects.7z
+  7zkpxc restore --prefix /mnt/restore projects.7z
+
+For an incremental backup, --chain DIR instead replays the full backup and
+every increment up to the given one into DIR (which must be empty),
+applying the recorded deletions:
+
+  7zkpxc restore --chain /mnt/restore home-2024-05-06-020000.7z`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runRestore,
+	GroupID: "actions",
+}
+
+func init() {
+	restoreCmd.Flags().String("conflict", "abort", "What to do with existing files: abort, skip, overwrite or rename")
+	restoreCmd.Flags().String("prefix", "", "Restore under this directory instead of /")
+	restoreCmd.Flags().Bool("dry-run", false, "Only show where each item would be restored")
+	restoreCmd.Flags().String("chain", "", "Replay an incremental backup chain into this directory")
+	restoreCmd.MarkFlagsMutuallyExclusive("chain", "prefix")
+	restoreCmd.MarkFlagsMutuallyExclusive("chain", "dry-run")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+// restoreItem is one top-level archive item and where it goes.
+type restoreItem struct {
+	Item     string // top-level path inside the archive
+	Dest     string // absolute destination of the item
+	Files    int    // regular files below the item
+	Existing int    // of those, how many already exist at the destination
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+
+	policy, _ := cmd.Flags().GetString("conflict")
+	if _, ok := conflictSwitches[policy]; !ok {
+		return fmt.Errorf("invalid --conflict %q (use abort, skip, overwrite or rename)", policy)
+	}
+	prefix, _ := cmd.Flags().GetString("prefix")
+	if prefix != "" {
+		abs, err := filepath.Abs(prefix)
+		if err != nil {
+			return fmt.Errorf("invalid --prefix: %w", err)
+		}
+		prefix = abs
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if chainDir, _ := cmd.Flags().GetString("chain"); chainDir != "" {
+		return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+			return replayChain(cfg, kp, entryPath, password, chainDir)
+		})
+	}
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		absPath, err := filepath.Abs(archivePath)
+		if err != nil {
+			absPath = archivePath
+		}
+
+		notes, _ := kp.GetAttribute(entryPath, "Notes")
+		meta := parseMetadata(notes)
+		if meta.Payload == tarPayload {
+			return fmt.Errorf("'%s' holds a tar stream ('a --tar') — use 'x' instead", filepath.Base(archivePath))
+		}
+		if len(meta.Sources) == 0 {
+			return fmt.Errorf("no source paths recorded for '%s' (created before 7zkpxc recorded them) — use 'x' instead", filepath.Base(archivePath))
+		}
+		if host, err := os.Hostname(); err == nil && meta.Host != "" && meta.Host != host {
+			fmt.Printf("⚠ '%s' was created on host '%s'; restoring on '%s'.\n", filepath.Base(archivePath), meta.Host, host)
+		}
+
+		entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, password, absPath)
+		if err != nil {
+			return fmt.Errorf("cannot list archive: %w", err)
+		}
+		items, unknown := buildRestorePlan(meta.Sources, entries, prefix)
+		printRestorePlan(filepath.Base(archivePath), items, unknown)
+
+		if dryRun {
+			fmt.Println("Dry run — nothing was extracted.")
+			return nil
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("no item of '%s' matches a recorded source path", filepath.Base(archivePath))
+		}
+		if policy == "abort" {
+			if existing := countExisting(items); existing > 0 {
+				return fmt.Errorf("%d file(s) already exist — choose --conflict skip, overwrite or rename", existing)
+			}
+		}
+
+		for _, it := range items {
+			fmt.Printf("Restoring '%s' to '%s'...\n", it.Item, it.Dest)
+			sevenZipArgs := []string{"x", "-o" + filepath.Dir(it.Dest), conflictSwitches[policy], "-spd", "--", absPath, it.Item}
+			if err := sevenzip.Run(cfg.SevenZip.BinaryPath, password, sevenZipArgs); err != nil {
+				return fmt.Errorf("restoring '%s' failed: %w", it.Item, err)
+			}
+		}
+		fmt.Printf("Restored %d item(s) from '%s'.\n", len(items), filepath.Base(archivePath))
+		return nil
+	})
+}
+
+// buildRestorePlan maps the top-level items of a listing to their recorded
+// sources. 7z stores a source argument under its base name (a wildcard like
+// "dir/*.txt" yields one item per matching file), so an item belongs to the
+// source whose base name equals or matches it and is restored next to that
+// source's parent. With prefix, destinations are re-rooted below it. Items
+// without a source are returned separately.
+func buildRestorePlan(sources []string, entries []sevenzip.Entry, prefix string) ([]restoreItem, []string) {
+	byItem := make(map[string]*restoreItem)
+	var order, unknown []string
+	seenUnknown := make(map[string]bool)
+
+	for _, e := range entries {
+		p := filepath.ToSlash(e.Path)
+		top, _, _ := strings.Cut(p, "/")
+		it, ok := byItem[top]
+		if !ok {
+			src := sourceForItem(sources, top)
+			if src == "" {
+				if !seenUnknown[top] {
+					seenUnknown[top] = true
+					unknown = append(unknown, top)
+				}
+				continue
+			}
+			dest := filepath.Join(filepath.Dir(src), top)
+			if prefix != "" {
+				dest = filepath.Join(prefix, dest)
+			}
+			it = &restoreItem{Item: top, Dest: dest}
+			byItem[top] = it
+			order = append(order, top)
+		}
+		if e.IsDir {
+			continue
+		}
+		it.Files++
+		target := filepath.Join(filepath.Dir(it.Dest), filepath.FromSlash(p))
+		if _, err := os.Lstat(target); err == nil {
+			it.Existing++
+		}
+	}
+
+	sort.Strings(order)
+	sort.Strings(unknown)
+	items := make([]restoreItem, 0, len(order))
+	for _, top := range order {
+		items = append(items, *byItem[top])
+	}
+	return items, unknown
+}
+
+// sourceForItem returns the recorded source a top-level item came from, or "".
+func sourceForItem(sources []string, item string) string {
+	for _, src := range sources {
+		if filepath.Base(src) == item {
+			return src
+		}
+	}
+	for _, src := range sources {
+		if ok, _ := path.Match(filepath.Base(src), item); ok {
+			return src
+		}
+	}
+	return ""
+}
+
+func countExisting(items []restoreItem) int {
+	n := 0
+	for _, it := range items {
+		n += it.Existing
+	}
+	return n
+}
+
+func printRestorePlan(archive string, items []restoreItem, unknown []string) {
+	fmt.Printf("Restore plan for '%s':\n", archive)
+	for _, it := range items {
+		status := fmt.Sprintf("%d file(s)", it.Files)
+		if it.Existing > 0 {
+			status += fmt.Sprintf(", %d already exist", it.Existing)
+		}
+		fmt.Printf("  %s → %s (%s)\n", it.Item, it.Dest, status)
+	}
+	for _, item := range unknown {
+		fmt.Printf("  ⚠ %s (no recorded source, skipped)\n", item)
+	}
+}
+
+// replayChain extracts the backup chain ending at entryPath into dir, base
+// first, deleting the tombstoned paths after each increment. password is the
+// (already resolved) password of the last archive.
+func replayChain(cfg *config.Config, kp PasswordProvider, entryPath string, password []byte, dir string) error {
+	chain, err := resolveChain(kp, entryPath)
+	if err != nil {
+		return err
+	}
+	for _, link := range chain {
+		if err := ensureArchiveExists(link.Path); err != nil {
+			return fmt.Errorf("chain archive '%s': %w", link.Path, err)
+		}
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("'%s' is not empty", dir)
+	}
+
+	fmt.Printf("Replaying %d archive(s) into '%s'...\n", len(chain), dir)
+	for i, link := range chain {
+		pw := password
+		if link.EntryPath != entryPath {
+			if pw, err = kp.GetPassword(link.EntryPath); err != nil {
+				return fmt.Errorf("password for '%s': %w", filepath.Base(link.Path), err)
+			}
+		}
+		fmt.Printf("[%d/%d] %s\n", i+1, len(chain), filepath.Base(link.Path))
+		err := sevenzip.RunQuiet(cfg.SevenZip.BinaryPath, pw, []string{"x", link.Path, "-o" + dir, "-aoa", "-y"})
+		if link.EntryPath != entryPath {
+			clear(pw)
+		}
+		if err != nil {
+			return fmt.Errorf("extracting '%s' failed: %w", filepath.Base(link.Path), err)
+		}
+		if err := applyTombstones(dir); err != nil {
+			return fmt.Errorf("applying deletions of '%s': %w", filepath.Base(link.Path), err)
+		}
+	}
+	fmt.Printf("Restored chain of %d archive(s) into '%s'.\n", len(chain), dir)
+	return nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+func TestSourceForItem(t *testing.T) {
+	sources := []string{"/home/u/proj", "/home/u/docs/*.txt", "/etc/app.conf"}
+	tests := map[string]string{
+		"proj":     "/home/u/proj",
+		"a.txt":    "/home/u/docs/*.txt",
+		"app.conf": "/etc/app.conf",
+		"other":    "",
+	}
+	for item, want := range tests {
+		if got := sourceForItem(sources, item); got != want {
+			t.Errorf("sourceForItem(%q) = %q, want %q", item, got, want)
+		}
+	}
+}
+
+func TestBuildRestorePlan(t *testing.T) {
+	root := t.TempDir()
+	proj := filepath.Join(root, "proj")
+	if err := os.MkdirAll(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proj, "main.go"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []string{proj, filepath.Join(root, "notes.md")}
+	entries := []sevenzip.Entry{
+		{Path: "proj", IsDir: true},
+		{Path: "proj/main.go", Size: 1},
+		{Path: "proj/sub/util.go", Size: 1},
+		{Path: "notes.md", Size: 1},
+		{Path: "stray.bin", Size: 1},
+	}
+
+	items, unknown := buildRestorePlan(sources, entries, "")
+	want := []restoreItem{
+		{Item: "notes.md", Dest: filepath.Join(root, "notes.md"), Files: 1},
+		{Item: "proj", Dest: proj, Files: 2, Existing: 1},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %+v, want %+v", items, want)
+	}
+	if !reflect.DeepEqual(unknown, []string{"stray.bin"}) {
+		t.Errorf("unknown = %v", unknown)
+	}
+	if countExisting(items) != 1 {
+		t.Errorf("countExisting = %d, want 1", countExisting(items))
+	}
+}
+
+func TestBuildRestorePlan_Prefix(t *testing.T) {
+	items, _ := buildRestorePlan([]string{"/home/u/proj"}, []sevenzip.Entry{{Path: "proj/a", Size: 1}}, "/mnt/restore")
+	if len(items) != 1 || items[0].Dest != filepath.Join("/mnt/restore", "home/u/proj") {
+		t.Errorf("items = %+v", items)
+	}
+}
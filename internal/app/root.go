@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -22,6 +23,16 @@ var skipDependencyCheck = map[string]bool{
 // pre-authenticated KeePass clients into the command runners cleanly.
 var testClientOptions []keepass.ClientOption
 
+// newKeePassClient opens a client for the configured database, passing the
+// configured key file (if any) along with testClientOptions.
+func newKeePassClient(cfg *config.Config) *keepass.Client {
+	opts := testClientOptions
+	if cfg.General.KeyFile != "" {
+		opts = append([]keepass.ClientOption{keepass.WithKeyFile(cfg.General.KeyFile)}, opts...)
+	}
+	return keepass.New(cfg.General.KdbxPath, opts...)
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "7zkpxc",
@@ -45,6 +56,39 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// ExitCode maps an error returned by Execute to a process exit status.
+// The exit code of a failed external program (7z, or the command run by
+// exec) is passed through; any other error is 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) && coder.ExitCode() > 0 {
+		return coder.ExitCode()
+	}
+	return 1
+}
+
+// codedError attaches an explicit process exit status to an error.
+type codedError struct {
+	err  error
+	code int
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+func (e *codedError) ExitCode() int { return e.code }
+
+// withExitCode makes Execute's caller exit with code when err is returned.
+// It overrides any exit code carried by err itself.
+func withExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{err: err, code: code}
+}
+
 func init() {
 	// Global flags can be defined here
 }
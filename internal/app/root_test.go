@@ -1,9 +1,12 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
 	"github.com/spf13/cobra"
 )
 
@@ -98,3 +101,27 @@ func TestCheckDependencies_SkipCompletion(t *testing.T) {
 		t.Errorf("checkDependencies should skip completion, got: %v", err)
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"plain error", errors.New("boom"), 1},
+		{"wrapped 7z error", fmt.Errorf("extraction failed: %w", &sevenzip.ExitError{Code: 2}), 2},
+		{"exec command", &commandExitError{Name: "grep", Code: 1}, 1},
+		{"exec command code 3", fmt.Errorf("x: %w", &commandExitError{Name: "sh", Code: 3}), 3},
+		{"zero code", &sevenzip.ExitError{Code: 0}, 1},
+		{"explicit code overrides 7z", withExitCode(fmt.Errorf("x: %w", &sevenzip.ExitError{Code: 8}), 2), 2},
+		{"explicit code 1", withExitCode(errors.New("differ"), 1), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
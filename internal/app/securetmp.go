@@ -0,0 +1,80 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// privateTempRoot returns the directory under which private temp directories
+// are created. $XDG_RUNTIME_DIR is preferred because it is per-user (0700) and
+// usually RAM-backed; os.TempDir() is the fallback.
+func privateTempRoot() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// newPrivateTempDir creates a fresh 0700 directory for decrypted plaintext.
+// The caller must remove it with wipeDir when done.
+func newPrivateTempDir(pattern string) (string, error) {
+	dir, err := os.MkdirTemp(privateTempRoot(), pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create private temp directory: %w", err)
+	}
+	// MkdirTemp already uses 0700, but be explicit in case of an unusual umask.
+	if err := os.Chmod(dir, 0o700); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to secure temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// wipeFile overwrites a regular file with zeros before removing it.
+// Best effort: on copy-on-write or journaling filesystems the old blocks may
+// survive, which is why plaintext should live on tmpfs whenever possible.
+func wipeFile(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().IsRegular() && info.Size() > 0 {
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			_, _ = io.CopyN(f, zeroReader{}, info.Size())
+			_ = f.Sync()
+			_ = f.Close()
+		}
+	}
+	return os.Remove(path)
+}
+
+// wipeDir overwrites every regular file below dir and then removes the tree.
+func wipeDir(dir string) error {
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // keep going; RemoveAll below cleans up whatever is left
+		}
+		if d.Type().IsRegular() {
+			// Make read-only extracted files writable so they can be overwritten
+			_ = os.Chmod(path, 0o600)
+			_ = wipeFile(path)
+		} else if d.IsDir() {
+			_ = os.Chmod(path, 0o700)
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
+
+// zeroReader is an io.Reader that yields an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrivateTempRoot_PrefersXDGRuntimeDir(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	if got := privateTempRoot(); got != runtimeDir {
+		t.Errorf("privateTempRoot() = %q, want %q", got, runtimeDir)
+	}
+}
+
+func TestPrivateTempRoot_FallsBackWhenMissing(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(t.TempDir(), "missing"))
+
+	if got := privateTempRoot(); got != os.TempDir() {
+		t.Errorf("privateTempRoot() = %q, want %q", got, os.TempDir())
+	}
+}
+
+func TestNewPrivateTempDir_Permissions(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	dir, err := newPrivateTempDir("7zkpxc-test-")
+	if err != nil {
+		t.Fatalf("newPrivateTempDir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("temp dir permissions = %o, want 700", perm)
+	}
+}
+
+func TestWipeFile_RemovesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(path, []byte("plaintext"), 0o400); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wipeFile(path); err != nil {
+		t.Fatalf("wipeFile: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file should be removed after wipeFile")
+	}
+}
+
+func TestWipeDir_RemovesTree(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "plain")
+	sub := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "f.txt"), []byte("data"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("f.txt", filepath.Join(sub, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wipeDir(dir); err != nil {
+		t.Fatalf("wipeDir: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("directory should be removed after wipeDir")
+	}
+}
+
+func TestZeroReader(t *testing.T) {
+	buf := []byte("not zero")
+	n, err := zeroReader{}.Read(buf)
+	if err != nil || n != len(buf) {
+		t.Fatalf("Read() = %d, %v", n, err)
+	}
+	for i, b := range buf {
+		if b != 0 {
+			t.Fatalf("byte %d = %d, want 0", i, b)
+		}
+	}
+}
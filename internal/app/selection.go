@@ -0,0 +1,442 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+// -------------------------------------------------------------------
+// Source selection for 'a'
+//
+// Without --exclude/--include and ignore files, sources are handed to 7z
+// as they are. Otherwise 7zkpxc walks them itself and passes the selected
+// files. Either way 7z gets them in a list file (@list), never in argv.
+// -------------------------------------------------------------------
+
+// ignoreFileName is the ignore file honored in the root of a source directory.
+const ignoreFileName = ".7zkpxcignore"
+
+// ignoreRule is one compiled line of an ignore file (gitignore syntax).
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// ignoreRules is an ordered rule list; the last matching rule wins.
+type ignoreRules []ignoreRule
+
+// parseIgnoreRules compiles gitignore-style lines. Blank lines and "#"
+// comments are skipped.
+func parseIgnoreRules(lines []string) (ignoreRules, error) {
+	var rules ignoreRules
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := compileIgnoreRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compileIgnoreRule translates one pattern into a regexp over slash-separated
+// paths relative to the ignore root. A pattern with a slash (other than a
+// trailing one) is anchored to the root; otherwise it matches at any depth.
+func compileIgnoreRule(pattern string) (ignoreRule, error) {
+	var rule ignoreRule
+	switch {
+	case strings.HasPrefix(pattern, "!"):
+		rule.negate = true
+		pattern = pattern[1:]
+	case strings.HasPrefix(pattern, `\!`), strings.HasPrefix(pattern, `\#`):
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if pattern == "" {
+		return rule, errors.New("empty pattern")
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return rule, errors.New("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return rule, err
+	}
+	rule.re = re
+	return rule, nil
+}
+
+// ignored reports whether rel (relative to the rules' root) is excluded.
+func (rules ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// ignoredPath is like ignored for a path that was not reached by a walk: it
+// is also excluded when one of its parent directories is.
+func (rules ignoreRules) ignoredPath(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if rules.ignored(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return rules.ignored(rel, isDir)
+}
+
+// loadIgnoreFile reads dir's ignore file. A missing file yields no rules.
+func loadIgnoreFile(dir string) (ignoreRules, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ignoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules, err := parseIgnoreRules(strings.Split(string(data), "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, ignoreFileName), err)
+	}
+	return rules, nil
+}
+
+// readFileList reads the paths of --files-from: one per line, or
+// NUL-separated (find -print0) with nul. Empty entries are skipped.
+func readFileList(name string, nul bool) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file list: %w", err)
+	}
+	sep := []byte("\n")
+	if nul {
+		sep = []byte{0}
+	}
+	var paths []string
+	for _, field := range bytes.Split(data, sep) {
+		p := string(field)
+		if !nul {
+			p = strings.TrimRight(p, "\r")
+		}
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// selectSources turns the sources of 'a' and the paths of --files-from into
+// the items handed to 7z. Without patterns or ignore files every path is
+// passed verbatim. Otherwise source directories are walked: a file is kept
+// unless an --exclude pattern or the source's ignore file matches it or one
+// of its directories, and, with includes, only if an include glob matches
+// its name or path. Listed paths are filtered the same way (relative to the
+// current directory) but not walked. Empty directories are not kept when
+// filtering.
+func selectSources(sources, listed, excludes, includes []string) ([]sourceFile, error) {
+	exclude, err := parseIgnoreRules(excludes)
+	if err != nil {
+		return nil, err
+	}
+	filtering := len(excludes) > 0 || len(includes) > 0
+
+	expanded := expandSourceGlobs(sources)
+	ignores := make(map[string]ignoreRules)
+	for _, src := range expanded {
+		if info, err := os.Stat(src); err != nil || !info.IsDir() {
+			continue
+		}
+		rules, err := loadIgnoreFile(src)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) > 0 {
+			ignores[src] = rules
+			filtering = true
+		}
+	}
+
+	var items []sourceFile
+	if !filtering {
+		for _, p := range sources {
+			items = append(items, sourceFile{Rel: p})
+		}
+		return listedItems(items, listed, nil)
+	}
+
+	keep := func(rel string, isDir bool, rules ignoreRules) bool {
+		if exclude.ignored(rel, isDir) || rules.ignored(rel, isDir) {
+			return false
+		}
+		return isDir || len(includes) == 0 || matchesAnyGlob(includes, rel)
+	}
+
+	for _, src := range expanded {
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return nil, err
+		}
+		parent := filepath.Dir(abs)
+		rules := ignores[src]
+		err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(abs, p)
+			if err != nil {
+				return err
+			}
+			if rel == "." {
+				rel = filepath.Base(abs)
+				if d.IsDir() {
+					return nil
+				}
+			}
+			if !keep(filepath.ToSlash(rel), d.IsDir(), rules) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			inArchive, err := filepath.Rel(parent, p)
+			if err != nil {
+				return err
+			}
+			items = append(items, sourceFile{Dir: parent, Rel: filepath.ToSlash(inArchive)})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan '%s': %w", src, err)
+		}
+	}
+
+	items, err = listedItems(items, listed, func(rel string, isDir bool) bool {
+		return !exclude.ignoredPath(rel, isDir) && keep(rel, isDir, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("nothing to archive: every file was excluded")
+	}
+	return items, nil
+}
+
+// listedItems appends the --files-from paths to items as literal names
+// relative to the current directory, keeping only those keep accepts (nil
+// keeps all). A listed directory that contains other listed paths is left
+// out, so "find ." output does not add files twice.
+func listedItems(items []sourceFile, listed []string, keep func(rel string, isDir bool) bool) ([]sourceFile, error) {
+	if len(listed) == 0 {
+		return items, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	rels := make([]string, len(listed))
+	for i, p := range listed {
+		rels[i] = filepath.ToSlash(filepath.Clean(p))
+	}
+	var parents []string
+	for _, rel := range rels {
+		if rel != "." {
+			parents = append(parents, path.Dir(rel))
+		}
+	}
+	hasChildren := func(dir string) bool {
+		for _, parent := range parents {
+			if dir == "." || parent == dir || strings.HasPrefix(parent, dir+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rel := range rels {
+		info, err := os.Lstat(filepath.FromSlash(rel))
+		isDir := err == nil && info.IsDir()
+		if isDir && hasChildren(rel) {
+			continue
+		}
+		if keep != nil && !keep(rel, isDir) {
+			continue
+		}
+		items = append(items, sourceFile{Dir: cwd, Rel: rel})
+	}
+	return items, nil
+}
+
+// expandSourceGlobs expands sources the shell left unexpanded ("*.txt").
+func expandSourceGlobs(sources []string) []string {
+	var out []string
+	for _, src := range sources {
+		if !strings.ContainsAny(src, "*?[") {
+			out = append(out, src)
+			continue
+		}
+		matches, _ := filepath.Glob(src)
+		out = append(out, matches...)
+	}
+	return out
+}
+
+// matchesAnyGlob reports whether a glob matches rel or its base name.
+func matchesAnyGlob(globs []string, rel string) bool {
+	for _, g := range globs {
+		if manifestPathMatches(g, rel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedSources returns what 'restore' should know about the items of a
+// new archive: the sources as given and, for listed relative paths, their
+// top-level directory (which 7z stores them under).
+func recordedSources(sources, listed []string) []string {
+	out := append([]string{}, sources...)
+	seen := make(map[string]bool)
+	for _, p := range listed {
+		clean := filepath.Clean(p)
+		if !filepath.IsAbs(clean) {
+			clean, _, _ = strings.Cut(filepath.ToSlash(clean), "/")
+		}
+		if !seen[clean] {
+			seen[clean] = true
+			out = append(out, clean)
+		}
+	}
+	return out
+}
+
+// runFileLists runs "7z <baseArgs> -scsUTF-8 <archive> @list <extraFlags>"
+// once per directory of items, inside that directory. Walked files (Dir set)
+// are literal names (-spd) stored under their full relative path (-spf2;
+// 7z otherwise keeps only the last component of each name). Verbatim items
+// (Dir empty) run in the current directory with 7z's usual wildcard and path
+// handling. Runs after the first add to the archive, which split volumes do
+// not support.
+func runFileLists(binaryPath string, password []byte, baseArgs []string, archiveName string, items []sourceFile, extraFlags []string) error {
+	absArchive, err := filepath.Abs(archiveName)
+	if err != nil {
+		return err
+	}
+
+	byDir := make(map[string][]string)
+	var dirs []string
+	for _, it := range items {
+		if strings.ContainsAny(it.Rel, "\n\r") {
+			return fmt.Errorf("cannot archive '%s': file names with line breaks are not supported", it.Rel)
+		}
+		if _, ok := byDir[it.Dir]; !ok {
+			dirs = append(dirs, it.Dir)
+		}
+		byDir[it.Dir] = append(byDir[it.Dir], it.Rel)
+	}
+	sort.Strings(dirs)
+
+	if len(dirs) == 0 {
+		args := append(append(append([]string{}, baseArgs...), absArchive), extraFlags...)
+		return sevenzip.Run(binaryPath, password, args)
+	}
+	if len(dirs) > 1 {
+		for _, a := range append(append([]string{}, baseArgs...), extraFlags...) {
+			if strings.HasPrefix(a, "-v") {
+				return fmt.Errorf("split volumes need all sources in one directory when filtering")
+			}
+		}
+	}
+
+	tmp, err := newPrivateTempDir("7zkpxc-list-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = wipeDir(tmp) }()
+
+	for i, dir := range dirs {
+		list := filepath.Join(tmp, fmt.Sprintf("list-%d.txt", i))
+		if err := os.WriteFile(list, []byte(strings.Join(byDir[dir], "\n")+"\n"), 0o600); err != nil {
+			return err
+		}
+		args := append([]string{}, baseArgs...)
+		if dir != "" {
+			args = append(args, "-spd", "-spf2")
+		}
+		args = append(args, "-scsUTF-8", absArchive, "@"+list)
+		args = append(args, extraFlags...)
+		if err := sevenzip.RunInDir(dir, binaryPath, password, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
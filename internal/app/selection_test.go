@@ -0,0 +1,202 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	rules, err := parseIgnoreRules([]string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"node_modules/",
+		"/build",
+		"docs/**/*.tmp",
+		`\#hash`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"sub/app.log", false, true},
+		{"sub/keep.log", false, false},
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"node_modules", false, false}, // dir-only rule
+		{"build", true, true},
+		{"src/build", true, false}, // anchored
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"other/a.tmp", false, false},
+		{"#hash", false, true},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := rules.ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	if _, err := parseIgnoreRules([]string{"[abc"}); err == nil {
+		t.Error("expected an error for an unterminated class")
+	}
+}
+
+func TestReadFileList(t *testing.T) {
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "lines")
+	nul := filepath.Join(dir, "nul")
+	if err := os.WriteFile(lines, []byte("a.txt\r\nb c.txt\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nul, []byte("a.txt\x00with\nnewline\x00"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readFileList(lines, false)
+	if err != nil || !reflect.DeepEqual(got, []string{"a.txt", "b c.txt"}) {
+		t.Errorf("lines = %q, %v", got, err)
+	}
+	got, err = readFileList(nul, true)
+	if err != nil || !reflect.DeepEqual(got, []string{"a.txt", "with\nnewline"}) {
+		t.Errorf("nul = %q, %v", got, err)
+	}
+}
+
+// makeTree creates files (slash paths) below root.
+func makeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		full := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func itemRels(items []sourceFile) []string {
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = it.Rel
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestSelectSources_Verbatim(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "proj/main.go")
+	src := filepath.Join(root, "proj")
+
+	items, err := selectSources([]string{src, "*.txt"}, []string{"listed.txt"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, _ := os.Getwd()
+	want := []sourceFile{{Rel: src}, {Rel: "*.txt"}, {Dir: cwd, Rel: "listed.txt"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %+v, want %+v", items, want)
+	}
+}
+
+func TestSelectSources_Filtered(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"proj/main.go",
+		"proj/debug.log",
+		"proj/node_modules/pkg/index.js",
+		"proj/secret/key.pem",
+		"proj/docs/guide.md",
+	)
+	if err := os.WriteFile(filepath.Join(root, "proj", ignoreFileName), []byte("secret/\n*.pem\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(root, "proj")
+
+	items, err := selectSources([]string{src}, nil, []string{"node_modules", "*.log"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"proj/" + ignoreFileName, "proj/docs/guide.md", "proj/main.go"}
+	if got := itemRels(items); !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+	for _, it := range items {
+		if it.Dir != root {
+			t.Errorf("%s: Dir = %q, want %q", it.Rel, it.Dir, root)
+		}
+	}
+
+	items, err = selectSources([]string{src}, nil, nil, []string{"*.md", "main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := itemRels(items); !reflect.DeepEqual(got, []string{"proj/docs/guide.md", "proj/main.go"}) {
+		t.Errorf("include items = %v", got)
+	}
+
+	if _, err := selectSources([]string{src}, nil, []string{"*"}, nil); err == nil || !strings.Contains(err.Error(), "nothing to archive") {
+		t.Errorf("expected 'nothing to archive', got %v", err)
+	}
+}
+
+func TestSelectSources_ListedFiltered(t *testing.T) {
+	items, err := selectSources(nil, []string{"./src/a.go", "src/a_test.go", "vendor/x.go"}, []string{"*_test.go", "/vendor"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, _ := os.Getwd()
+	if want := []sourceFile{{Dir: cwd, Rel: "src/a.go"}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %+v, want %+v", items, want)
+	}
+}
+
+func TestListedItems_SkipsParentsOfListedPaths(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "src/a.go", "src/sub/b.go", "lone/c.go")
+	t.Chdir(root)
+
+	// As printed by "find . -print0", plus a directory listed on its own.
+	listed := []string{".", "./src", "./src/a.go", "./src/sub", "./src/sub/b.go", "lone"}
+	items, err := listedItems(nil, listed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := itemRels(items); !reflect.DeepEqual(got, []string{"lone", "src/a.go", "src/sub/b.go"}) {
+		t.Errorf("items = %v", got)
+	}
+}
+
+func TestRecordedSources(t *testing.T) {
+	got := recordedSources([]string{"docs"}, []string{"./src/a.go", "src/b.go", "/etc/hosts", "top.txt"})
+	want := []string{"docs", "src", "/etc/hosts", "top.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recordedSources = %v, want %v", got, want)
+	}
+}
+
+func TestRunFileLists_VolumesNeedOneDirectory(t *testing.T) {
+	items := []sourceFile{{Dir: "/a", Rel: "x/1"}, {Dir: "/b", Rel: "y/2"}}
+	err := runFileLists("7z", nil, []string{"a", "-v100m"}, "out.7z", items, nil)
+	if err == nil || !strings.Contains(err.Error(), "split volumes") {
+		t.Errorf("expected a split volume error, got %v", err)
+	}
+	err = runFileLists("7z", nil, []string{"a"}, "out.7z", []sourceFile{{Rel: "bad\nname"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "line breaks") {
+		t.Errorf("expected a line break error, got %v", err)
+	}
+}
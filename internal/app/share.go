@@ -0,0 +1,340 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/spf13/cobra"
+)
+
+// sharesGroup is the top-level KeePassXC group holding share passwords,
+// kept apart from the long-term archive entries.
+const sharesGroup = "Shares"
+
+const shareHeader = "[7zkpxc-share]"
+
+var shareCmd = &cobra.Command{
+	Use:   "share <archive>",
+	Short: "Create a re-encrypted copy with its own share password",
+	Long: `Creates a copy of an archive encrypted with a new, separate share password,
+so the archive's long-term password never has to leave KeePassXC.
+
+The share password is stored in the top-level "Shares" group. Its entry
+links back to the source entry and records an expiry date.
+
+  7zkpxc share report.7z                    # 30 days, random password
+  7zkpxc share --diceware 6 --expires 2w report.7z
+  7zkpxc share --list
+  7zkpxc share --revoke report.share-20250101.7z
+
+--revoke deletes both the share copy and its KeePassXC entry.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE:    runShare,
+	GroupID: "actions",
+}
+
+func init() {
+	shareCmd.Flags().Int("length", 0, "Share password length (default: general.password_length)")
+	shareCmd.Flags().Int("diceware", 0, "Use a diceware passphrase with this many words")
+	shareCmd.MarkFlagsMutuallyExclusive("length", "diceware")
+	shareCmd.Flags().String("expires", "30d", "Expiry as days (30d), weeks (2w) or a date (2006-01-02)")
+	shareCmd.Flags().StringP("output", "o", "", "Path of the share copy (default: <name>.share-<date>.7z)")
+	shareCmd.Flags().Bool("show-password", false, "Print the share password after creating the copy")
+	shareCmd.Flags().Bool("revoke", false, "Delete the given share copy and its KeePassXC entry")
+	shareCmd.Flags().Bool("list", false, "List share entries and their expiry dates")
+	shareCmd.MarkFlagsMutuallyExclusive("revoke", "list")
+	rootCmd.AddCommand(shareCmd)
+}
+
+// shareInfo is the [7zkpxc-share] section of a share entry's Notes.
+type shareInfo struct {
+	Source     string    // Entry path of the source archive
+	SourcePath string    // Absolute path of the source archive at share time
+	Created    time.Time // Date the share was created
+	Expires    time.Time // Date after which the share should be revoked
+}
+
+func runShare(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		return runShareList()
+	}
+	if revoke, _ := cmd.Flags().GetBool("revoke"); revoke {
+		return runShareRevoke(args[0])
+	}
+
+	archivePath := args[0]
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+
+	now := time.Now()
+	expiresFlag, _ := cmd.Flags().GetString("expires")
+	expires, err := parseExpiry(expiresFlag, now)
+	if err != nil {
+		return err
+	}
+	output, _ := cmd.Flags().GetString("output")
+	length, _ := cmd.Flags().GetInt("length")
+	words, _ := cmd.Flags().GetInt("diceware")
+	showPassword, _ := cmd.Flags().GetBool("show-password")
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		absFirst := resolveFirstVolume(absPath)
+		if err := ensureRebuildable(absFirst); err != nil {
+			return err
+		}
+
+		if output == "" {
+			name := AnalyzeArchive(absFirst).NormalizedName
+			stem := strings.TrimSuffix(name, filepath.Ext(name))
+			output = filepath.Join(filepath.Dir(absFirst), stem+".share-"+now.Format("20060102")+".7z")
+		} else if filepath.Ext(output) == "" {
+			output += ".7z"
+		}
+		absOut, err := filepath.Abs(output)
+		if err != nil {
+			return fmt.Errorf("failed to resolve output path: %w", err)
+		}
+		if _, err := os.Stat(absOut); err == nil {
+			return fmt.Errorf("share copy '%s' already exists — refusing to overwrite", absOut)
+		}
+
+		var sharePassword []byte
+		if words > 0 {
+			fmt.Printf("Generating %d-word diceware share passphrase...\n", words)
+			sharePassword, err = kp.Diceware(words)
+		} else {
+			if length <= 0 {
+				length = cfg.General.PasswordLength
+			}
+			fmt.Printf("Generating %d-character share password...\n", length)
+			sharePassword, err = kp.GeneratePassword(length)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate share password: %w", err)
+		}
+		defer func() {
+			for i := range sharePassword {
+				sharePassword[i] = 0
+			}
+		}()
+
+		stagedFirst, stagingDir, err := rebuildArchive(cfg.SevenZip.BinaryPath, absFirst, password, sharePassword, cfg.SevenZip.DefaultArgs)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(stagingDir) }()
+
+		fmt.Printf("Saving share entry to KeePassXC group '%s'...\n", sharesGroup)
+		shareEntry, _, err := addArchiveEntry(kp, sharesGroup, absOut, sharePassword)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Rename(stagedFirst, absOut); err != nil {
+			fmt.Println("Moving share copy failed, rolling back KeePassXC entry...")
+			if rbErr := kp.DeleteEntry(shareEntry); rbErr != nil {
+				fmt.Printf("Warning: rollback failed — manually delete '%s' from KeePassXC: %v\n", shareEntry, rbErr)
+			}
+			return fmt.Errorf("failed to move share copy into place: %w", err)
+		}
+		_ = os.Chmod(absOut, 0o600)
+
+		notes := buildShareSection(shareInfo{
+			Source:     entryPath,
+			SourcePath: absFirst,
+			Created:    now,
+			Expires:    expires,
+		})
+		if err := kp.UpdateEntryNotes(shareEntry, notes); err != nil {
+			fmt.Printf("Warning: could not record share details in '%s': %v\n", shareEntry, err)
+		}
+		updateMetadata(kp, shareEntry, absOut)
+
+		fmt.Printf("Success! Share copy created: %s\n", absOut)
+		fmt.Printf("  Entry:   %s\n", shareEntry)
+		fmt.Printf("  Expires: %s\n", expires.Format("2006-01-02"))
+		if showPassword {
+			fmt.Printf("  Password: %s\n", sharePassword)
+		}
+		return nil
+	})
+}
+
+// runShareRevoke deletes a share copy and its entry in the Shares group.
+// Entries without a [7zkpxc-share] section are never touched.
+func runShareRevoke(sharePath string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(sharePath)
+	if err != nil {
+		absPath = sharePath
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	password, shareEntry, _, err := resolvePassword(kp, sharesGroup, absPath, "")
+	for i := range password {
+		password[i] = 0
+	}
+	if err != nil {
+		if IsPasswordNotFound(err) {
+			return fmt.Errorf("no share entry found for '%s' in group '%s'", filepath.Base(absPath), sharesGroup)
+		}
+		return err
+	}
+
+	notes, _ := kp.GetAttribute(shareEntry, "Notes")
+	if _, ok := parseShareSection(notes); !ok {
+		return fmt.Errorf("'%s' is not a share entry — refusing to delete it", shareEntry)
+	}
+
+	for _, v := range archiveVolumes(resolveFirstVolume(absPath)) {
+		if err := os.Remove(v); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete share copy '%s': %w", v, err)
+		}
+	}
+	fmt.Printf("Deleted share copy '%s'.\n", filepath.Base(absPath))
+
+	if err := kp.DeleteEntry(shareEntry); err != nil {
+		return fmt.Errorf("share copy deleted but entry '%s' could not be removed: %w", shareEntry, err)
+	}
+	fmt.Printf("Deleted share entry '%s'.\n", shareEntry)
+	return nil
+}
+
+// runShareList prints every share entry with its source and expiry date.
+func runShareList() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	kp := newKeePassClient(cfg)
+	defer kp.Close()
+
+	if !kp.GroupExists(sharesGroup) {
+		fmt.Println("No shares.")
+		return nil
+	}
+	titles, err := kp.ListEntries(sharesGroup)
+	if err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		fmt.Println("No shares.")
+		return nil
+	}
+
+	now := time.Now()
+	for _, title := range titles {
+		entryPath := joinEntry(sharesGroup, title)
+		notes, _ := kp.GetAttribute(entryPath, "Notes")
+		info, ok := parseShareSection(notes)
+		if !ok {
+			continue
+		}
+		status := "expires " + info.Expires.Format("2006-01-02")
+		if !info.Expires.IsZero() && now.After(info.Expires) {
+			status = "⚠ EXPIRED " + info.Expires.Format("2006-01-02")
+		}
+		lastPath, _ := kp.GetAttribute(entryPath, "Username")
+		fmt.Printf("%s  (%s)\n", title, status)
+		fmt.Printf("    copy:   %s\n", lastPath)
+		fmt.Printf("    source: %s\n", info.Source)
+	}
+	return nil
+}
+
+// parseExpiry turns "30d", "2w" or "2006-01-02" into an absolute expiry date.
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("expiry date %s is in the past", s)
+		}
+		return t, nil
+	}
+
+	if len(s) >= 2 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err == nil && n > 0 {
+			switch s[len(s)-1] {
+			case 'd':
+				return now.AddDate(0, 0, n), nil
+			case 'w':
+				return now.AddDate(0, 0, 7*n), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q (use e.g. 30d, 2w or 2006-01-02)", s)
+}
+
+// buildShareSection returns the [7zkpxc-share] INI section string.
+func buildShareSection(info shareInfo) string {
+	var b strings.Builder
+	b.WriteString(shareHeader)
+	b.WriteByte('\n')
+	fmt.Fprintf(&b, "source=%s\n", info.Source)
+	if info.SourcePath != "" {
+		fmt.Fprintf(&b, "source_path=%s\n", info.SourcePath)
+	}
+	fmt.Fprintf(&b, "created=%s\n", info.Created.Format("2006-01-02"))
+	fmt.Fprintf(&b, "expires=%s\n", info.Expires.Format("2006-01-02"))
+	return b.String()
+}
+
+// parseShareSection extracts shareInfo from a Notes string.
+// ok is false when the notes carry no [7zkpxc-share] section.
+func parseShareSection(notes string) (info shareInfo, ok bool) {
+	inSection := false
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == shareHeader {
+			inSection, ok = true, true
+			continue
+		}
+		if inSection && (strings.HasPrefix(line, "[") || line == "") {
+			break
+		}
+		if !inSection {
+			continue
+		}
+
+		key, val, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch strings.TrimSpace(key) {
+		case "source":
+			info.Source = val
+		case "source_path":
+			info.SourcePath = val
+		case "created":
+			info.Created, _ = time.ParseInLocation("2006-01-02", val, time.Local)
+		case "expires":
+			info.Expires, _ = time.ParseInLocation("2006-01-02", val, time.Local)
+		}
+	}
+	return info, ok
+}
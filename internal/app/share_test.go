@@ -0,0 +1,71 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 10, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"30d", time.Date(2025, 2, 9, 15, 0, 0, 0, time.UTC)},
+		{"2w", time.Date(2025, 1, 24, 15, 0, 0, 0, time.UTC)},
+		{"2025-03-01", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiry(tt.in, now)
+		if err != nil {
+			t.Errorf("parseExpiry(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseExpiry(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseExpiry_Invalid(t *testing.T) {
+	now := time.Date(2025, 1, 10, 15, 0, 0, 0, time.UTC)
+	for _, in := range []string{"", "d", "0d", "-3d", "10y", "soon", "2024-12-31"} {
+		if _, err := parseExpiry(in, now); err == nil {
+			t.Errorf("parseExpiry(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestShareSection_RoundTrip(t *testing.T) {
+	info := shareInfo{
+		Source:     "7zkpxc/report.7z (a3b2c1d0)",
+		SourcePath: "/home/user/report.7z",
+		Created:    time.Date(2025, 1, 10, 0, 0, 0, 0, time.Local),
+		Expires:    time.Date(2025, 2, 9, 0, 0, 0, 0, time.Local),
+	}
+
+	// Regular metadata is appended by updateMetadata after the share section
+	notes := mergeMetadataIntoNotes(buildShareSection(info), EntryMetadata{Size: 42, Ver: "1.0.0"})
+
+	got, ok := parseShareSection(notes)
+	if !ok {
+		t.Fatalf("share section not found in %q", notes)
+	}
+	if got != info {
+		t.Errorf("parseShareSection = %+v, want %+v", got, info)
+	}
+	if m := parseMetadata(notes); m.Size != 42 {
+		t.Errorf("metadata size = %d, want 42", m.Size)
+	}
+}
+
+func TestParseShareSection_Missing(t *testing.T) {
+	notes := buildMetadataSection(EntryMetadata{Size: 1, Ver: "1.0.0"})
+	if _, ok := parseShareSection(notes); ok {
+		t.Error("regular archive entry must not be treated as a share")
+	}
+	if !strings.Contains(buildShareSection(shareInfo{}), shareHeader) {
+		t.Error("buildShareSection must write the share header")
+	}
+}
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/shamir"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// sssPrefix starts every encoded secret share. The trailing digit is the
+// format version.
+const sssPrefix = "7zkpxc-sss1"
+
+var splitSecretCmd = &cobra.Command{
+	Use:   "split-secret <archive>",
+	Short: "Split an archive's password into Shamir shares",
+	Long: `Splits the archive's password into n Shamir shares over GF(256), any k of
+which reconstruct it. Fewer than k shares reveal nothing about the password.
+
+Each share is a line of text with the entry's UUID, the threshold, the
+share number and a CRC32 checksum, so typos are caught before recombining:
+
+  7zkpxc-sss1:a3b2c1d0:3:1:5f0c...e2:9a1b2c3d
+
+By default the shares are printed. With --to (one age recipient per share,
+in order) each share is instead encrypted to its custodian and written to
+"<archive>.sss-<i>-of-<n>.age"; plaintext shares never touch the disk.
+
+  7zkpxc split-secret dr-vault.7z -n 5 -k 3
+  7zkpxc x --shares dr-vault.7z`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runSplitSecret,
+	GroupID: "actions",
+}
+
+func init() {
+	splitSecretCmd.Flags().IntP("shares", "n", 5, "Number of shares to create")
+	splitSecretCmd.Flags().IntP("threshold", "k", 3, "Number of shares needed to recover the password")
+	splitSecretCmd.Flags().StringArray("to", nil, "age recipient for each share, in order (repeat n times)")
+	rootCmd.AddCommand(splitSecretCmd)
+}
+
+func runSplitSecret(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	archivePath := args[0]
+
+	n, _ := cmd.Flags().GetInt("shares")
+	k, _ := cmd.Flags().GetInt("threshold")
+	recipients, _ := cmd.Flags().GetStringArray("to")
+	if len(recipients) > 0 && len(recipients) != n {
+		return fmt.Errorf("--to given %d time(s), but %d shares requested — one recipient per share", len(recipients), n)
+	}
+
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+
+	return withKeePassArchive(archivePath, true, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		shares, err := shamir.Split(password, n, k)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			for _, s := range shares {
+				clear(s.Y)
+			}
+		}()
+
+		id := "none"
+		if _, uuid8, ok := parseEntryTitle(path.Base(entryPath)); ok {
+			id = uuid8
+		}
+
+		if len(recipients) == 0 {
+			fmt.Printf("Password of '%s' split into %d shares (any %d recover it):\n\n", filepath.Base(archivePath), n, k)
+			for _, s := range shares {
+				fmt.Printf("Share %d/%d: %s\n", s.X, n, encodeSecretShare(id, k, s))
+			}
+			fmt.Println("\nHand each share to a different custodian. Recover with '7zkpxc x --shares'.")
+			return nil
+		}
+
+		base := AnalyzeArchive(resolveFirstVolume(absPath)).NormalizedName
+		for i, s := range shares {
+			out := filepath.Join(filepath.Dir(absPath), fmt.Sprintf("%s.sss-%d-of-%d%s", base, s.X, n, grantSuffix))
+			text := []byte(encodeSecretShare(id, k, s))
+			_, err := writeGrant(out, text, []string{recipients[i]})
+			clear(text)
+			if err != nil {
+				return fmt.Errorf("failed to export share %d: %w", s.X, err)
+			}
+			fmt.Printf("  ✓ Share %d/%d → %s\n", s.X, n, out)
+		}
+		fmt.Printf("Success! %d shares exported (any %d recover the password).\n", n, k)
+		return nil
+	})
+}
+
+// encodeSecretShare renders a share as "7zkpxc-sss1:<id>:<k>:<x>:<hex>:<crc32>".
+// The checksum covers everything before it.
+func encodeSecretShare(id string, k int, s shamir.Share) string {
+	body := fmt.Sprintf("%s:%s:%d:%d:%s", sssPrefix, id, k, s.X, hex.EncodeToString(s.Y))
+	return fmt.Sprintf("%s:%08x", body, crc32.ChecksumIEEE([]byte(body)))
+}
+
+// decodeSecretShare parses and checksums a share produced by encodeSecretShare.
+// Whitespace anywhere in the text is ignored so shares can be typed in groups.
+func decodeSecretShare(text string) (id string, k int, s shamir.Share, err error) {
+	text = strings.Join(strings.Fields(text), "")
+	i := strings.LastIndexByte(text, ':')
+	if i < 0 {
+		return "", 0, s, fmt.Errorf("not a 7zkpxc share")
+	}
+	body, sum := text[:i], text[i+1:]
+
+	parts := strings.Split(body, ":")
+	if len(parts) != 5 || parts[0] != sssPrefix {
+		return "", 0, s, fmt.Errorf("not a 7zkpxc share")
+	}
+	if want := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body))); !strings.EqualFold(sum, want) {
+		return "", 0, s, fmt.Errorf("checksum mismatch — the share was mistyped or damaged")
+	}
+
+	k, err = strconv.Atoi(parts[2])
+	if err != nil || k < 2 {
+		return "", 0, s, fmt.Errorf("invalid threshold %q", parts[2])
+	}
+	x, err := strconv.Atoi(parts[3])
+	if err != nil || x < 1 || x > 255 {
+		return "", 0, s, fmt.Errorf("invalid share number %q", parts[3])
+	}
+	y, err := hex.DecodeString(parts[4])
+	if err != nil || len(y) == 0 {
+		return "", 0, s, fmt.Errorf("invalid share data")
+	}
+	return parts[1], k, shamir.Share{X: byte(x), Y: y}, nil
+}
+
+// readSecretShares prompts for shares on the terminal (without echo) until
+// the threshold recorded in the first share is reached, then recombines them.
+func readSecretShares() ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("--shares requires an interactive terminal")
+	}
+
+	var (
+		shares []shamir.Share
+		id     string
+		k      int
+	)
+	defer func() {
+		for _, s := range shares {
+			clear(s.Y)
+		}
+	}()
+
+	for k == 0 || len(shares) < k {
+		if k == 0 {
+			fmt.Fprintf(os.Stderr, "Share 1: ")
+		} else {
+			fmt.Fprintf(os.Stderr, "Share %d of %d: ", len(shares)+1, k)
+		}
+		line, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read share: %w", err)
+		}
+		if strings.TrimSpace(string(line)) == "" {
+			return nil, fmt.Errorf("aborted: empty share")
+		}
+
+		shareID, shareK, s, err := decodeSecretShare(string(line))
+		clear(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ✗ %v — try again\n", err)
+			continue
+		}
+		if k != 0 && (shareID != id || shareK != k) {
+			clear(s.Y)
+			fmt.Fprintf(os.Stderr, "  ✗ share belongs to a different split (%s, %d needed) — try again\n", shareID, shareK)
+			continue
+		}
+		duplicate := false
+		for _, prev := range shares {
+			duplicate = duplicate || prev.X == s.X
+		}
+		if duplicate {
+			clear(s.Y)
+			fmt.Fprintf(os.Stderr, "  ✗ share %d was already entered — try again\n", s.X)
+			continue
+		}
+
+		id, k = shareID, shareK
+		shares = append(shares, s)
+		fmt.Fprintf(os.Stderr, "  ✓ share %d accepted\n", s.X)
+	}
+
+	return shamir.Combine(shares)
+}
+
+// withSecretShares runs op with a password recombined from Shamir shares
+// typed on the terminal. KeePassXC is not used (kp is nil, entryPath empty).
+func withSecretShares(archivePath string, op ArchiveOp) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		absPath = archivePath
+	}
+	if err := ensureArchiveExists(absPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Enter the Shamir shares for '%s' (input is hidden).\n", archivePath)
+	password, err := readSecretShares()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	return op(cfg, nil, password, "")
+}
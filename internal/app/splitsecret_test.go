@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lxstig/7zkpxc/internal/shamir"
+)
+
+func TestSecretShare_RoundTrip(t *testing.T) {
+	secret := []byte("archive-password-123")
+	shares, err := shamir.Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []shamir.Share
+	for _, i := range []int{4, 0, 2} {
+		text := encodeSecretShare("a3b2c1d0", 3, shares[i])
+		if !strings.HasPrefix(text, sssPrefix+":a3b2c1d0:3:") {
+			t.Errorf("unexpected encoding %q", text)
+		}
+		id, k, s, err := decodeSecretShare(text)
+		if err != nil {
+			t.Fatalf("decodeSecretShare(%q): %v", text, err)
+		}
+		if id != "a3b2c1d0" || k != 3 || s.X != shares[i].X {
+			t.Errorf("decoded id=%q k=%d x=%d", id, k, s.X)
+		}
+		decoded = append(decoded, s)
+	}
+
+	got, err := shamir.Combine(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("recombined %q, want %q", got, secret)
+	}
+}
+
+func TestDecodeSecretShare_IgnoresWhitespace(t *testing.T) {
+	text := encodeSecretShare("a3b2c1d0", 2, shamir.Share{X: 7, Y: []byte{1, 2, 3, 4, 5, 6}})
+	spaced := text[:20] + " " + text[20:30] + "\n  " + text[30:]
+	if _, _, s, err := decodeSecretShare(spaced); err != nil || s.X != 7 {
+		t.Errorf("decodeSecretShare with whitespace: x=%d err=%v", s.X, err)
+	}
+}
+
+func TestDecodeSecretShare_Invalid(t *testing.T) {
+	valid := encodeSecretShare("a3b2c1d0", 3, shamir.Share{X: 1, Y: []byte{0xab, 0xcd}})
+
+	// Flip one hex digit of the share data
+	i := strings.Index(valid, ":abcd:") + 1
+	typo := valid[:i] + "b" + valid[i+1:]
+
+	tests := []struct {
+		name, text, want string
+	}{
+		{"garbage", "hello", "not a 7zkpxc share"},
+		{"wrong prefix", strings.Replace(valid, sssPrefix, "other-sss1", 1), "not a 7zkpxc share"},
+		{"typo", typo, "checksum mismatch"},
+		{"bad checksum", valid[:len(valid)-1] + "0", "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeSecretShare(tt.text)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// hardLinkKey returns the inode of a file with more than one link.
+func hardLinkKey(info fs.FileInfo) (fileKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink < 2 {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: st.Ino}, true
+}
+
+// readXattrs returns the extended attributes of p (POSIX ACLs included).
+// Filesystems without extended attributes yield none.
+func readXattrs(p string) (map[string]string, error) {
+	size, err := syscall.Listxattr(p, nil)
+	if errors.Is(err, syscall.ENOTSUP) || size == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]byte, size)
+	if size, err = syscall.Listxattr(p, names); err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string)
+	for _, name := range bytes.Split(names[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		n, err := syscall.Getxattr(p, string(name), nil)
+		if err != nil {
+			return nil, err
+		}
+		val := make([]byte, n)
+		if n, err = syscall.Getxattr(p, string(name), val); err != nil {
+			return nil, err
+		}
+		attrs[string(name)] = string(val[:n])
+	}
+	return attrs, nil
+}
+
+// setXattr sets one extended attribute of p.
+func setXattr(p, name, value string) error {
+	return syscall.Setxattr(p, name, []byte(value), 0)
+}
+
+// makeDevice creates the character device, block device or FIFO of hdr.
+func makeDevice(p string, hdr *tar.Header) error {
+	mode := uint32(hdr.Mode & 0o7777)
+	switch hdr.Typeflag {
+	case tar.TypeChar:
+		mode |= syscall.S_IFCHR
+	case tar.TypeBlock:
+		mode |= syscall.S_IFBLK
+	default:
+		mode |= syscall.S_IFIFO
+	}
+	return syscall.Mknod(p, mode, int(mkdev(hdr.Devmajor, hdr.Devminor)))
+}
+
+// mkdev encodes a device number like glibc's makedev.
+func mkdev(major, minor int64) uint64 {
+	ma, mi := uint64(major), uint64(minor)
+	return (mi & 0xff) | (ma&0xfff)<<8 | (mi&^0xff)<<12 | (ma&^0xfff)<<32
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package app
+
+import (
+	"archive/tar"
+	"errors"
+	"io/fs"
+)
+
+// hardLinkKey would identify multiply linked files; outside Linux every file
+// is stored in full.
+func hardLinkKey(info fs.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
+
+// readXattrs returns no extended attributes outside Linux.
+func readXattrs(p string) (map[string]string, error) {
+	return nil, nil
+}
+
+// setXattr is not supported outside Linux.
+func setXattr(p, name, value string) error {
+	return errors.ErrUnsupported
+}
+
+// makeDevice is not supported outside Linux.
+func makeDevice(p string, hdr *tar.Header) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,681 @@
+package app
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+)
+
+// tarPayload is EntryMetadata.Payload for archives holding one tar stream
+// ('a --tar'); 'x' unpacks those through extractTar.
+const tarPayload = "tar"
+
+// paxXattrPrefix marks extended attributes (POSIX ACLs included, as
+// system.posix_acl_*) in PAX records, as GNU tar and bsdtar write them.
+const paxXattrPrefix = "SCHILY.xattr."
+
+// fileKey identifies an inode, so that hard links are stored once.
+type fileKey struct{ dev, ino uint64 }
+
+// tarMemberName is the name of the tar stream inside an archive:
+// "backup.7z" holds "backup.tar".
+func tarMemberName(archivePath string) string {
+	base := filepath.Base(archivePath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".tar"
+}
+
+// reproducibleSwitches make 7z's output depend on its input only: one thread
+// (LZMA2 splits blocks per thread), solid mode, and no timestamps for the
+// stdin member, which would otherwise get the current time.
+var reproducibleSwitches = []string{"-mmt=1", "-ms=on", "-mtm=off", "-mtc=off", "-mta=off"}
+
+// tarWriteOptions controls writeTar.
+type tarWriteOptions struct {
+	Reproducible bool      // sorted entries with normalized metadata (normalizeTarHeader)
+	Epoch        time.Time // modification time of every entry when Reproducible
+}
+
+// tarPack is what packTar learns while streaming: the files inside the tar
+// and the SHA-256 of the stream.
+type tarPack struct {
+	Manifest archiveManifest
+	SHA256   string
+}
+
+// packTar returns a packFunc that streams a tar of items into "7z a -si"
+// (sevenZipArgs plus "-p", the archive and extraFlags) and fills out. A tar
+// or 7z failure removes the partial archive and all of its volumes.
+func packTar(binaryPath string, sevenZipArgs []string, archiveName string, items []sourceFile, opts tarWriteOptions, extraFlags []string, out *tarPack) packFunc {
+	return func(password []byte) (err error) {
+		absArchive, err := filepath.Abs(archiveName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				_ = removeArchiveVolumes(absArchive)
+			}
+		}()
+
+		args := append([]string{}, sevenZipArgs...)
+		args = append(args, "-p", "-si"+tarMemberName(absArchive), absArchive)
+		args = append(args, extraFlags...)
+
+		pr, pw := io.Pipe()
+		tarDone := make(chan error, 1)
+		go func() {
+			h := sha256.New()
+			m, err := writeTar(io.MultiWriter(pw, h), items, opts)
+			*out = tarPack{Manifest: m, SHA256: hex.EncodeToString(h.Sum(nil))}
+			_ = pw.CloseWithError(err)
+			tarDone <- err
+		}()
+
+		runErr := sevenzip.StreamIn(binaryPath, password, args, pr, os.Stdout)
+		// Unblock the tar writer if 7z stopped reading early.
+		_ = pr.CloseWithError(io.ErrClosedPipe)
+		if tarErr := <-tarDone; tarErr != nil && !errors.Is(tarErr, io.ErrClosedPipe) {
+			return fmt.Errorf("building tar stream: %w", tarErr)
+		}
+		return runErr
+	}
+}
+
+// tarBuilder writes files into a PAX tar, each path once.
+type tarBuilder struct {
+	tw       *tar.Writer
+	opts     tarWriteOptions
+	seen     map[string]bool
+	links    map[fileKey]string // first name stored for each multiply linked inode
+	manifest archiveManifest
+}
+
+// tarSource is a file on disk and its name in the tar.
+type tarSource struct {
+	path, name string
+}
+
+// writeTar writes items as a PAX tar to w and returns the manifest of its
+// regular files. Names follow 7z's: a verbatim source (Dir empty) is stored
+// under its base name, a walked or listed file under Rel, preceded by its
+// parent directories. Owners, modes, times, extended attributes and device
+// numbers are kept and hard links are stored as links; sparse files are
+// stored in full and sockets skipped. With opts.Reproducible, entries are
+// sorted by name and normalized, so equal trees give equal streams.
+func writeTar(w io.Writer, items []sourceFile, opts tarWriteOptions) (archiveManifest, error) {
+	b := &tarBuilder{
+		tw:       tar.NewWriter(w),
+		opts:     opts,
+		seen:     make(map[string]bool),
+		links:    make(map[fileKey]string),
+		manifest: archiveManifest{Version: manifestVersion, Files: []manifestFile{}},
+	}
+	var sources []tarSource
+	for _, it := range items {
+		found, err := tarSources(it)
+		if err != nil {
+			return b.manifest, err
+		}
+		sources = append(sources, found...)
+	}
+	if opts.Reproducible {
+		// A parent's name is a prefix of its children's, so it still comes first.
+		sort.SliceStable(sources, func(i, j int) bool { return sources[i].name < sources[j].name })
+	}
+	for _, src := range sources {
+		if err := b.add(src.path, src.name); err != nil {
+			return b.manifest, err
+		}
+	}
+	return b.manifest, b.tw.Close()
+}
+
+// tarSources lists the files of one item, each directory before its contents.
+func tarSources(it sourceFile) ([]tarSource, error) {
+	var out []tarSource
+	if it.Dir == "" {
+		for _, src := range expandSourceGlobs([]string{it.Rel}) {
+			abs, err := filepath.Abs(src)
+			if err != nil {
+				return nil, err
+			}
+			parent := filepath.Dir(abs)
+			err = filepath.WalkDir(abs, func(p string, _ fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(parent, p)
+				if err != nil {
+					return err
+				}
+				out = append(out, tarSource{p, filepath.ToSlash(rel)})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+
+	root := it.Dir
+	if filepath.IsAbs(it.Rel) {
+		root = "/"
+	}
+	name := tarStoredName(it.Rel)
+	if name == "" {
+		return nil, fmt.Errorf("cannot archive '%s' in a tar stream", it.Rel)
+	}
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		out = append(out, tarSource{filepath.Join(root, filepath.FromSlash(dir)), dir})
+	}
+	return append(out, tarSource{filepath.Join(it.Dir, filepath.FromSlash(it.Rel)), name}), nil
+}
+
+// tarStoredName turns a walked or listed path into a relative tar name,
+// dropping leading "/" and "../" like tar does; "" if nothing is left.
+func tarStoredName(rel string) string {
+	name := path.Clean(filepath.ToSlash(rel))
+	name = strings.TrimLeft(name, "/")
+	for name == ".." || strings.HasPrefix(name, "../") {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, ".."), "/")
+	}
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+// add writes the header (and data) of the file at p under name.
+func (b *tarBuilder) add(p, name string) error {
+	if b.seen[name] {
+		return nil
+	}
+	b.seen[name] = true
+
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket != 0 {
+		fmt.Fprintf(os.Stderr, "⚠ skipping socket '%s'\n", p)
+		return nil
+	}
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return fmt.Errorf("'%s': %w", p, err)
+	}
+	hdr.Format = tar.FormatPAX
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if info.Mode().IsRegular() {
+		if key, ok := hardLinkKey(info); ok {
+			if first, dup := b.links[key]; dup {
+				hdr.Typeflag = tar.TypeLink
+				hdr.Linkname = first
+				hdr.Size = 0
+			} else {
+				b.links[key] = name
+			}
+		}
+	}
+	if b.opts.Reproducible {
+		normalizeTarHeader(hdr, b.opts.Epoch)
+	} else if info.Mode()&fs.ModeSymlink == 0 {
+		xattrs, err := readXattrs(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ '%s': extended attributes not stored: %v\n", p, err)
+		}
+		for attr, val := range xattrs {
+			if hdr.PAXRecords == nil {
+				hdr.PAXRecords = make(map[string]string)
+			}
+			hdr.PAXRecords[paxXattrPrefix+attr] = val
+		}
+	}
+
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("'%s': %w", p, err)
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	h := crc32.NewIEEE()
+	if _, err := io.CopyN(b.tw, io.TeeReader(f, h), hdr.Size); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("'%s' shrank while it was being archived", p)
+		}
+		return err
+	}
+	b.manifest.Files = append(b.manifest.Files, manifestFile{
+		Path:     name,
+		Size:     hdr.Size,
+		Modified: hdr.ModTime,
+		CRC:      fmt.Sprintf("%08X", h.Sum32()),
+	})
+	return nil
+}
+
+// normalizeTarHeader strips what differs between two checkouts of the same
+// tree: times (all set to epoch), owners (root, no names), extended
+// attributes and permission details (0755 for directories and executables,
+// 0644 for other files).
+func normalizeTarHeader(hdr *tar.Header, epoch time.Time) {
+	hdr.ModTime = epoch
+	hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+	hdr.PAXRecords = nil
+	switch {
+	case hdr.Typeflag == tar.TypeSymlink:
+		hdr.Mode = 0o777
+	case hdr.Typeflag == tar.TypeDir || hdr.Mode&0o111 != 0:
+		hdr.Mode = 0o755
+	default:
+		hdr.Mode = 0o644
+	}
+}
+
+// sourceDateEpoch returns the time set in SOURCE_DATE_EPOCH (seconds since
+// 1970, the reproducible-builds convention), or the Unix epoch if unset.
+func sourceDateEpoch() (time.Time, error) {
+	val := os.Getenv("SOURCE_DATE_EPOCH")
+	if val == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	sec, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || sec < 0 {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: want seconds since 1970", val)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// tarDigest returns the SHA-256 of the tar writeTar builds from items,
+// without storing it anywhere.
+func tarDigest(items []sourceFile, opts tarWriteOptions) (string, error) {
+	h := sha256.New()
+	if _, err := writeTar(h, items, opts); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// tarExtractOptions controls how extractTar restores ownership.
+type tarExtractOptions struct {
+	SameOwner    bool // chown entries to their archived owner (default for root)
+	NumericOwner bool // use the archived uid/gid, not the user and group names
+}
+
+// tarExtractor unpacks one tar stream below dest.
+type tarExtractor struct {
+	dest     string
+	opts     tarExtractOptions
+	uids     map[string]int
+	gids     map[string]int
+	warnings int
+}
+
+// extractTar unpacks a tar stream into dest. No entry may leave dest, by its
+// name or through a symbolic link extracted before it. Directory modes and
+// times are applied last, so read-only directories can be filled first.
+// setuid/setgid bits are only kept with SameOwner. Attributes that cannot be
+// restored (owners without privileges, extended attributes the filesystem
+// rejects) are reported on stderr and counted in warnings.
+func extractTar(r io.Reader, dest string, opts tarExtractOptions) (entries, warnings int, err error) {
+	x := &tarExtractor{dest: dest, opts: opts, uids: make(map[string]int), gids: make(map[string]int)}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return 0, 0, err
+	}
+
+	type dirEntry struct {
+		target string
+		hdr    *tar.Header
+	}
+	var dirs []dirEntry
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, x.warnings, err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		name, err := sanitizeTarName(hdr.Name)
+		if err != nil {
+			return entries, x.warnings, err
+		}
+		if name == "." {
+			continue
+		}
+		target, err := x.target(name)
+		if err != nil {
+			return entries, x.warnings, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			// A symlink here would take the directory's attributes outside dest
+			if err := replaceable(target); err != nil {
+				return entries, x.warnings, err
+			}
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return entries, x.warnings, err
+			}
+			dirs = append(dirs, dirEntry{target, hdr})
+			entries++
+			continue
+		case tar.TypeReg:
+			err = x.writeFile(target, tr)
+		case tar.TypeSymlink:
+			if err = replaceable(target); err == nil {
+				err = os.Symlink(hdr.Linkname, target)
+			}
+		case tar.TypeLink:
+			var linkName, linkTarget string
+			if linkName, err = sanitizeTarName(hdr.Linkname); err == nil {
+				if linkTarget, err = x.target(linkName); err == nil {
+					if err = replaceable(target); err == nil {
+						err = os.Link(linkTarget, target)
+					}
+				}
+			}
+			if err != nil {
+				return entries, x.warnings, fmt.Errorf("'%s': %w", hdr.Name, err)
+			}
+			entries++
+			continue // shares the attributes of its target
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			if err = replaceable(target); err == nil {
+				err = makeDevice(target, hdr)
+			}
+			if err != nil {
+				x.warn(hdr.Name, "special file", err)
+				continue
+			}
+		default:
+			x.warn(hdr.Name, "entry", fmt.Errorf("unsupported type %q", hdr.Typeflag))
+			continue
+		}
+		if err != nil {
+			return entries, x.warnings, fmt.Errorf("'%s': %w", hdr.Name, err)
+		}
+		x.applyAttrs(target, hdr)
+		entries++
+	}
+
+	// Deepest first, so that a parent's time is set after its children.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		x.applyAttrs(dirs[i].target, dirs[i].hdr)
+	}
+	return entries, x.warnings, nil
+}
+
+// sanitizeTarName cleans an entry name, refusing absolute names and names
+// that climb out of the target directory. "." is the directory itself.
+func sanitizeTarName(name string) (string, error) {
+	if path.IsAbs(name) {
+		return "", fmt.Errorf("refusing absolute path '%s' in tar stream", name)
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("refusing path '%s' outside the target directory", name)
+	}
+	return clean, nil
+}
+
+// target returns where name goes below dest, creating missing parent
+// directories. Existing parents must not be symbolic links.
+func (x *tarExtractor) target(name string) (string, error) {
+	dir := x.dest
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("refusing to extract '%s' through symbolic link '%s'", name, dir)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("cannot extract '%s': '%s' is not a directory", name, dir)
+		}
+	}
+	return filepath.Join(x.dest, filepath.FromSlash(name)), nil
+}
+
+// replaceable removes a non-directory at target, so that an extracted entry
+// replaces it instead of writing through it.
+func replaceable(target string) error {
+	info, err := os.Lstat(target)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+	return os.Remove(target)
+}
+
+func (x *tarExtractor) writeFile(target string, r io.Reader) error {
+	if err := replaceable(target); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// applyAttrs restores owner, extended attributes, mode and times, in that
+// order: chown clears setuid bits, and a read-only mode would block the
+// extended attributes.
+func (x *tarExtractor) applyAttrs(target string, hdr *tar.Header) {
+	if x.opts.SameOwner {
+		if err := os.Lchown(target, x.uid(hdr), x.gid(hdr)); err != nil {
+			x.warn(hdr.Name, "owner", err)
+		}
+	}
+	if hdr.Typeflag == tar.TypeSymlink {
+		return
+	}
+	for key, val := range hdr.PAXRecords {
+		if attr, ok := strings.CutPrefix(key, paxXattrPrefix); ok {
+			if err := setXattr(target, attr, val); err != nil {
+				x.warn(hdr.Name, "attribute "+attr, err)
+			}
+		}
+	}
+	if err := os.Chmod(target, tarFileMode(hdr.Mode, x.opts.SameOwner)); err != nil {
+		x.warn(hdr.Name, "permissions", err)
+	}
+	atime := hdr.AccessTime
+	if atime.IsZero() {
+		atime = hdr.ModTime
+	}
+	if err := os.Chtimes(target, atime, hdr.ModTime); err != nil {
+		x.warn(hdr.Name, "times", err)
+	}
+}
+
+func (x *tarExtractor) warn(name, what string, err error) {
+	fmt.Fprintf(os.Stderr, "⚠ %s: cannot restore %s: %v\n", name, what, err)
+	x.warnings++
+}
+
+// uid returns the owner to restore: the local user of the archived name
+// unless NumericOwner is set or the name is unknown, else the archived uid.
+func (x *tarExtractor) uid(hdr *tar.Header) int {
+	if x.opts.NumericOwner || hdr.Uname == "" {
+		return hdr.Uid
+	}
+	if id, ok := x.uids[hdr.Uname]; ok {
+		return id
+	}
+	id := hdr.Uid
+	if u, err := user.Lookup(hdr.Uname); err == nil {
+		if n, err := strconv.Atoi(u.Uid); err == nil {
+			id = n
+		}
+	}
+	x.uids[hdr.Uname] = id
+	return id
+}
+
+// gid is uid for the group.
+func (x *tarExtractor) gid(hdr *tar.Header) int {
+	if x.opts.NumericOwner || hdr.Gname == "" {
+		return hdr.Gid
+	}
+	if id, ok := x.gids[hdr.Gname]; ok {
+		return id
+	}
+	id := hdr.Gid
+	if g, err := user.LookupGroup(hdr.Gname); err == nil {
+		if n, err := strconv.Atoi(g.Gid); err == nil {
+			id = n
+		}
+	}
+	x.gids[hdr.Gname] = id
+	return id
+}
+
+// tarFileMode converts tar mode bits; setuid and setgid are only kept with
+// keepSetID, as tar does for ordinary users.
+func tarFileMode(mode int64, keepSetID bool) fs.FileMode {
+	m := fs.FileMode(mode & 0o777)
+	if keepSetID && mode&0o4000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if keepSetID && mode&0o2000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if mode&0o1000 != 0 {
+		m |= fs.ModeSticky
+	}
+	return m
+}
+
+// extractTarPayload streams the tar inside archivePath out of 7z ("x -so")
+// and unpacks it into dest.
+func extractTarPayload(binaryPath string, password []byte, archivePath, dest string, opts tarExtractOptions) error {
+	absArchive, err := filepath.Abs(archivePath)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	type result struct {
+		entries, warnings int
+		err               error
+	}
+	done := make(chan result, 1)
+	go func() {
+		entries, warnings, err := extractTar(pr, dest, opts)
+		if err == nil {
+			// Drain the tar's end padding so 7z can finish.
+			_, err = io.Copy(io.Discard, pr)
+		}
+		_ = pr.CloseWithError(err)
+		done <- result{entries, warnings, err}
+	}()
+
+	start := time.Now()
+	streamErr := sevenzip.Stream(binaryPath, password, []string{"x", "-so", "-y", absArchive}, pw, nil)
+	_ = pw.CloseWithError(streamErr)
+	res := <-done
+	// A tar error that is not just 7z's failure seen through the pipe comes
+	// first: it is why 7z lost its reader.
+	if res.err != nil && (streamErr == nil || !errors.Is(res.err, streamErr)) {
+		return fmt.Errorf("unpacking tar stream: %w", res.err)
+	}
+	if streamErr != nil {
+		return fmt.Errorf("extraction failed: %w", streamErr)
+	}
+
+	fmt.Printf("Unpacked %d entries into '%s' in %s.\n", res.entries, dest, time.Since(start).Round(time.Millisecond))
+	if res.warnings > 0 {
+		fmt.Printf("⚠ %d attribute(s) could not be restored (see above).\n", res.warnings)
+	}
+	return nil
+}
+
+// recordTarPayload marks the entry's archive as a tar stream (with its
+// digest and, if reproducible, the epoch) and replaces its manifest (which
+// lists only the .tar) with the files inside the tar. Non-fatal, like
+// updateMetadata, but a missing mark is reported: 'x' would then extract the
+// .tar itself.
+func recordTarPayload(kp backupStore, entryPath string, p tarPack, opts tarWriteOptions) {
+	notes, _ := kp.GetAttribute(entryPath, "Notes")
+	meta := parseMetadata(notes)
+	meta.Payload = tarPayload
+	meta.SHA256 = p.SHA256
+	if opts.Reproducible {
+		meta.Reproducible, meta.Epoch = true, opts.Epoch.Unix()
+	}
+	if err := kp.UpdateEntryNotes(entryPath, mergeMetadataIntoNotes(notes, meta)); err != nil {
+		fmt.Printf("Warning: could not mark the entry as a tar archive — 'x' will extract the .tar file: %v\n", err)
+	}
+
+	data, err := encodeManifest(p.Manifest)
+	if err == nil {
+		err = kp.ImportAttachment(entryPath, manifestAttachment, data)
+	}
+	if err != nil {
+		fmt.Printf("Note: could not update the file manifest: %v\n", err)
+	}
+}
+
+// refuseTarPayload stops commands that change the members of an archive
+// holding a tar stream: 'x' could no longer unpack it as one.
+func refuseTarPayload(kp PasswordProvider, entryPath, archivePath string) error {
+	notes, _ := kp.GetAttribute(entryPath, "Notes")
+	if parseMetadata(notes).Payload == tarPayload {
+		return fmt.Errorf("'%s' holds a tar stream ('a --tar') and cannot be changed in place — create a new archive instead", filepath.Base(archivePath))
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// tarNames lists the entry names of a tar stream.
+func tarNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+	}
+	return names
+}
+
+// buildTar writes hdrs (with body as the data of regular files) to a tar.
+func buildTar(t *testing.T, hdrs ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range hdrs {
+		body := ""
+		if h.Typeflag == tar.TypeReg {
+			body = "data"
+			h.Size = int64(len(body))
+		}
+		if h.Mode == 0 {
+			h.Mode = 0o644
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteTar_Roundtrip(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	makeTree(t, src, "sub/a.txt")
+	if err := os.Chmod(filepath.Join(src, "sub", "a.txt"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(src, "sub", "a.txt"), filepath.Join(src, "hard.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(src, "sub"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "sub", "a.txt"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	manifest, err := writeTar(&buf, []sourceFile{{Rel: src}}, tarWriteOptions{})
+	if err != nil {
+		t.Fatalf("writeTar: %v", err)
+	}
+	if len(manifest.Files) != 1 {
+		t.Fatalf("manifest = %+v, want the one regular file (the hard link is a link)", manifest.Files)
+	}
+	if f := manifest.Files[0]; f.Size != 1 || f.CRC != "8CDC1683" || !f.Modified.Equal(mtime) {
+		t.Errorf("manifest file = %+v", f)
+	}
+
+	dest := t.TempDir()
+	entries, warnings, err := extractTar(&buf, dest, tarExtractOptions{})
+	if err != nil {
+		t.Fatalf("extractTar: %v", err)
+	}
+	if entries != 5 || warnings != 0 {
+		t.Errorf("entries, warnings = %d, %d; want 5, 0", entries, warnings)
+	}
+
+	out := filepath.Join(dest, "src")
+	if data, _ := os.ReadFile(filepath.Join(out, "sub", "a.txt")); string(data) != "x" {
+		t.Errorf("content = %q", data)
+	}
+	info, err := os.Stat(filepath.Join(out, "sub", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 || !info.ModTime().Equal(mtime) {
+		t.Errorf("mode, mtime = %v, %v", info.Mode().Perm(), info.ModTime())
+	}
+	if dir, _ := os.Stat(filepath.Join(out, "sub")); dir == nil || dir.Mode().Perm() != 0o750 {
+		t.Errorf("directory mode not restored: %v", dir)
+	}
+	if target, _ := os.Readlink(filepath.Join(out, "link")); target != "sub/a.txt" {
+		t.Errorf("symlink target = %q", target)
+	}
+	hard, err := os.Stat(filepath.Join(out, "hard.txt"))
+	if err != nil || !os.SameFile(info, hard) {
+		t.Errorf("hard link not restored as a link (err %v)", err)
+	}
+}
+
+func TestWriteTar_WalkedItemsHaveParents(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/b/c.txt", "a/d.txt")
+
+	var buf bytes.Buffer
+	items := []sourceFile{{Dir: root, Rel: "a/b/c.txt"}, {Dir: root, Rel: "a/d.txt"}}
+	if _, err := writeTar(&buf, items, tarWriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(tarNames(t, buf.Bytes()), ",")
+	if want := "a/,a/b/,a/b/c.txt,a/d.txt"; got != want {
+		t.Errorf("names = %s, want %s", got, want)
+	}
+}
+
+func TestWriteTar_Xattrs(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "f.txt")
+	src := filepath.Join(dir, "f.txt")
+	if err := setXattr(src, "user.test", "value"); err != nil {
+		t.Skipf("extended attributes not supported here: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := writeTar(&buf, []sourceFile{{Rel: src}}, tarWriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	if _, warnings, err := extractTar(&buf, dest, tarExtractOptions{}); err != nil || warnings != 0 {
+		t.Fatalf("extractTar: %v (%d warnings)", err, warnings)
+	}
+	attrs, err := readXattrs(filepath.Join(dest, "f.txt"))
+	if err != nil || attrs["user.test"] != "value" {
+		t.Errorf("xattrs = %v (err %v)", attrs, err)
+	}
+}
+
+func TestExtractTar_RejectsUnsafeNames(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "/etc/evil.txt", "a/../../evil.txt"} {
+		data := buildTar(t, &tar.Header{Name: name, Typeflag: tar.TypeReg})
+		dest := filepath.Join(t.TempDir(), "dest")
+		if _, _, err := extractTar(bytes.NewReader(data), dest, tarExtractOptions{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); err == nil {
+			t.Errorf("%s: file written outside the target", name)
+		}
+	}
+}
+
+func TestExtractTar_RefusesSymlinkParent(t *testing.T) {
+	outside := t.TempDir()
+	data := buildTar(t,
+		&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: outside},
+		&tar.Header{Name: "link/evil.txt", Typeflag: tar.TypeReg},
+	)
+	_, _, err := extractTar(bytes.NewReader(data), t.TempDir(), tarExtractOptions{})
+	if err == nil || !strings.Contains(err.Error(), "symbolic link") {
+		t.Errorf("expected a symlink error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+		t.Error("file written through the symlink")
+	}
+}
+
+func TestExtractTar_DirReplacesSymlink(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.Chmod(outside, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "d")); err != nil {
+		t.Fatal(err)
+	}
+	data := buildTar(t, &tar.Header{Name: "d/", Typeflag: tar.TypeDir, Mode: 0o555})
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(dest, "d"), 0o755) })
+	if _, _, err := extractTar(bytes.NewReader(data), dest, tarExtractOptions{}); err != nil {
+		t.Fatalf("extractTar: %v", err)
+	}
+	if info, err := os.Lstat(filepath.Join(dest, "d")); err != nil || !info.IsDir() {
+		t.Errorf("symlink not replaced by a directory: %v", info)
+	}
+	if info, _ := os.Stat(outside); info == nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("mode applied through the symlink: %v", info)
+	}
+}
+
+func TestExtractTar_FifoAndReadOnlyDir(t *testing.T) {
+	data := buildTar(t,
+		&tar.Header{Name: "ro/", Typeflag: tar.TypeDir, Mode: 0o555},
+		&tar.Header{Name: "ro/file", Typeflag: tar.TypeReg},
+		&tar.Header{Name: "pipe", Typeflag: tar.TypeFifo, Mode: 0o600},
+	)
+	dest := t.TempDir()
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(dest, "ro"), 0o755) })
+	if _, _, err := extractTar(bytes.NewReader(data), dest, tarExtractOptions{}); err != nil {
+		t.Fatalf("extractTar: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "ro", "file")); string(data) != "data" {
+		t.Errorf("file in read-only directory = %q", data)
+	}
+	if info, _ := os.Stat(filepath.Join(dest, "ro")); info == nil || info.Mode().Perm() != 0o555 {
+		t.Errorf("read-only directory mode not applied: %v", info)
+	}
+	if info, err := os.Lstat(filepath.Join(dest, "pipe")); err == nil && info.Mode()&fs.ModeNamedPipe == 0 {
+		t.Errorf("pipe mode = %v", info.Mode())
+	}
+}
+
+func TestExtractTar_SameOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root to chown")
+	}
+	data := buildTar(t, &tar.Header{Name: "f", Typeflag: tar.TypeReg, Uid: 1234, Gid: 4321, Uname: "no-such-user-7zkpxc"})
+	dest := t.TempDir()
+	if _, _, err := extractTar(bytes.NewReader(data), dest, tarExtractOptions{SameOwner: true}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(filepath.Join(dest, "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	if st.Uid != 1234 || st.Gid != 4321 {
+		t.Errorf("owner = %d:%d, want the archived 1234:4321 (unknown names fall back to ids)", st.Uid, st.Gid)
+	}
+}
+
+func TestTarFileMode(t *testing.T) {
+	if got := tarFileMode(0o4755, false); got != 0o755 {
+		t.Errorf("without keepSetID: %v", got)
+	}
+	if got := tarFileMode(0o6755, true); got != 0o755|fs.ModeSetuid|fs.ModeSetgid {
+		t.Errorf("with keepSetID: %v", got)
+	}
+	if got := tarFileMode(0o1777, false); got != 0o777|fs.ModeSticky {
+		t.Errorf("sticky: %v", got)
+	}
+}
+
+func TestTarStoredName(t *testing.T) {
+	tests := map[string]string{
+		"a/b.txt":     "a/b.txt",
+		"/etc/passwd": "etc/passwd",
+		"../../x":     "x",
+		"./a/./b":     "a/b",
+		".":           "",
+	}
+	for in, want := range tests {
+		if got := tarStoredName(in); got != want {
+			t.Errorf("tarStoredName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTarMemberName(t *testing.T) {
+	if got := tarMemberName("/backups/rootfs.7z"); got != "rootfs.tar" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRecordTarPayload(t *testing.T) {
+	store := &fakeBackupStore{MockPasswordProvider: NewMockPasswordProvider(), files: map[string][]byte{}}
+	entry := "7zkpxc/rootfs.7z (a3b2c1d0)"
+	store.attributes[entry] = map[string]string{"Notes": "mine\n[7zkpxc]\nsize=10\n"}
+
+	if err := refuseTarPayload(store, entry, "rootfs.7z"); err != nil {
+		t.Fatalf("unmarked archive refused: %v", err)
+	}
+	packed := tarPack{
+		Manifest: archiveManifest{Version: manifestVersion, Files: []manifestFile{{Path: "rootfs/etc/hosts", Size: 3}}},
+		SHA256:   "ab12",
+	}
+	recordTarPayload(store, entry, packed, tarWriteOptions{Reproducible: true, Epoch: time.Unix(1700000000, 0)})
+
+	notes := store.attributes[entry]["Notes"]
+	meta := parseMetadata(notes)
+	if meta.Payload != tarPayload || meta.Size != 10 || !strings.HasPrefix(notes, "mine\n") {
+		t.Errorf("notes = %q", notes)
+	}
+	if meta.SHA256 != "ab12" || !meta.Reproducible || meta.Epoch != 1700000000 {
+		t.Errorf("digest, reproducible, epoch = %q, %v, %d", meta.SHA256, meta.Reproducible, meta.Epoch)
+	}
+	states, err := manifestStates(store, entry)
+	if err != nil || len(states) != 1 || states["rootfs/etc/hosts"].Size != 3 {
+		t.Errorf("manifest states = %+v (err %v)", states, err)
+	}
+	if err := refuseTarPayload(store, entry, "rootfs.7z"); err == nil {
+		t.Error("tar archive should be refused")
+	}
+}
+
+// reproducibleTree creates the same files below root with the given mode
+// and mtime, in the given order.
+func reproducibleTree(t *testing.T, root string, mode fs.FileMode, mtime time.Time, files ...string) {
+	t.Helper()
+	makeTree(t, root, files...)
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteTar_Reproducible(t *testing.T) {
+	epoch := time.Unix(1700000000, 0).UTC()
+	opts := tarWriteOptions{Reproducible: true, Epoch: epoch}
+
+	one := filepath.Join(t.TempDir(), "src")
+	reproducibleTree(t, one, 0o600, time.Now(), "b/y.txt", "a.txt", "b/x.txt")
+	two := filepath.Join(t.TempDir(), "src")
+	reproducibleTree(t, two, 0o664, time.Unix(1, 0), "b/x.txt", "a.txt", "b/y.txt")
+
+	d1, err := tarDigest([]sourceFile{{Rel: one}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := tarDigest([]sourceFile{{Rel: two}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d1 != d2 {
+		t.Errorf("equal trees gave different digests %s and %s", d1, d2)
+	}
+	if d3, _ := tarDigest([]sourceFile{{Rel: one}}, tarWriteOptions{Reproducible: true}); d3 == d1 {
+		t.Error("the epoch should change the digest")
+	}
+
+	var buf bytes.Buffer
+	if _, err := writeTar(&buf, []sourceFile{{Rel: one}}, opts); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&buf)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		if !hdr.ModTime.Equal(epoch) || hdr.Uid != 0 || hdr.Uname != "" || len(hdr.PAXRecords) != 0 {
+			t.Errorf("%s not normalized: %+v", hdr.Name, hdr)
+		}
+		if want := int64(0o644); hdr.Typeflag == tar.TypeReg && hdr.Mode != want {
+			t.Errorf("%s mode = %o, want %o", hdr.Name, hdr.Mode, want)
+		}
+	}
+	if got := strings.Join(names, ","); got != "src/,src/a.txt,src/b/,src/b/x.txt,src/b/y.txt" {
+		t.Errorf("names = %s", got)
+	}
+}
+
+func TestNormalizeTarHeader_Modes(t *testing.T) {
+	tests := []struct {
+		typ  byte
+		mode int64
+		want int64
+	}{
+		{tar.TypeReg, 0o700, 0o755},
+		{tar.TypeReg, 0o4600, 0o644},
+		{tar.TypeDir, 0o700, 0o755},
+		{tar.TypeSymlink, 0o755, 0o777},
+	}
+	for _, tt := range tests {
+		hdr := &tar.Header{Typeflag: tt.typ, Mode: tt.mode, Uid: 1000, Gname: "staff"}
+		normalizeTarHeader(hdr, time.Unix(0, 0))
+		if hdr.Mode != tt.want || hdr.Uid != 0 || hdr.Gname != "" {
+			t.Errorf("type %c mode %o: got mode %o, uid %d, gname %q", tt.typ, tt.mode, hdr.Mode, hdr.Uid, hdr.Gname)
+		}
+	}
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	if got, err := sourceDateEpoch(); err != nil || got.Unix() != 0 {
+		t.Errorf("unset: %v, %v", got, err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if got, err := sourceDateEpoch(); err != nil || got.Unix() != 1700000000 {
+		t.Errorf("set: %v, %v", got, err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := sourceDateEpoch(); err == nil {
+		t.Error("expected an error for a non-numeric value")
+	}
+}
@@ -0,0 +1,314 @@
+package app
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// -------------------------------------------------------------------
+// Foreign archive unpacking
+//
+// Used by 'convert' to turn plain ZIP and tarballs into managed 7z
+// archives. ZIP, tar, gzip and bzip2 are read with the standard library;
+// zstd and xz are piped through their command-line decompressors.
+// -------------------------------------------------------------------
+
+// sourceFormat describes a foreign archive type that can be converted.
+type sourceFormat struct {
+	Name         string // e.g. "zip", "tar.gz"
+	Ext          string // Lower-case suffix matched against the file name
+	Decompressor string // External decompressor for the tar stream, if any
+}
+
+// sourceFormats lists the supported inputs. Longer suffixes come first so
+// that ".tar.gz" wins over a hypothetical ".gz".
+var sourceFormats = []sourceFormat{
+	{Name: "tar.gz", Ext: ".tar.gz"},
+	{Name: "tar.gz", Ext: ".tgz"},
+	{Name: "tar.bz2", Ext: ".tar.bz2"},
+	{Name: "tar.bz2", Ext: ".tbz2"},
+	{Name: "tar.xz", Ext: ".tar.xz", Decompressor: "xz"},
+	{Name: "tar.xz", Ext: ".txz", Decompressor: "xz"},
+	{Name: "tar.zst", Ext: ".tar.zst", Decompressor: "zstd"},
+	{Name: "tar.zst", Ext: ".tzst", Decompressor: "zstd"},
+	{Name: "tar", Ext: ".tar"},
+	{Name: "zip", Ext: ".zip"},
+}
+
+// detectSourceFormat matches a file name against sourceFormats and returns
+// the format together with the name stripped of its archive suffix.
+func detectSourceFormat(name string) (sourceFormat, string, bool) {
+	base := filepath.Base(name)
+	lower := strings.ToLower(base)
+	for _, f := range sourceFormats {
+		if strings.HasSuffix(lower, f.Ext) && len(base) > len(f.Ext) {
+			return f, base[:len(base)-len(f.Ext)], true
+		}
+	}
+	return sourceFormat{}, "", false
+}
+
+// unpackResult summarises what was written by unpackSource.
+type unpackResult struct {
+	Files   map[string]int64 // Slash-separated relative path → size, regular files only
+	Skipped []string         // Entries that were not extracted (symlinks, devices, ...)
+}
+
+// unpackSource extracts src into destDir, preserving relative paths, file
+// modes and modification times.
+func unpackSource(src string, format sourceFormat, destDir string) (*unpackResult, error) {
+	u := &unpacker{
+		root:   destDir,
+		result: &unpackResult{Files: make(map[string]int64)},
+	}
+
+	var err error
+	if format.Name == "zip" {
+		err = u.unpackZip(src)
+	} else {
+		err = u.unpackTarFile(src, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.applyDirTimes()
+	return u.result, nil
+}
+
+type unpacker struct {
+	root     string
+	result   *unpackResult
+	dirTimes map[string]time.Time
+}
+
+// target resolves an archive member name to a path below the root.
+// Absolute names and names escaping the root via ".." are rejected.
+func (u *unpacker) target(name string) (rel, full string, err error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	rel = path.Clean(strings.TrimPrefix(name, "./"))
+	if rel == "." || rel == "" {
+		return "", "", nil
+	}
+	if path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", "", fmt.Errorf("refusing to extract '%s': path escapes the archive root", name)
+	}
+	return rel, filepath.Join(u.root, filepath.FromSlash(rel)), nil
+}
+
+func (u *unpacker) mkdir(rel, full string, mode os.FileMode, mtime time.Time) error {
+	if err := os.MkdirAll(full, 0o700); err != nil {
+		return fmt.Errorf("failed to create directory '%s': %w", rel, err)
+	}
+	_ = os.Chmod(full, mode.Perm()|0o700)
+	if u.dirTimes == nil {
+		u.dirTimes = make(map[string]time.Time)
+	}
+	u.dirTimes[full] = mtime
+	return nil
+}
+
+func (u *unpacker) writeFile(rel, full string, r io.Reader, mode os.FileMode, mtime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(full), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", rel, err)
+	}
+	f, err := os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create '%s': %w", rel, err)
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract '%s': %w", rel, err)
+	}
+	if err := os.Chtimes(full, mtime, mtime); err != nil {
+		return fmt.Errorf("failed to set modification time of '%s': %w", rel, err)
+	}
+	u.result.Files[rel] = n
+	return nil
+}
+
+// copyHardLink stores a hard link as a regular copy of the file it links to.
+// Links to anything but an already extracted regular file are skipped.
+func (u *unpacker) copyHardLink(rel, full string, hdr *tar.Header) error {
+	linkRel, linkFull, err := u.target(hdr.Linkname)
+	if err != nil {
+		return err
+	}
+	if _, ok := u.result.Files[linkRel]; !ok || linkRel == rel {
+		u.result.Skipped = append(u.result.Skipped, rel)
+		return nil
+	}
+	f, err := os.Open(linkFull)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s' for hard link '%s': %w", linkRel, rel, err)
+	}
+	defer func() { _ = f.Close() }()
+	return u.writeFile(rel, full, f, hdr.FileInfo().Mode(), hdr.ModTime)
+}
+
+// applyDirTimes sets directory mtimes last (deepest first), because creating
+// files inside a directory updates its mtime.
+func (u *unpacker) applyDirTimes() {
+	dirs := make([]string, 0, len(u.dirTimes))
+	for d := range u.dirTimes {
+		dirs = append(dirs, d)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, d := range dirs {
+		_ = os.Chtimes(d, u.dirTimes[d], u.dirTimes[d])
+	}
+}
+
+func (u *unpacker) unpackZip(src string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("failed to open ZIP archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	for _, f := range zr.File {
+		rel, full, err := u.target(f.Name)
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			continue
+		}
+		if f.Flags&0x1 != 0 {
+			return fmt.Errorf("'%s' is an encrypted ZIP — use '7zkpxc adopt' to store its password instead", filepath.Base(src))
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := u.mkdir(rel, full, mode, f.Modified); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("failed to read '%s': %w", rel, err)
+			}
+			err = u.writeFile(rel, full, rc, mode, f.Modified)
+			_ = rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			u.result.Skipped = append(u.result.Skipped, rel)
+		}
+	}
+	return nil
+}
+
+func (u *unpacker) unpackTarFile(src string, format sourceFormat) error {
+	if format.Decompressor != "" {
+		return u.unpackTarExternal(src, format.Decompressor)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	switch format.Name {
+	case "tar.gz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip stream: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	case "tar.bz2":
+		r = bzip2.NewReader(f)
+	}
+	return u.unpackTar(r)
+}
+
+// unpackTarExternal streams src through "<decompressor> -dc" into the tar reader.
+func (u *unpacker) unpackTarExternal(src, decompressor string) error {
+	bin, err := exec.LookPath(decompressor)
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH — install it to convert '%s'", decompressor, filepath.Base(src))
+	}
+
+	cmd := exec.Command(bin, "-dc", "--", src)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", decompressor, err)
+	}
+
+	tarErr := u.unpackTar(stdout)
+	// Drain so the decompressor is not killed by SIGPIPE on trailing padding
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	if tarErr != nil {
+		return tarErr
+	}
+	if waitErr != nil {
+		return fmt.Errorf("%s failed: %w (%s)", decompressor, waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (u *unpacker) unpackTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar stream: %w", err)
+		}
+
+		rel, full, err := u.target(hdr.Name)
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			continue
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := u.mkdir(rel, full, mode, hdr.ModTime); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := u.writeFile(rel, full, tr, mode, hdr.ModTime); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			if err := u.copyHardLink(rel, full, hdr); err != nil {
+				return err
+			}
+		default:
+			u.result.Skipped = append(u.result.Skipped, rel)
+		}
+	}
+}
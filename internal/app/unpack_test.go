@@ -0,0 +1,190 @@
+package app
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectSourceFormat(t *testing.T) {
+	tests := []struct {
+		name, format, stem string
+		ok                 bool
+	}{
+		{"photos.zip", "zip", "photos", true},
+		{"/in/Backup.TAR.GZ", "tar.gz", "Backup", true},
+		{"logs.tgz", "tar.gz", "logs", true},
+		{"src.tar.bz2", "tar.bz2", "src", true},
+		{"data.tar.zst", "tar.zst", "data", true},
+		{"data.tar.xz", "tar.xz", "data", true},
+		{"plain.tar", "tar", "plain", true},
+		{"archive.7z", "", "", false},
+		{"notes.gz", "", "", false},
+		{".zip", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, stem, ok := detectSourceFormat(tt.name)
+			if ok != tt.ok || f.Name != tt.format || stem != tt.stem {
+				t.Errorf("detectSourceFormat(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.name, f.Name, stem, ok, tt.format, tt.stem, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnpackSource_Zip(t *testing.T) {
+	mtime := time.Date(2020, 5, 17, 8, 30, 0, 0, time.UTC)
+	src := filepath.Join(t.TempDir(), "in.zip")
+
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{"a.txt": "hello", "sub/b.txt": "world!"} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: mtime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	dest := t.TempDir()
+	res, err := unpackSource(src, sourceFormat{Name: "zip"}, dest)
+	if err != nil {
+		t.Fatalf("unpackSource: %v", err)
+	}
+
+	if len(res.Files) != 2 || res.Files["a.txt"] != 5 || res.Files["sub/b.txt"] != 6 {
+		t.Errorf("unexpected manifest %v", res.Files)
+	}
+	info, err := os.Stat(filepath.Join(dest, "sub", "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+func TestUnpackSource_TarGz(t *testing.T) {
+	mtime := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	src := filepath.Join(t.TempDir(), "in.tar.gz")
+
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "./docs/", Typeflag: tar.TypeDir, Mode: 0o755, ModTime: mtime})
+	_ = tw.WriteHeader(&tar.Header{Name: "./docs/readme.md", Typeflag: tar.TypeReg, Mode: 0o644, Size: 3, ModTime: mtime})
+	_, _ = tw.Write([]byte("abc"))
+	_ = tw.WriteHeader(&tar.Header{Name: "docs/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd", ModTime: mtime})
+	_ = tw.Close()
+	_ = gz.Close()
+	_ = f.Close()
+
+	dest := t.TempDir()
+	format, _, _ := detectSourceFormat(src)
+	res, err := unpackSource(src, format, dest)
+	if err != nil {
+		t.Fatalf("unpackSource: %v", err)
+	}
+
+	if len(res.Files) != 1 || res.Files["docs/readme.md"] != 3 {
+		t.Errorf("unexpected manifest %v", res.Files)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0] != "docs/link" {
+		t.Errorf("expected symlink to be skipped, got %v", res.Skipped)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "docs", "link")); !os.IsNotExist(err) {
+		t.Error("symlink must not be created")
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "docs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("directory mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+func TestUnpackSource_HardLinkCopied(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "in.tar")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	_ = tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4})
+	_, _ = tw.Write([]byte("data"))
+	_ = tw.WriteHeader(&tar.Header{Name: "sub/b.txt", Typeflag: tar.TypeLink, Linkname: "a.txt", Mode: 0o644})
+	_ = tw.WriteHeader(&tar.Header{Name: "c.txt", Typeflag: tar.TypeLink, Linkname: "missing.txt", Mode: 0o644})
+	_ = tw.Close()
+	_ = f.Close()
+
+	dest := t.TempDir()
+	res, err := unpackSource(src, sourceFormat{Name: "tar"}, dest)
+	if err != nil {
+		t.Fatalf("unpackSource: %v", err)
+	}
+
+	if len(res.Files) != 2 || res.Files["sub/b.txt"] != 4 {
+		t.Errorf("unexpected manifest %v", res.Files)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dest, "sub", "b.txt")); string(got) != "data" {
+		t.Errorf("hard link copy = %q, want %q", got, "data")
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0] != "c.txt" {
+		t.Errorf("expected dangling hard link to be skipped, got %v", res.Skipped)
+	}
+}
+
+func TestUnpackSource_RejectsTraversal(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "evil.tar")
+
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	_ = tw.WriteHeader(&tar.Header{Name: "../../escape.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	_, _ = tw.Write([]byte("x"))
+	_ = tw.Close()
+	_ = f.Close()
+
+	dest := filepath.Join(t.TempDir(), "out")
+	_, err = unpackSource(src, sourceFormat{Name: "tar"}, dest)
+	if err == nil || !strings.Contains(err.Error(), "escapes the archive root") {
+		t.Fatalf("expected traversal error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "escape.txt")); !os.IsNotExist(err) {
+		t.Error("file escaped the destination directory")
+	}
+}
+
+func TestUnpackSource_MissingDecompressor(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	src := filepath.Join(t.TempDir(), "in.tar.zst")
+	if err := os.WriteFile(src, []byte("not really zstd"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	format, _, _ := detectSourceFormat(src)
+	_, err := unpackSource(src, format, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "zstd not found") {
+		t.Fatalf("expected missing decompressor error, got %v", err)
+	}
+}
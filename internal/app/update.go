@@ -2,9 +2,12 @@ package app
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/lxstig/7zkpxc/internal/config"
 	"github.com/lxstig/7zkpxc/internal/keepass"
@@ -13,9 +16,17 @@ import (
 )
 
 var updateCmd = &cobra.Command{
-	Use:     "u <archive_path> [files...]",
-	Short:   "Update files to archive",
-	Long:    `Updates files in an existing encrypted archive. Only newer files are added.`,
+	Use:   "u <archive_path> [files...]",
+	Short: "Update files to archive",
+	Long: `Updates files in an existing encrypted archive. Only newer files are added.
+
+With --mirror the archive is made to match the given sources exactly:
+members that no longer exist on disk, or are not below any source, are
+removed as well (7z -up0q0x2). --dry-run lists what would be added (+),
+updated (~) and removed (-) without touching the archive.
+
+  7zkpxc u --mirror --dry-run docs.7z ~/docs
+  7zkpxc u --mirror docs.7z ~/docs`,
 	Args:    cobra.MinimumNArgs(1),
 	RunE:    runUpdate,
 	GroupID: "actions",
@@ -25,6 +36,8 @@ func init() {
 	updateCmd.Flags().Bool("fast", false, "Fastest compression (-mx=1)")
 	updateCmd.Flags().Bool("best", false, "Best compression (-mx=9)")
 	updateCmd.MarkFlagsMutuallyExclusive("fast", "best")
+	updateCmd.Flags().Bool("mirror", false, "Also remove archive members that are not in the sources")
+	updateCmd.Flags().Bool("dry-run", false, "With --mirror, only print the planned changes")
 
 	updateCmd.FParseErrWhitelist.UnknownFlags = true
 	rootCmd.AddCommand(updateCmd)
@@ -38,6 +51,12 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		archiveName += ".7z"
 	}
 
+	mirror, _ := cmd.Flags().GetBool("mirror")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun && !mirror {
+		return fmt.Errorf("--dry-run requires --mirror")
+	}
+
 	var files, extraFlags []string
 	for _, arg := range args[1:] {
 		if strings.HasPrefix(arg, "-") {
@@ -65,9 +84,35 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	return withKeePassArchive(archiveName, false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+	if mirror && len(files) == 0 {
+		return fmt.Errorf("--mirror needs at least one source")
+	}
+
+	return withKeePassArchive(archiveName, dryRun, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		if err := refuseTarPayload(kp, entryPath, archiveName); err != nil {
+			return err
+		}
+		if dryRun {
+			entries, err := sevenzip.List(cfg.SevenZip.BinaryPath, password, archiveName)
+			if err != nil {
+				return fmt.Errorf("cannot list archive: %w", err)
+			}
+			disk, err := sourceStates(files)
+			if err != nil {
+				return err
+			}
+			printMirrorPlan(os.Stdout, planMirror(archiveStates(entries), disk))
+			fmt.Println("Dry run — archive not modified.")
+			return nil
+		}
+
 		fmt.Printf("Updating archive '%s'...\n", archiveName)
 		sevenZipArgs := []string{"u"}
+		if mirror {
+			// p0: drop members no source matches; q0: drop members missing on
+			// disk; x2: the disk copy wins even if the archived one is newer.
+			sevenZipArgs = append(sevenZipArgs, "-up0q0x2")
+		}
 
 		sevenZipArgs = append(sevenZipArgs, getCompressionFlags(cmd)...)
 		sevenZipArgs = append(sevenZipArgs, archiveName)
@@ -79,6 +124,76 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		}
 
 		fmt.Println("Archive updated successfully.")
+		refreshManifest(cfg, kp, password, entryPath, archiveName)
 		return nil
 	})
 }
+
+// mirrorPlan lists what 'u --mirror' changes, by archive path.
+type mirrorPlan struct {
+	Add, Update, Remove []string
+}
+
+// sourceStates returns the files below the update sources keyed like 7z
+// stores them (relative to each source's parent). Wildcards are expanded.
+func sourceStates(files []string) (map[string]fileState, error) {
+	var sources []string
+	for _, f := range files {
+		if !strings.ContainsAny(f, "*?[") {
+			sources = append(sources, f)
+			continue
+		}
+		matches, err := filepath.Glob(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", f, err)
+		}
+		sources = append(sources, matches...)
+	}
+	scanned, err := scanSources(sources, nil)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]fileState, len(scanned))
+	for rel, f := range scanned {
+		states[rel] = fileState{Size: f.Size, Modified: time.Unix(f.Mod, 0)}
+	}
+	return states, nil
+}
+
+// planMirror compares the archive with the disk the way 7z's update does:
+// a file present on both sides is updated when the mtimes differ (at
+// one-second precision). All lists are sorted.
+func planMirror(archive, disk map[string]fileState) mirrorPlan {
+	var plan mirrorPlan
+	for p, d := range disk {
+		a, ok := archive[p]
+		switch {
+		case !ok:
+			plan.Add = append(plan.Add, p)
+		case a.Modified.Unix() != d.Modified.Unix():
+			plan.Update = append(plan.Update, p)
+		}
+	}
+	for p := range archive {
+		if _, ok := disk[p]; !ok {
+			plan.Remove = append(plan.Remove, p)
+		}
+	}
+	sort.Strings(plan.Add)
+	sort.Strings(plan.Update)
+	sort.Strings(plan.Remove)
+	return plan
+}
+
+func printMirrorPlan(w io.Writer, plan mirrorPlan) {
+	for _, p := range plan.Add {
+		fmt.Fprintf(w, "+ %s\n", p)
+	}
+	for _, p := range plan.Update {
+		fmt.Fprintf(w, "~ %s\n", p)
+	}
+	for _, p := range plan.Remove {
+		fmt.Fprintf(w, "- %s\n", p)
+	}
+	fmt.Fprintf(w, "Mirror plan: %d to add, %d to update, %d to remove\n", len(plan.Add), len(plan.Update), len(plan.Remove))
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanMirror(t *testing.T) {
+	t0 := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	archive := map[string]fileState{
+		"docs/same.txt":    {Size: 1, Modified: t0},
+		"docs/subsec.txt":  {Size: 1, Modified: t0},
+		"docs/changed.txt": {Size: 1, Modified: t0},
+		"docs/gone.txt":    {Size: 1, Modified: t0},
+		"other/stray.txt":  {Size: 1, Modified: t0},
+	}
+	disk := map[string]fileState{
+		"docs/same.txt":    {Size: 1, Modified: t0},
+		"docs/subsec.txt":  {Size: 1, Modified: t0.Add(300 * time.Millisecond)},
+		"docs/changed.txt": {Size: 2, Modified: t0.Add(time.Minute)},
+		"docs/new.txt":     {Size: 1, Modified: t0},
+	}
+
+	plan := planMirror(archive, disk)
+	want := mirrorPlan{
+		Add:    []string{"docs/new.txt"},
+		Update: []string{"docs/changed.txt"},
+		Remove: []string{"docs/gone.txt", "other/stray.txt"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("plan = %+v, want %+v", plan, want)
+	}
+
+	var buf bytes.Buffer
+	printMirrorPlan(&buf, plan)
+	wantOut := "+ docs/new.txt\n~ docs/changed.txt\n- docs/gone.txt\n- other/stray.txt\nMirror plan: 1 to add, 1 to update, 2 to remove\n"
+	if buf.String() != wantOut {
+		t.Errorf("output = %q, want %q", buf.String(), wantOut)
+	}
+}
+
+func TestSourceStates_Wildcards(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"docs/a.txt", "docs/b.log", "notes/c.txt"} {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	states, err := sourceStates([]string{filepath.Join(root, "docs", "*.txt"), filepath.Join(root, "notes")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for p := range states {
+		got = append(got, p)
+	}
+	if len(got) != 2 || states["a.txt"].Size != 1 || states["notes/c.txt"].Size != 1 {
+		t.Errorf("states = %v", got)
+	}
+}
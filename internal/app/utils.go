@@ -358,22 +358,48 @@ func (l *passwordLookup) searchAndTry(basename string) ([]byte, string, error) {
 //  2. Backward compat: encoded exact path  "%2Fhome%2F...%2Farchive.7z"
 //  3. Backward compat: old flat basename   "archive.7z"
 //  4. Split archive fallbacks for each of the above
-func GetPasswordForArchive(kp PasswordProvider, entryPathPrefix, archivePath string) ([]byte, string, error) {
-	if kp == nil {
+//  5. The age grant sidecar next to the archive, unwrapped with identityFile
+//     (when set); the entry path is then empty
+//
+// kp may be nil when there is no KeePassXC database; only the grant is
+// tried then.
+func GetPasswordForArchive(kp PasswordProvider, entryPathPrefix, archivePath, identityFile string) ([]byte, string, error) {
+	if kp == nil && identityFile == "" {
 		return nil, "", fmt.Errorf("password provider is nil")
 	}
 	if archivePath == "" {
 		return nil, "", fmt.Errorf("archive path is empty")
 	}
 
+	var notFound error = &PasswordNotFoundError{ArchiveName: AnalyzeArchive(archivePath).OriginalName}
+	if kp != nil {
+		l := &passwordLookup{kp: kp, prefix: entryPathPrefix}
+		pass, entryPath, err := l.find(archivePath)
+		if !IsPasswordNotFound(err) || identityFile == "" {
+			return pass, entryPath, err
+		}
+		notFound = err
+	}
+
+	// 5. age grant
+	pass, ok, err := grantPassword(archivePath, identityFile)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", notFound
+	}
+	return pass, "", nil
+}
+
+// find runs the KeePassXC steps of the lookup chain.
+func (l *passwordLookup) find(archivePath string) ([]byte, string, error) {
 	info := AnalyzeArchive(archivePath)
 	absPath, err := filepath.Abs(archivePath)
 	if err != nil {
 		absPath = archivePath
 	}
 
-	l := &passwordLookup{kp: kp, prefix: entryPathPrefix}
-
 	// 1. Search by basename (UUID format primary, old format fallback)
 	if pass, entryPath, err := l.searchAndTry(filepath.Base(archivePath)); err != nil || pass != nil {
 		return pass, entryPath, err
@@ -455,9 +481,13 @@ func promptMultiMatch(candidates []EntryCandidate) (EntryCandidate, error) {
 // Returns (password, entryPath, needsMigration, err).
 // needsMigration is true when the entry is in old format (non-UUID title) and
 // the caller should call migrateEntry after a successful operation.
-func resolvePassword(kp PasswordProvider, prefix, archivePath string) (password []byte, entryPath string, needsMigration bool, err error) {
+func resolvePassword(kp PasswordProvider, prefix, archivePath, identityFile string) (password []byte, entryPath string, needsMigration bool, err error) {
 	var resolvedPath string
-	password, resolvedPath, err = GetPasswordForArchive(kp, prefix, archivePath)
+	password, resolvedPath, err = GetPasswordForArchive(kp, prefix, archivePath, identityFile)
+	if err == nil && resolvedPath == "" {
+		fmt.Fprintf(os.Stderr, "No KeePassXC entry for '%s' — using its age grant.\n", archivePath)
+		return password, "", false, nil
+	}
 	if err == nil {
 		// Check if the resolved entry is already in UUID format
 		title := resolvedPath
@@ -499,7 +529,7 @@ func resolvePassword(kp PasswordProvider, prefix, archivePath string) (password
 		}
 		chosen = &c
 	} else {
-		fmt.Printf("Auto-selected matching entry for '%s' based on exact path.\n", archivePath)
+		fmt.Fprintf(os.Stderr, "Auto-selected matching entry for '%s' based on exact path.\n", archivePath)
 	}
 
 	password, err = kp.GetPassword(chosen.EntryPath)
@@ -719,14 +749,14 @@ func attemptOrphanRecovery(kp PasswordProvider, cfg *config.Config, absArchivePa
 // bruteforceOrphans tries every orphan's password against the archive.
 func bruteforceOrphans(kp PasswordProvider, cfg *config.Config, absArchivePath string, orphans []OrphanCandidate) ([]byte, string, error) {
 	newBasename := filepath.Base(absArchivePath)
-	fmt.Printf("\nBrute-forcing %d orphan passwords against '%s'...\n\n", len(orphans), newBasename)
+	fmt.Fprintf(os.Stderr, "\nBrute-forcing %d orphan passwords against '%s'...\n\n", len(orphans), newBasename)
 
 	for i, o := range orphans {
-		fmt.Printf("  [%d/%d] %s — ", i+1, len(orphans), o.Title)
+		fmt.Fprintf(os.Stderr, "  [%d/%d] %s — ", i+1, len(orphans), o.Title)
 
 		password, err := kp.GetPassword(o.EntryPath)
 		if err != nil {
-			fmt.Printf("✗ (could not get password)\n")
+			fmt.Fprintf(os.Stderr, "✗ (could not get password)\n")
 			continue
 		}
 
@@ -735,20 +765,20 @@ func bruteforceOrphans(kp PasswordProvider, cfg *config.Config, absArchivePath s
 			for j := range password {
 				password[j] = 0
 			}
-			fmt.Printf("✗\n")
+			fmt.Fprintf(os.Stderr, "✗\n")
 			continue
 		}
 		if match == sevenzip.MatchUnencrypted {
 			for j := range password {
 				password[j] = 0
 			}
-			fmt.Printf("✗ (unencrypted)\n")
-			fmt.Printf("\n⚠ Archive '%s' is not encrypted — cannot match.\n", newBasename)
+			fmt.Fprintf(os.Stderr, "✗ (unencrypted)\n")
+			fmt.Fprintf(os.Stderr, "\n⚠ Archive '%s' is not encrypted — cannot match.\n", newBasename)
 			return nil, "", fmt.Errorf("archive is not encrypted")
 		}
 
 		// Match found!
-		fmt.Printf("✓ MATCH\n")
+		fmt.Fprintf(os.Stderr, "✓ MATCH\n")
 		return relinkOrphanEntry(kp, cfg, absArchivePath, o, password)
 	}
 
@@ -764,7 +794,7 @@ func verifyAndRelinkOrphan(kp PasswordProvider, cfg *config.Config, absArchivePa
 		return nil, "", fmt.Errorf("failed to get password for '%s': %w", chosen.Title, err)
 	}
 
-	fmt.Printf("Verifying password against '%s'...\n", newBasename)
+	fmt.Fprintf(os.Stderr, "Verifying password against '%s'...\n", newBasename)
 	match, verifyErr := sevenzip.VerifyPassword(cfg.SevenZip.BinaryPath, password, absArchivePath)
 	if match != sevenzip.MatchCorrect {
 		for i := range password {
@@ -776,7 +806,7 @@ func verifyAndRelinkOrphan(kp PasswordProvider, cfg *config.Config, absArchivePa
 		return nil, "", fmt.Errorf("archive '%s' is unencrypted — cannot use KeePassXC entry", newBasename)
 	}
 
-	fmt.Printf("✓ Success\n")
+	fmt.Fprintf(os.Stderr, "✓ Success\n")
 	return relinkOrphanEntry(kp, cfg, absArchivePath, chosen, password)
 }
 
@@ -791,20 +821,20 @@ func relinkOrphanEntry(kp PasswordProvider, cfg *config.Config, absArchivePath s
 	} else {
 		newUUID, err := generateUniqueUUID8(kp, cfg.General.DefaultGroup, newBasename)
 		if err != nil {
-			fmt.Printf("Note: could not generate UUID for new title: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Note: could not generate UUID for new title: %v\n", err)
 			newTitle = chosen.Title
 		} else {
 			newTitle = makeEntryTitle(newBasename, newUUID)
 		}
 	}
 
-	fmt.Printf("Relinking entry '%s' → '%s'...\n", chosen.Title, newTitle)
+	fmt.Fprintf(os.Stderr, "Relinking entry '%s' → '%s'...\n", chosen.Title, newTitle)
 	if err := kp.EditEntryTitle(chosen.EntryPath, newTitle, absArchivePath); err != nil {
-		fmt.Printf("Warning: relink failed (password is valid, entry not updated): %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: relink failed (password is valid, entry not updated): %v\n", err)
 	}
 
 	newEntryPath := filepath.ToSlash(filepath.Clean(cfg.General.DefaultGroup + "/" + newTitle))
-	fmt.Println("Recovery successful! Entry relinked.")
+	fmt.Fprintln(os.Stderr, "Recovery successful! Entry relinked.")
 	return password, newEntryPath, nil
 }
 
@@ -833,10 +863,18 @@ func ensureArchiveExists(absPath string) error {
 // housekeeping (migrating old entries, updating moved paths).
 // If readOnly is true, it does not attempt to migrate or update paths (used by delete).
 func withKeePassArchive(archivePath string, readOnly bool, op ArchiveOp) error {
+	return withKeePassArchiveIdentity(archivePath, "", readOnly, op)
+}
+
+// withKeePassArchiveIdentity is withKeePassArchive with an age identity file
+// for the grant step of the lookup (an --identity flag); empty means
+// general.age_identity.
+func withKeePassArchiveIdentity(archivePath, identityFile string, readOnly bool, op ArchiveOp) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
 	}
+	identityFile = ageIdentity(cfg, identityFile)
 
 	absPath, err := filepath.Abs(archivePath)
 	if err != nil {
@@ -847,13 +885,15 @@ func withKeePassArchive(archivePath string, readOnly bool, op ArchiveOp) error {
 		return err
 	}
 
-	kp := keepass.New(cfg.General.KdbxPath, testClientOptions...)
+	kp := newKeePassClient(cfg)
 	defer kp.Close()
 
-	fmt.Printf("Fetching password for '%s'...\n", archivePath)
-	password, entryPath, needsMigration, err := resolvePassword(kp, cfg.General.DefaultGroup, archivePath)
+	// Lookup status goes to stderr: stdout may carry data ('cat')
+	fmt.Fprintf(os.Stderr, "Fetching password for '%s'...\n", archivePath)
+	lookup := keePassLookup(cfg, kp, identityFile)
+	password, entryPath, needsMigration, err := resolvePassword(lookup, cfg.General.DefaultGroup, archivePath, identityFile)
 	if err != nil {
-		if IsPasswordNotFound(err) {
+		if IsPasswordNotFound(err) && lookup != nil {
 			// Attempt orphan recovery — the archive may have been renamed
 			recPass, recPath, recErr := attemptOrphanRecovery(kp, cfg, absPath)
 			if recErr != nil {
@@ -894,8 +934,8 @@ func withKeePassArchive(archivePath string, readOnly bool, op ArchiveOp) error {
 		fmt.Fprintf(os.Stderr, "\nHint: Wrong password? The entry may be mislinked. Try '7zkpxc relink %s' to fix.\n", archivePath)
 	}
 
-	// Housekeeping (only on success and if not read-only)
-	if opErr == nil && !readOnly {
+	// Housekeeping (only on success, if not read-only and there is an entry)
+	if opErr == nil && !readOnly && entryPath != "" {
 		if isUnencrypted {
 			fmt.Printf("\n[i] Note: Archive '%s' is not encrypted. KeePassXC entry was NOT updated to prevent metadata mix-ups.\n", filepath.Base(absPath))
 		} else {
@@ -3,6 +3,7 @@ package app
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -495,7 +496,7 @@ func TestGetPasswordForArchive_UUIDFormat(t *testing.T) {
 	mock := NewMockPasswordProvider()
 	addUUIDEntry(mock, "backups", "archive.7z", "a3b2c1d0", "/home/user/archive.7z", []byte("secret"))
 
-	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z")
+	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -509,7 +510,7 @@ func TestGetPasswordForArchive_MultiMatch(t *testing.T) {
 	addUUIDEntry(mock, "backups", "backup.7z", "a3b2c1d0", "/cloud/backup.7z", []byte("pass1"))
 	addUUIDEntry(mock, "backups", "backup.7z", "f1e2d3c4", "/local/backup.7z", []byte("pass2"))
 
-	_, _, err := GetPasswordForArchive(mock, "backups", "backup.7z")
+	_, _, err := GetPasswordForArchive(mock, "backups", "backup.7z", "")
 	if err == nil {
 		t.Fatal("expected MultiMatchError, got nil")
 	}
@@ -530,7 +531,7 @@ func TestGetPasswordForArchive_UUIDFormat_NoMatch(t *testing.T) {
 	// Entry for a different basename — should not match
 	addUUIDEntry(mock, "backups", "other.7z", "a3b2c1d0", "/home/user/other.7z", []byte("secret"))
 
-	_, _, err := GetPasswordForArchive(mock, "backups", "archive.7z")
+	_, _, err := GetPasswordForArchive(mock, "backups", "archive.7z", "")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -545,7 +546,7 @@ func TestGetPasswordForArchive_UUIDFormat_SplitArchive(t *testing.T) {
 	addUUIDEntry(mock, "backups", "archive.7z", "a3b2c1d0", "/home/user/archive.7z", []byte("split_pass"))
 
 	// User references a split volume
-	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z.001")
+	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z.001", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -569,7 +570,7 @@ func TestGetPasswordForArchive_BackwardCompat_Encoded(t *testing.T) {
 	mock.SetAttribute(entryPath, "Username", "/home/user/archive.7z")
 
 	// Lookup via absolute path — must hit step 2 (backward compat)
-	pass, _, err := GetPasswordForArchive(mock, "backups", "/home/user/archive.7z")
+	pass, _, err := GetPasswordForArchive(mock, "backups", "/home/user/archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -591,7 +592,7 @@ func TestGetPasswordForArchive_BackwardCompat_SearchOldFormat(t *testing.T) {
 	mock.SetAttribute(entryPath, "Username", "/home/user/archive.7z")
 
 	// Search by basename alone (no absolute path known — simulates post-format scenario)
-	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z")
+	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -605,7 +606,7 @@ func TestGetPasswordForArchive_BackwardCompat_FlatBasename(t *testing.T) {
 	// Oldest format: just the basename, no encoding
 	mock.SetPassword("backups/archive.7z", []byte("flat_pass"))
 
-	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z")
+	pass, _, err := GetPasswordForArchive(mock, "backups", "archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -721,7 +722,7 @@ func TestGetPasswordForArchive(t *testing.T) {
 			mock := NewMockPasswordProvider()
 			tt.setupMock(mock)
 
-			password, _, err := GetPasswordForArchive(mock, tt.prefix, tt.archivePath)
+			password, _, err := GetPasswordForArchive(mock, tt.prefix, tt.archivePath, "")
 
 			if tt.wantError {
 				if err == nil {
@@ -744,7 +745,7 @@ func TestGetPasswordForArchive(t *testing.T) {
 
 func TestGetPasswordForArchive_EdgeCases(t *testing.T) {
 	t.Run("Nil provider", func(t *testing.T) {
-		_, _, err := GetPasswordForArchive(nil, "prefix", "file.7z")
+		_, _, err := GetPasswordForArchive(nil, "prefix", "file.7z", "")
 		if err == nil {
 			t.Error("Expected error for nil provider")
 		}
@@ -752,7 +753,7 @@ func TestGetPasswordForArchive_EdgeCases(t *testing.T) {
 
 	t.Run("Empty archive path", func(t *testing.T) {
 		mock := NewMockPasswordProvider()
-		_, _, err := GetPasswordForArchive(mock, "prefix", "")
+		_, _, err := GetPasswordForArchive(mock, "prefix", "", "")
 		if err == nil {
 			t.Error("Expected error for empty archive path")
 		}
@@ -902,7 +903,7 @@ func BenchmarkGetPasswordForArchive(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _, _ = GetPasswordForArchive(mock, "backups", "archive.7z.001")
+		_, _, _ = GetPasswordForArchive(mock, "backups", "archive.7z.001", "")
 	}
 }
 
@@ -1173,7 +1174,7 @@ func TestResolvePassword_SingleMatch(t *testing.T) {
 	mock := NewMockPasswordProvider()
 	addUUIDEntry(mock, "backups", "archive.7z", "a3b2c1d0", "/home/user/archive.7z", []byte("resolved"))
 
-	pass, entryPath, needsMigration, err := resolvePassword(mock, "backups", "archive.7z")
+	pass, entryPath, needsMigration, err := resolvePassword(mock, "backups", "archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1193,7 +1194,7 @@ func TestResolvePassword_OldFormat_NeedsMigration(t *testing.T) {
 	// Old flat format (no UUID)
 	mock.SetPassword("backups/archive.7z", []byte("old_pass"))
 
-	pass, entryPath, needsMigration, err := resolvePassword(mock, "backups", "archive.7z")
+	pass, entryPath, needsMigration, err := resolvePassword(mock, "backups", "archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1210,7 +1211,7 @@ func TestResolvePassword_OldFormat_NeedsMigration(t *testing.T) {
 
 func TestResolvePassword_NotFound(t *testing.T) {
 	mock := NewMockPasswordProvider()
-	_, _, _, err := resolvePassword(mock, "backups", "nonexistent.7z")
+	_, _, _, err := resolvePassword(mock, "backups", "nonexistent.7z", "")
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1228,7 +1229,7 @@ func TestResolvePassword_MultiMatch_SmartResolve(t *testing.T) {
 	// resolvePassword calls GetPasswordForArchive which returns MultiMatchError,
 	// then smart resolution matches by abs path. Since we can't control filepath.Abs
 	// in tests easily, we test that MultiMatchError is properly generated
-	_, _, _, err := resolvePassword(mock, "backups", "archive.7z")
+	_, _, _, err := resolvePassword(mock, "backups", "archive.7z", "")
 	// This might auto-resolve or return error depending on abs path match.
 	// The important thing is it doesn't panic.
 	if err != nil && !IsMultiMatch(err) {
@@ -1715,7 +1716,7 @@ func TestGetPasswordForArchive_SplitArchive_EncodedFallback(t *testing.T) {
 	mock.SetAttribute("archives/"+encoded, "Username", absNorm)
 
 	// Look up via split volume path
-	pass, _, err := GetPasswordForArchive(mock, "archives", "/home/user/backup.7z.001")
+	pass, _, err := GetPasswordForArchive(mock, "archives", "/home/user/backup.7z.001", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1728,7 +1729,7 @@ func TestGetPasswordForArchive_ReturnEntryPath(t *testing.T) {
 	mock := NewMockPasswordProvider()
 	ep := addUUIDEntry(mock, "grp", "test.7z", "aabbccdd", "/x/test.7z", []byte("pw"))
 
-	_, entryPath, err := GetPasswordForArchive(mock, "grp", "test.7z")
+	_, entryPath, err := GetPasswordForArchive(mock, "grp", "test.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1749,7 +1750,7 @@ func TestResolvePassword_EncodedPath_NeedsMigration(t *testing.T) {
 	mock.SetPassword(entryPath, []byte("old_enc_pass"))
 	mock.SetAttribute(entryPath, "Username", "/home/user/archive.7z")
 
-	pass, resolvedPath, needsMigration, err := resolvePassword(mock, "backups", "/home/user/archive.7z")
+	pass, resolvedPath, needsMigration, err := resolvePassword(mock, "backups", "/home/user/archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1797,7 +1798,7 @@ func TestResolvePassword_MultiMatch_AutoSelect(t *testing.T) {
 	addUUIDEntry(mock, "grp", "archive.7z", "bbbbbbbb", "/home/user/dir2/archive.7z", []byte("pw2"))
 
 	// Resolve with the exact path matching entry 1
-	pass, entryPath, needsMigration, err := resolvePassword(mock, "grp", "/home/user/dir1/archive.7z")
+	pass, entryPath, needsMigration, err := resolvePassword(mock, "grp", "/home/user/dir1/archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1820,7 +1821,7 @@ func TestResolvePassword_MultiMatch_AutoSelect_ExactPath2(t *testing.T) {
 	addUUIDEntry(mock, "grp", "archive.7z", "bbbbbbbb", "/path/b/archive.7z", []byte("pw_b"))
 
 	// Resolve with the exact path matching entry 2
-	pass, entryPath, _, err := resolvePassword(mock, "grp", "/path/b/archive.7z")
+	pass, entryPath, _, err := resolvePassword(mock, "grp", "/path/b/archive.7z", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1832,6 +1833,27 @@ func TestResolvePassword_MultiMatch_AutoSelect_ExactPath2(t *testing.T) {
 	}
 }
 
+// Status lines must not end up in machine-readable output (diff --json).
+func TestResolvePassword_MultiMatch_AutoSelect_NotOnStdout(t *testing.T) {
+	mock := NewMockPasswordProvider()
+	addUUIDEntry(mock, "grp", "archive.7z", "aaaaaaaa", "/path/a/archive.7z", []byte("pw_a"))
+	addUUIDEntry(mock, "grp", "archive.7z", "bbbbbbbb", "/path/b/archive.7z", []byte("pw_b"))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	_, _, _, err := resolvePassword(mock, "grp", "/path/a/archive.7z", "")
+	_ = w.Close()
+	os.Stdout = old
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out, _ := io.ReadAll(r); len(out) != 0 {
+		t.Errorf("stdout = %q, want nothing", out)
+	}
+}
+
 // -------------------------------------------------------------------
 // updatePathIfMoved (71.4% → coverage)
 // -------------------------------------------------------------------
@@ -0,0 +1,324 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lxstig/7zkpxc/internal/config"
+	"github.com/lxstig/7zkpxc/internal/keepass"
+	"github.com/lxstig/7zkpxc/internal/sevenzip"
+	"github.com/spf13/cobra"
+)
+
+// watchMaxBackoff caps the wait between retries of a failed sync.
+const watchMaxBackoff = 5 * time.Minute
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir> <archive>",
+	Short: "Keep an archive in sync with a directory",
+	Long: `Watches a directory and applies every change to an existing managed
+archive: new and modified files are updated, deleted ones removed. The
+archive holds the directory under its own name, as '7zkpxc a <archive> <dir>'
+creates it.
+
+Changes are collected until the directory has been quiet for --debounce,
+then applied in one 7z run with the stored password, after which the entry's
+metadata and manifest are refreshed. A failed sync is retried with growing
+delays (up to 5 minutes); changes keep accumulating meanwhile.
+
+At start, and whenever the kernel dropped events, the whole directory is
+mirrored as by 'u --mirror': files deleted while watch was not looking are
+removed from the archive as well.
+
+The database is unlocked once. The master and archive passwords stay in
+memory only and are wiped when watch exits (Ctrl-C or SIGTERM, after a final
+sync of pending changes).
+
+  7zkpxc watch ~/secrets secrets.7z
+  7zkpxc watch --debounce 10s ~/secrets /mnt/backup/secrets.7z`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runWatch,
+	GroupID: "actions",
+}
+
+func init() {
+	watchCmd.Flags().Duration("debounce", 2*time.Second, "Wait until the directory is quiet this long before syncing")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watchSession is one running watch: a directory and the archive it feeds.
+type watchSession struct {
+	cfg       *config.Config
+	kp        *keepass.Client
+	password  []byte
+	entryPath string
+	dir       string // absolute path of the watched directory
+	archive   string // absolute path of the archive
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+	if debounce <= 0 {
+		return fmt.Errorf("--debounce must be positive")
+	}
+
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("cannot watch '%s': %w", args[0], err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", args[0])
+	}
+	archive, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+
+	return withKeePassArchive(args[1], false, func(cfg *config.Config, kp *keepass.Client, password []byte, entryPath string) error {
+		if err := refuseTarPayload(kp, entryPath, archive); err != nil {
+			return err
+		}
+		s := &watchSession{cfg: cfg, kp: kp, password: password, entryPath: entryPath, dir: dir, archive: archive}
+		return s.run(debounce)
+	})
+}
+
+// run syncs once, then applies debounced changes until interrupted.
+func (s *watchSession) run(debounce time.Duration) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("cannot start watcher: %w", err)
+	}
+	defer func() { _ = w.Close() }()
+	if err := s.watchTree(w, s.dir); err != nil {
+		return err
+	}
+
+	// The directory itself is the first change: one update catches up with
+	// everything that changed before watch started.
+	pending := map[string]bool{s.dir: true}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	failures := 0
+
+	fmt.Printf("Watching '%s' → '%s' (Ctrl-C to stop)...\n", s.dir, filepath.Base(s.archive))
+	for {
+		select {
+		case sig := <-sigs:
+			if len(pending) > 0 {
+				fmt.Printf("\n%v — syncing %d pending change(s) before exit...\n", sig, len(pending))
+				if err := s.sync(pending); err != nil {
+					return fmt.Errorf("final sync failed: %w", err)
+				}
+			}
+			fmt.Println("Stopped watching.")
+			return nil
+
+		case ev, ok := <-w.Events:
+			if !ok {
+				return fmt.Errorf("watcher closed unexpectedly")
+			}
+			if !s.relevant(ev) {
+				continue
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Lstat(ev.Name); err == nil && info.IsDir() {
+					_ = s.watchTree(w, ev.Name)
+				}
+			}
+			pending[ev.Name] = true
+			if failures == 0 {
+				timer.Reset(debounce)
+			}
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return fmt.Errorf("watcher closed unexpectedly")
+			}
+			fmt.Fprintf(os.Stderr, "⚠ watch: %v\n", err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were lost: resync the whole directory.
+				pending[s.dir] = true
+				timer.Reset(debounce)
+			}
+
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+			if err := s.sync(pending); err != nil {
+				failures++
+				wait := watchBackoff(debounce, failures)
+				fmt.Fprintf(os.Stderr, "✗ sync failed: %v — retrying in %s\n", err, wait)
+				timer.Reset(wait)
+				continue
+			}
+			failures = 0
+			clear(pending)
+		}
+	}
+}
+
+// relevant drops events that do not change content, and those for the
+// archive itself (and its volumes and temporary files) when it lives inside
+// the watched directory.
+func (s *watchSession) relevant(ev fsnotify.Event) bool {
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	return !strings.HasPrefix(ev.Name, s.archive)
+}
+
+// watchTree adds root and every directory below it to the watcher.
+// Unreadable subdirectories are skipped with a warning.
+func (s *watchSession) watchTree(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "⚠ not watching '%s': %v\n", p, err)
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.Add(p); err != nil {
+			if p == root {
+				return fmt.Errorf("cannot watch '%s': %w", p, err)
+			}
+			fmt.Fprintf(os.Stderr, "⚠ not watching '%s': %v\n", p, err)
+		}
+		return nil
+	})
+}
+
+// sync applies the pending changes to the archive and refreshes the entry.
+func (s *watchSession) sync(pending map[string]bool) error {
+	parent := filepath.Dir(s.dir)
+	update, remove := splitWatchBatch(pending, parent)
+	updateFlags := watchUpdateFlags(pending, s.dir)
+	mirror := slices.Contains(updateFlags, "-up0q0x2")
+	if mirror {
+		remove = nil // the mirror update drops them
+	}
+
+	tmp, err := newPrivateTempDir("7zkpxc-watch-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = wipeDir(tmp) }()
+
+	// Paths are relative to the parent of the directory, like the archive's;
+	// -spf2 keeps them whole when adding.
+	run := func(op string, paths []string, flags ...string) error {
+		if len(paths) == 0 {
+			return nil
+		}
+		list := filepath.Join(tmp, op+".txt")
+		if err := os.WriteFile(list, []byte(strings.Join(paths, "\n")+"\n"), 0o600); err != nil {
+			return err
+		}
+		args := append(append([]string{op}, flags...), "-spd", "-scsUTF-8", s.archive, "@"+list)
+		return sevenzip.RunInDir(parent, s.cfg.SevenZip.BinaryPath, s.password, args)
+	}
+	if err := run("d", remove); err != nil {
+		return fmt.Errorf("removing deleted files: %w", err)
+	}
+	if err := run("u", update, updateFlags...); err != nil {
+		return fmt.Errorf("updating changed files: %w", err)
+	}
+
+	updateMetadata(s.kp, s.entryPath, s.archive)
+	refreshManifest(s.cfg, s.kp, s.password, s.entryPath, s.archive)
+	if mirror {
+		fmt.Printf("✓ [%s] synced: whole directory mirrored\n", time.Now().Format("15:04:05"))
+	} else {
+		fmt.Printf("✓ [%s] synced: %d updated, %d removed\n", time.Now().Format("15:04:05"), len(update), len(remove))
+	}
+	return nil
+}
+
+// watchUpdateFlags returns the switches of the "u" run. When the directory
+// itself is pending (the catch-up at start, or a resync after lost events)
+// the update mirrors it like 'u --mirror', so members whose files were
+// deleted meanwhile are removed too; a plain "u" never removes members.
+func watchUpdateFlags(pending map[string]bool, dir string) []string {
+	if pending[dir] {
+		return []string{"-spf2", "-up0q0x2"}
+	}
+	return []string{"-spf2"}
+}
+
+// splitWatchBatch sorts changed paths into those to update (they exist) and
+// those to remove from the archive, relative to parent in slash form. Paths
+// below another path of the same list are dropped: 7z handles directories
+// recursively.
+func splitWatchBatch(pending map[string]bool, parent string) (update, remove []string) {
+	for p := range pending {
+		rel, err := filepath.Rel(parent, p)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if _, err := os.Lstat(p); err == nil {
+			update = append(update, rel)
+		} else {
+			remove = append(remove, rel)
+		}
+	}
+	return topLevelPaths(update), topLevelPaths(remove)
+}
+
+// topLevelPaths sorts paths and drops those inside another one.
+func topLevelPaths(paths []string) []string {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[p] = true
+	}
+	var out []string
+	for _, p := range paths {
+		nested := false
+		for d := path.Dir(p); d != "." && d != "/"; d = path.Dir(d) {
+			if set[d] {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// watchBackoff doubles the retry delay per consecutive failure, up to
+// watchMaxBackoff.
+func watchBackoff(debounce time.Duration, failures int) time.Duration {
+	wait := debounce
+	for i := 0; i < failures && wait < watchMaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, watchMaxBackoff)
+}
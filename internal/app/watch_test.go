@@ -0,0 +1,95 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestSplitWatchBatch(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "secrets")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pending := map[string]bool{
+		filepath.Join(dir, "a.txt"):              true,
+		filepath.Join(dir, "sub"):                true,
+		filepath.Join(dir, "sub", "b.txt"):       true, // covered by sub
+		filepath.Join(dir, "gone.txt"):           true,
+		filepath.Join(dir, "old"):                true,
+		filepath.Join(dir, "old", "x.txt"):       true, // covered by old
+		filepath.Join(parent, "..", "elsewhere"): true,
+	}
+	update, remove := splitWatchBatch(pending, parent)
+	if want := []string{"secrets/a.txt", "secrets/sub"}; !reflect.DeepEqual(update, want) {
+		t.Errorf("update = %v, want %v", update, want)
+	}
+	if want := []string{"secrets/gone.txt", "secrets/old"}; !reflect.DeepEqual(remove, want) {
+		t.Errorf("remove = %v, want %v", remove, want)
+	}
+}
+
+func TestTopLevelPaths(t *testing.T) {
+	got := topLevelPaths([]string{"a/b/x", "a/b-c", "a/b", "d"})
+	if want := []string{"a/b", "a/b-c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("topLevelPaths = %v, want %v", got, want)
+	}
+}
+
+func TestWatchUpdateFlags(t *testing.T) {
+	dir := "/home/user/secrets"
+	got := watchUpdateFlags(map[string]bool{dir: true, dir + "/a.txt": true}, dir)
+	if want := []string{"-spf2", "-up0q0x2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with the directory pending = %v, want %v", got, want)
+	}
+	got = watchUpdateFlags(map[string]bool{dir + "/a.txt": true}, dir)
+	if want := []string{"-spf2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with single files pending = %v, want %v", got, want)
+	}
+}
+
+func TestWatchBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 4 * time.Second},
+		{3, 16 * time.Second},
+		{20, watchMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := watchBackoff(2*time.Second, tt.failures); got != tt.want {
+			t.Errorf("watchBackoff(2s, %d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestWatchSession_Relevant(t *testing.T) {
+	s := &watchSession{dir: "/data/secrets", archive: "/data/secrets/secrets.7z"}
+	tests := []struct {
+		ev   fsnotify.Event
+		want bool
+	}{
+		{fsnotify.Event{Name: "/data/secrets/a.txt", Op: fsnotify.Write}, true},
+		{fsnotify.Event{Name: "/data/secrets/a.txt", Op: fsnotify.Chmod}, false},
+		{fsnotify.Event{Name: "/data/secrets/a.txt", Op: fsnotify.Chmod | fsnotify.Write}, true},
+		{fsnotify.Event{Name: "/data/secrets/secrets.7z", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: "/data/secrets/secrets.7z.tmp", Op: fsnotify.Create}, false},
+	}
+	for _, tt := range tests {
+		if got := s.relevant(tt.ev); got != tt.want {
+			t.Errorf("relevant(%v) = %v, want %v", tt.ev, got, tt.want)
+		}
+	}
+}
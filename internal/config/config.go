@@ -14,15 +14,28 @@ const (
 	PasswordLengthDefault = 64
 )
 
+// BackupNameDefault is the archive name template used by backup profiles
+// without a name of their own.
+const BackupNameDefault = "{profile}-{date}-{time}.7z"
+
 // Config holds the application configuration
 type Config struct {
 	General  GeneralConfig  `mapstructure:"general" yaml:"general"`
 	SevenZip SevenZipConfig `mapstructure:"sevenzip" yaml:"sevenzip"`
+	// Backups are the profiles run by '7zkpxc backup', keyed by profile name.
+	// Viper lower-cases map keys, so profile names are case-insensitive.
+	Backups map[string]BackupProfile `mapstructure:"backups" yaml:"backups"`
 }
 
 type GeneralConfig struct {
 	KdbxPath     string `mapstructure:"kdbx_path" yaml:"kdbx_path"`
 	DefaultGroup string `mapstructure:"default_group" yaml:"default_group"`
+	// KeyFile is an optional KeePassXC key file, passed to keepassxc-cli
+	// alongside the master password and included by 'escrow'.
+	KeyFile string `mapstructure:"key_file" yaml:"key_file"`
+	// AgeIdentity is an optional age identity file. It unwraps an archive's
+	// grant sidecar ('7zkpxc grant') when KeePassXC has no entry for it.
+	AgeIdentity string `mapstructure:"age_identity" yaml:"age_identity"`
 	// UseKeyring is persisted in the config file but not yet acted on.
 	// TODO: implement OS keyring integration (e.g. via keyring package).
 	UseKeyring     bool `mapstructure:"use_keyring" yaml:"use_keyring"`
@@ -34,6 +47,40 @@ type SevenZipConfig struct {
 	BinaryPath  string   `mapstructure:"binary_path" yaml:"binary_path"`
 }
 
+// BackupProfile describes one archive produced by '7zkpxc backup'.
+type BackupProfile struct {
+	Sources     []string `mapstructure:"sources" yaml:"sources"`
+	Excludes    []string `mapstructure:"excludes" yaml:"excludes"` // 7z wildcards, excluded recursively
+	Destination string   `mapstructure:"destination" yaml:"destination"`
+	// Name is the archive name template; {profile}, {host}, {date} and
+	// {time} are replaced. Defaults to BackupNameDefault.
+	Name string `mapstructure:"name" yaml:"name"`
+	// Group is the KeePassXC group for the entry; defaults to
+	// general.default_group.
+	Group string `mapstructure:"group" yaml:"group"`
+	// Args replace sevenzip.default_args for this profile when set.
+	Args []string `mapstructure:"args" yaml:"args"`
+	// Retention is applied by '7zkpxc prune-backups'.
+	Retention RetentionPolicy `mapstructure:"retention" yaml:"retention"`
+}
+
+// RetentionPolicy decides which backups 'prune-backups' keeps: the newest
+// KeepLast archives plus the newest archive of each of the last KeepDaily
+// days, KeepWeekly ISO weeks, KeepMonthly months and KeepYearly years.
+// Zero disables a rule.
+type RetentionPolicy struct {
+	KeepLast    int `mapstructure:"keep_last" yaml:"keep_last"`
+	KeepDaily   int `mapstructure:"keep_daily" yaml:"keep_daily"`
+	KeepWeekly  int `mapstructure:"keep_weekly" yaml:"keep_weekly"`
+	KeepMonthly int `mapstructure:"keep_monthly" yaml:"keep_monthly"`
+	KeepYearly  int `mapstructure:"keep_yearly" yaml:"keep_yearly"`
+}
+
+// IsZero reports whether no rule is set.
+func (r RetentionPolicy) IsZero() bool {
+	return r == RetentionPolicy{}
+}
+
 var (
 	// cachedConfig stores the loaded config to prevent redundant disk reads.
 	// In a short-lived CLI this saves ~1ms per call, but avoids parsing twice
@@ -122,10 +169,15 @@ func SaveConfig(cfg *Config) error {
 	v := viper.New()
 	v.Set("general.kdbx_path", cfg.General.KdbxPath)
 	v.Set("general.default_group", cfg.General.DefaultGroup)
+	v.Set("general.key_file", cfg.General.KeyFile)
+	v.Set("general.age_identity", cfg.General.AgeIdentity)
 	v.Set("general.use_keyring", cfg.General.UseKeyring)
 	v.Set("general.password_length", cfg.General.PasswordLength)
 	v.Set("sevenzip.default_args", cfg.SevenZip.DefaultArgs)
 	v.Set("sevenzip.binary_path", cfg.SevenZip.BinaryPath)
+	if len(cfg.Backups) > 0 {
+		v.Set("backups", cfg.Backups)
+	}
 
 	configPath := filepath.Join(configDir, "config.yaml")
 	return v.WriteConfigAs(configPath)
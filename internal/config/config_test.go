@@ -119,6 +119,13 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 			DefaultArgs: []string{"-mhe=on", "-mx=5"},
 			BinaryPath:  "/usr/bin/7z",
 		},
+		Backups: map[string]BackupProfile{
+			"home": {
+				Sources:     []string{"/home/user/docs"},
+				Destination: "/mnt/backup",
+				Retention:   RetentionPolicy{KeepLast: 3, KeepDaily: 7},
+			},
+		},
 	}
 
 	if err := SaveConfig(original); err != nil {
@@ -151,6 +158,10 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 	if len(loaded.SevenZip.DefaultArgs) != len(original.SevenZip.DefaultArgs) {
 		t.Errorf("DefaultArgs len = %d, want %d", len(loaded.SevenZip.DefaultArgs), len(original.SevenZip.DefaultArgs))
 	}
+	home := loaded.Backups["home"]
+	if home.Destination != "/mnt/backup" || len(home.Sources) != 1 || home.Retention.KeepDaily != 7 {
+		t.Errorf("Backups[home] = %+v, want %+v", home, original.Backups["home"])
+	}
 }
 
 func TestPasswordLength_InvalidMin(t *testing.T) {
@@ -331,3 +342,44 @@ func TestConstants(t *testing.T) {
 		t.Errorf("Min (%d) should be at least 1", PasswordLengthMin)
 	}
 }
+
+func TestLoadConfig_BackupProfiles(t *testing.T) {
+	resetViper(t)
+
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+
+	configDir := filepath.Join(tmpHome, ".config", "7zkpxc")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	yaml := `general:
+  kdbx_path: /tmp/test.kdbx
+backups:
+  Home:
+    sources: ["~/docs", "~/projects"]
+    excludes: ["node_modules", "*.tmp"]
+    destination: /mnt/backup
+    group: Archives/Backups
+    args: ["-mhe=on", "-mx=3"]
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	p, ok := loaded.Backups["home"]
+	if !ok {
+		t.Fatalf("profile 'home' not loaded: %+v", loaded.Backups)
+	}
+	if len(p.Sources) != 2 || len(p.Excludes) != 2 || len(p.Args) != 2 {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+	if p.Destination != "/mnt/backup" || p.Group != "Archives/Backups" || p.Name != "" {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 type Client struct {
 	DatabasePath   string
+	KeyFile        string // optional, passed as --key-file to database commands
 	masterPassword []byte // zeroed on Close()
 	passwordSet    bool
 }
@@ -40,6 +41,14 @@ func WithPassword(password []byte) ClientOption {
 	}
 }
 
+// WithKeyFile sets a key file that is passed to every database command
+// together with the master password.
+func WithKeyFile(path string) ClientOption {
+	return func(c *Client) {
+		c.KeyFile = path
+	}
+}
+
 // buildCmd creates an exec.Cmd for keepassxc-cli enforcing English output
 // so that error string matching (like "already exists") works consistently regardless of user locale.
 func buildCmd(args ...string) *exec.Cmd {
@@ -53,6 +62,16 @@ func buildCmd(args ...string) *exec.Cmd {
 	return cmd
 }
 
+// dbCmd is like buildCmd for commands that open the database: it inserts
+// --key-file after the subcommand name when a key file is configured.
+func (c *Client) dbCmd(args ...string) *exec.Cmd {
+	if c.KeyFile == "" || len(args) == 0 {
+		return buildCmd(args...)
+	}
+	withKey := append([]string{args[0], "--key-file", c.KeyFile}, args[1:]...)
+	return buildCmd(withKey...)
+}
+
 // Close securely wipes the master password from memory
 func (c *Client) Close() {
 	for i := range c.masterPassword {
@@ -69,13 +88,24 @@ func (c *Client) getMasterPassword() []byte {
 }
 
 func (c *Client) runCmd(args ...string) ([]byte, error) {
+	return c.runCmdFile(nil, args...)
+}
+
+// pipedFile is the path under which a command run by runCmdFile reads the
+// data passed to it.
+const pipedFile = "/dev/fd/3"
+
+// runCmdFile is runCmd for commands that read a file: when data is not nil,
+// it is passed through a pipe that the command opens as pipedFile, so it
+// never touches the disk.
+func (c *Client) runCmdFile(data []byte, args ...string) ([]byte, error) {
 	lockRetries := 0
 	for {
 		if err := c.EnsureUnlocked(); err != nil {
 			return nil, err
 		}
 
-		cmd := buildCmd(args...)
+		cmd := c.dbCmd(args...)
 		var outBuf bytes.Buffer
 		var errBuf bytes.Buffer
 		cmd.Stdout = &outBuf
@@ -86,21 +116,48 @@ func (c *Client) runCmd(args ...string) ([]byte, error) {
 			return nil, err
 		}
 
+		var fileR, fileW *os.File
+		if data != nil {
+			if fileR, fileW, err = os.Pipe(); err != nil {
+				return nil, err
+			}
+			cmd.ExtraFiles = []*os.File{fileR}
+		}
+
 		if err := cmd.Start(); err != nil {
+			if fileR != nil {
+				_ = fileR.Close()
+				_ = fileW.Close()
+			}
 			return nil, err
 		}
 
+		// The command reads the file after the password; feed it meanwhile
+		// (a command that fails early closes the pipe and ends the write).
+		fed := make(chan struct{})
+		if fileR != nil {
+			_ = fileR.Close()
+			go func() {
+				_, _ = fileW.Write(data)
+				_ = fileW.Close()
+				close(fed)
+			}()
+		} else {
+			close(fed)
+		}
+
 		_, _ = stdin.Write(c.getMasterPassword())
 		_, _ = stdin.Write([]byte("\n"))
 		_ = stdin.Close()
 
 		err = cmd.Wait()
+		<-fed
 		if err != nil {
 			errStr := parseKeepassxcStderr(errBuf.String(), c.DatabasePath)
 
 			// Check if the error is an incorrect master password
 			if strings.Contains(errStr, "Invalid credentials") || strings.Contains(errStr, "HMAC mismatch") {
-				fmt.Println("\033[31mError: Invalid KeePassXC master password. Please try again.\033[0m")
+				fmt.Fprintln(os.Stderr, "\033[31mError: Invalid KeePassXC master password. Please try again.\033[0m")
 				c.clearMasterPassword()
 				lockRetries = 0
 				continue
@@ -111,7 +168,7 @@ func (c *Client) runCmd(args ...string) ([]byte, error) {
 			if strings.Contains(lowerErrStr, "locked by") || strings.Contains(lowerErrStr, "lock file") || strings.Contains(lowerErrStr, "database is locked") {
 				if lockRetries < 3 {
 					lockRetries++
-					fmt.Printf("\033[33mWarning: KeePassXC database is locked by another process. Retrying in 2 seconds... (%d/3)\033[0m\n", lockRetries)
+					fmt.Fprintf(os.Stderr, "\033[33mWarning: KeePassXC database is locked by another process. Retrying in 2 seconds... (%d/3)\033[0m\n", lockRetries)
 					time.Sleep(2 * time.Second)
 					continue
 				}
@@ -131,7 +188,7 @@ func (c *Client) runCmdQuiet(args ...string) ([]byte, error) {
 			return nil, err
 		}
 
-		cmd := buildCmd(args...)
+		cmd := c.dbCmd(args...)
 		var outBuf bytes.Buffer
 		var errBuf bytes.Buffer
 		cmd.Stdout = &outBuf
@@ -156,7 +213,7 @@ func (c *Client) runCmdQuiet(args ...string) ([]byte, error) {
 
 			// Check if the error is an incorrect master password
 			if strings.Contains(actualErrStr, "Invalid credentials") || strings.Contains(actualErrStr, "HMAC mismatch") {
-				fmt.Println("\033[31mError: Invalid KeePassXC master password. Please try again.\033[0m")
+				fmt.Fprintln(os.Stderr, "\033[31mError: Invalid KeePassXC master password. Please try again.\033[0m")
 				c.clearMasterPassword()
 				lockRetries = 0
 				continue
@@ -168,7 +225,7 @@ func (c *Client) runCmdQuiet(args ...string) ([]byte, error) {
 				if lockRetries < 3 {
 					lockRetries++
 					// Don't print in quiet mode ideally, but lock is a critical stall
-					fmt.Printf("\033[33mWarning: KeePassXC database is locked. Retrying in 2 seconds... (%d/3)\033[0m\n", lockRetries)
+					fmt.Fprintf(os.Stderr, "\033[33mWarning: KeePassXC database is locked. Retrying in 2 seconds... (%d/3)\033[0m\n", lockRetries)
 					time.Sleep(2 * time.Second)
 					continue
 				}
@@ -218,22 +275,35 @@ func (c *Client) clearMasterPassword() {
 // This delegates all cryptographic work to KeePassXC's audited generator.
 // Flags: -L length, -l lowercase, -U uppercase, -n numbers, -s special characters.
 func (c *Client) GeneratePassword(length int) ([]byte, error) {
-	cmd := buildCmd("generate",
+	return runGenerator("generate",
 		"-L", strconv.Itoa(length),
 		"-l", "-U", "-n", "-s",
 	)
+}
+
+// Diceware creates a passphrase of the given number of words using
+// keepassxc-cli diceware (EFF large wordlist, space-separated).
+// Easier to read out or type than GeneratePassword output.
+func (c *Client) Diceware(words int) ([]byte, error) {
+	return runGenerator("diceware", "-W", strconv.Itoa(words))
+}
+
+// runGenerator runs a keepassxc-cli generator subcommand and returns its
+// output as an owned byte slice that the caller can zero.
+func runGenerator(args ...string) ([]byte, error) {
+	cmd := buildCmd(args...)
 
 	var outBuf, errBuf bytes.Buffer
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
 
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("keepassxc-cli generate failed: %w: %s", err, errBuf.String())
+		return nil, fmt.Errorf("keepassxc-cli %s failed: %w: %s", args[0], err, errBuf.String())
 	}
 
 	password := bytes.TrimSpace(outBuf.Bytes())
 	if len(password) == 0 {
-		return nil, fmt.Errorf("keepassxc-cli generate returned empty password")
+		return nil, fmt.Errorf("keepassxc-cli %s returned empty password", args[0])
 	}
 
 	// Create a copy to own the memory cleanly, so we can zero it later
@@ -257,12 +327,23 @@ func (c *Client) EnsureUnlocked() error {
 
 	dir := filepath.Dir(c.DatabasePath) + "/"
 	base := filepath.Base(c.DatabasePath)
-	fmt.Printf("Enter password for %s\033[32m%s\033[0m: ", dir, base)
-	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	// Standard input may carry data (e.g. 'a --stdin-name'); then the
+	// password is read from the terminal itself.
+	fd := int(syscall.Stdin)
+	if !term.IsTerminal(fd) {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return fmt.Errorf("cannot ask for the database password: standard input is not a terminal and %w", err)
+		}
+		defer func() { _ = tty.Close() }()
+		fd = int(tty.Fd())
+	}
+	fmt.Fprintf(os.Stderr, "Enter password for %s\033[32m%s\033[0m: ", dir, base)
+	bytePassword, err := term.ReadPassword(fd)
 	if err != nil {
 		return err
 	}
-	fmt.Println()                   // Newline
+	fmt.Fprintln(os.Stderr)         // Newline
 	c.masterPassword = bytePassword // Already []byte, no conversion needed
 	c.passwordSet = true
 	return nil
@@ -411,7 +492,7 @@ func (c *Client) AddEntry(group, title string, password []byte, username string,
 	// keepassxc uses forward slashes
 	fullPath = filepath.ToSlash(filepath.Clean(fullPath))
 
-	cmdAdd := buildCmd("add", c.DatabasePath, fullPath,
+	cmdAdd := c.dbCmd("add", c.DatabasePath, fullPath,
 		"--username", username,
 		"--url", url,
 		"-p",
@@ -575,3 +656,107 @@ func (c *Client) UpdateEntryNotes(entryPath, notes string) error {
 	}
 	return nil
 }
+
+// UpdateEntryPassword replaces the password of an existing entry.
+// KeePassXC snapshots the entry into its history before applying the edit,
+// so the previous password stays recoverable from the entry history.
+// Like AddEntry, it writes the master password followed by the new password
+// (twice, for the confirmation prompt) to stdin.
+func (c *Client) UpdateEntryPassword(entryPath string, password []byte) error {
+	if err := c.EnsureUnlocked(); err != nil {
+		return err
+	}
+
+	cmdEdit := c.dbCmd("edit", "-p", c.DatabasePath, entryPath)
+
+	var outBuf bytes.Buffer
+	cmdEdit.Stdout = &outBuf
+	cmdEdit.Stderr = &outBuf
+
+	stdin, err := cmdEdit.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmdEdit.Start(); err != nil {
+		return err
+	}
+
+	_, _ = stdin.Write(c.getMasterPassword())
+	_, _ = stdin.Write([]byte("\n"))
+	_, _ = stdin.Write(password)
+	_, _ = stdin.Write([]byte("\n"))
+	_, _ = stdin.Write(password)
+	_, _ = stdin.Write([]byte("\n"))
+	_ = stdin.Close()
+
+	if err := cmdEdit.Wait(); err != nil {
+		return fmt.Errorf("keepassxc-cli edit -p failed: %s: %s", err, outBuf.String())
+	}
+
+	return nil
+}
+
+// ImportAttachment stores data as the attachment name of an entry,
+// replacing an existing attachment of the same name. keepassxc-cli only
+// imports from files, so data is handed to it through a pipe.
+func (c *Client) ImportAttachment(entryPath, name string, data []byte) error {
+	if err := c.EnsureUnlocked(); err != nil {
+		return err
+	}
+
+	out, err := c.runCmdFile(data, "attachment-import", "-f", c.DatabasePath, entryPath, name, pipedFile)
+	if err != nil {
+		return fmt.Errorf("keepassxc-cli attachment-import failed: %s: %s", err, out)
+	}
+	return nil
+}
+
+// ExportAttachment returns the content of the attachment name of an entry.
+func (c *Client) ExportAttachment(entryPath, name string) ([]byte, error) {
+	if err := c.EnsureUnlocked(); err != nil {
+		return nil, err
+	}
+
+	out, err := c.runCmdQuiet("attachment-export", "-q", "--stdout", c.DatabasePath, entryPath, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export attachment '%s': %w", name, err)
+	}
+	return out, nil
+}
+
+// CreateDatabase creates a new, empty KDBX database at dbPath protected by
+// password (keepassxc-cli db-create -p). The password is written twice to
+// stdin for the confirmation prompt. It fails if dbPath already exists.
+func CreateDatabase(dbPath string, password []byte) error {
+	if _, err := os.Stat(dbPath); err == nil {
+		return fmt.Errorf("database '%s' already exists", dbPath)
+	}
+
+	cmdCreate := buildCmd("db-create", "-p", dbPath)
+
+	var outBuf bytes.Buffer
+	cmdCreate.Stdout = &outBuf
+	cmdCreate.Stderr = &outBuf
+
+	stdin, err := cmdCreate.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmdCreate.Start(); err != nil {
+		return err
+	}
+
+	_, _ = stdin.Write(password)
+	_, _ = stdin.Write([]byte("\n"))
+	_, _ = stdin.Write(password)
+	_, _ = stdin.Write([]byte("\n"))
+	_ = stdin.Close()
+
+	if err := cmdCreate.Wait(); err != nil {
+		return fmt.Errorf("keepassxc-cli db-create failed: %s: %s", err, outBuf.String())
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package keepass
 
 import (
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -124,6 +126,22 @@ func TestGeneratePassword_Uniqueness(t *testing.T) {
 	}
 }
 
+func TestDiceware_WordCount(t *testing.T) {
+	if _, err := exec.LookPath("keepassxc-cli"); err != nil {
+		t.Skip("keepassxc-cli not installed, skipping")
+	}
+
+	client := New("/dummy/path.kdbx")
+
+	pw, err := client.Diceware(6)
+	if err != nil {
+		t.Fatalf("Diceware(6) failed: %v", err)
+	}
+	if words := strings.Fields(string(pw)); len(words) != 6 {
+		t.Errorf("Diceware(6) returned %d words, want 6", len(words))
+	}
+}
+
 func TestNew_Defaults(t *testing.T) {
 	client := New("/test/db.kdbx")
 	if client.DatabasePath != "/test/db.kdbx" {
@@ -407,3 +425,59 @@ func TestEnsureUnlocked_AlreadyUnlocked(t *testing.T) {
 		t.Errorf("EnsureUnlocked should return nil when already unlocked, got: %v", err)
 	}
 }
+
+func TestDbCmd_KeyFile(t *testing.T) {
+	c := New("/tmp/db.kdbx", WithKeyFile("/tmp/db.keyx"))
+	cmd := c.dbCmd("show", "-q", "/tmp/db.kdbx", "entry")
+	want := []string{"keepassxc-cli", "show", "--key-file", "/tmp/db.keyx", "-q", "/tmp/db.kdbx", "entry"}
+	if strings.Join(cmd.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("dbCmd args = %v, want %v", cmd.Args, want)
+	}
+
+	plain := New("/tmp/db.kdbx").dbCmd("ls", "/tmp/db.kdbx")
+	if strings.Contains(strings.Join(plain.Args, " "), "--key-file") {
+		t.Errorf("dbCmd without key file must not add --key-file: %v", plain.Args)
+	}
+}
+
+func TestCreateDatabase_RefusesExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "existing.kdbx")
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := CreateDatabase(path, []byte("pw"))
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected 'already exists' error, got %v", err)
+	}
+}
+
+// The attachment reaches keepassxc-cli through a pipe, never a temp file.
+func TestImportAttachment_Piped(t *testing.T) {
+	bin := t.TempDir()
+	got := filepath.Join(t.TempDir(), "imported")
+	script := `#!/bin/sh
+read -r pw
+[ "$pw" = "master" ] || exit 1
+for last; do :; done
+cat "$last" > "` + got + `"
+`
+	if err := os.WriteFile(filepath.Join(bin, "keepassxc-cli"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	c := New("/db.kdbx")
+	c.SetMasterPassword([]byte("master"))
+	data := []byte(strings.Repeat("manifest\n", 20000)) // larger than a pipe buffer
+	if err := c.ImportAttachment("grp/entry", "manifest.json.gz", data); err != nil {
+		t.Fatalf("ImportAttachment: %v", err)
+	}
+	if out, _ := os.ReadFile(got); string(out) != string(data) {
+		t.Errorf("imported %d bytes, want %d", len(out), len(data))
+	}
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("temp files left behind: %v", left)
+	}
+}
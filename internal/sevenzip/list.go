@@ -0,0 +1,90 @@
+package sevenzip
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single item of an archive listing.
+type Entry struct {
+	Path     string
+	Size     int64
+	IsDir    bool
+	Modified time.Time
+	CRC      string // Hex CRC32 as printed by 7z; empty for directories
+}
+
+// List returns the entries of an archive using "7z l -slt".
+func List(binaryPath string, password []byte, archivePath string) ([]Entry, error) {
+	var out bytes.Buffer
+	args := []string{"l", "-slt", "-ba", archivePath}
+	if _, err := runWithTimeoutInternal(context.Background(), "", binaryPath, password, args, DefaultTimeout, &out); err != nil {
+		return nil, err
+	}
+	return parseTechnicalListing(out.Bytes()), nil
+}
+
+// parseTechnicalListing parses the "Key = Value" blocks printed by
+// "7z l -slt -ba". Blocks are separated by blank lines; lines without
+// " = " (prompts, PTY noise) are ignored.
+func parseTechnicalListing(data []byte) []Entry {
+	var entries []Entry
+	var cur *Entry
+
+	flush := func() {
+		if cur != nil && cur.Path != "" {
+			entries = append(entries, *cur)
+		}
+		cur = nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		if key == "Path" {
+			// A new block may start without a separating blank line
+			flush()
+			cur = &Entry{Path: value}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch key {
+		case "Size":
+			cur.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "Folder":
+			cur.IsDir = value == "+"
+		case "Attributes":
+			if strings.HasPrefix(value, "D") {
+				cur.IsDir = true
+			}
+		case "Modified":
+			// 7z prints local time, optionally with fractional seconds
+			for _, layout := range []string{"2006-01-02 15:04:05.9999999", "2006-01-02 15:04:05"} {
+				if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+					cur.Modified = t
+					break
+				}
+			}
+		case "CRC":
+			cur.CRC = value
+		}
+	}
+	flush()
+
+	return entries
+}
@@ -0,0 +1,52 @@
+package sevenzip
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTechnicalListing(t *testing.T) {
+	input := "Enter password (will not be echoed):\r\n" +
+		"Path = docs\r\n" +
+		"Size = 0\r\n" +
+		"Modified = 2024-03-01 10:20:30\r\n" +
+		"Attributes = D drwxr-xr-x\r\n" +
+		"\r\n" +
+		"Path = docs/a.txt\r\n" +
+		"Size = 1234\r\n" +
+		"Modified = 2024-03-01 10:20:30.1234567\r\n" +
+		"Attributes = A -rw-r--r--\r\n" +
+		"CRC = 3610A686\r\n" +
+		"\r\n" +
+		"Path = b = c.txt\r\n" +
+		"Folder = -\r\n" +
+		"Size = 5\r\n"
+
+	entries := parseTechnicalListing([]byte(input))
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(entries), entries)
+	}
+
+	if !entries[0].IsDir || entries[0].Path != "docs" {
+		t.Errorf("entry 0 = %+v, want directory 'docs'", entries[0])
+	}
+
+	a := entries[1]
+	if a.Path != "docs/a.txt" || a.Size != 1234 || a.IsDir || a.CRC != "3610A686" {
+		t.Errorf("entry 1 = %+v", a)
+	}
+	want := time.Date(2024, 3, 1, 10, 20, 30, 123456700, time.Local)
+	if !a.Modified.Equal(want) {
+		t.Errorf("Modified = %v, want %v", a.Modified, want)
+	}
+
+	if entries[2].Path != "b = c.txt" || entries[2].Size != 5 {
+		t.Errorf("entry 2 = %+v, want path with ' = ' preserved", entries[2])
+	}
+}
+
+func TestParseTechnicalListing_Empty(t *testing.T) {
+	if entries := parseTechnicalListing(nil); len(entries) != 0 {
+		t.Errorf("expected no entries, got %+v", entries)
+	}
+}
@@ -0,0 +1,93 @@
+package sevenzip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// Stream runs a 7z command that writes file data to standard output
+// (e.g. "x -so") and copies that data to stdout byte for byte.
+//
+// Unlike Run, only 7z's stdin and stderr are attached to the PTY, so the
+// password prompt is still answered there while standard output stays a
+// clean pipe. Everything 7z prints on the PTY (prompts, progress, error
+// messages; never the password echo) goes to msgs; nil discards it.
+func Stream(binaryPath string, password []byte, args []string, stdout, msgs io.Writer) error {
+	// Make the PTY the controlling terminal (fd 0 in the child), as pty.Start does
+	return runStreaming(binaryPath, password, args, nil, stdout, 0, msgs)
+}
+
+// StreamIn runs a 7z command that reads file data from standard input
+// (e.g. "a -si<name>") and feeds it from stdin.
+//
+// Standard input is a clean pipe; stdout and stderr are attached to the PTY,
+// which is also the controlling terminal, so 7z's password prompt (read from
+// the terminal, not stdin) is answered there. Everything 7z prints goes to
+// msgs; nil discards it.
+func StreamIn(binaryPath string, password []byte, args []string, stdin io.Reader, msgs io.Writer) error {
+	// fd 1 (the PTY) becomes the controlling terminal
+	return runStreaming(binaryPath, password, args, stdin, nil, 1, msgs)
+}
+
+// runStreaming runs 7z with stdin and stdout wired to the given streams; a
+// nil stream and stderr are attached to a PTY instead. cttyFd is the child
+// fd (attached to the PTY) that becomes its controlling terminal, where the
+// password prompt is answered.
+func runStreaming(binaryPath string, password []byte, args []string, stdin io.Reader, stdout io.Writer, cttyFd int, msgs io.Writer) error {
+	if msgs == nil {
+		msgs = io.Discard
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ptmx.Close() }()
+
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	cmd.Stdin = tty
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Stdout = tty
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	cmd.Stderr = tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: cttyFd}
+
+	startErr := cmd.Start()
+	_ = tty.Close() // the child holds its own copy
+	if startErr != nil {
+		return startErr
+	}
+
+	done := make(chan error, 1)
+	passwordSent := make(chan struct{})
+	var prompted atomic.Bool
+	go processOutput(ptmx, password, passwordSent, msgs, done, &prompted)
+
+	errWait := cmd.Wait()
+	<-done
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("7z operation timed out after %s", DefaultTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(errWait, &exitErr) {
+		return &ExitError{Code: exitErr.ExitCode()}
+	}
+	return errWait
+}
@@ -0,0 +1,103 @@
+package sevenzip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fake7z writes a shell script that behaves like "7z x -so" on an encrypted
+// archive: it prompts for the password on stderr, reads it from stdin and
+// writes payload to stdout if it matches.
+func fake7z(t *testing.T) string {
+	t.Helper()
+	script := `#!/bin/sh
+printf 'Enter password (will not be echoed):' >&2
+read -r pw
+if [ "$pw" != "s3cret" ]; then
+  echo "ERROR: Wrong password" >&2
+  exit 2
+fi
+printf 'line one\nline two\n'
+`
+	path := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStream_CleanStdout(t *testing.T) {
+	var out, msgs bytes.Buffer
+	if err := Stream(fake7z(t), []byte("s3cret"), []string{"x", "-so", "a.7z"}, &out, &msgs); err != nil {
+		t.Fatalf("Stream: %v (messages: %q)", err, msgs.String())
+	}
+	if out.String() != "line one\nline two\n" {
+		t.Errorf("stdout = %q, want only the file data", out.String())
+	}
+	if !strings.Contains(msgs.String(), "Enter password") {
+		t.Errorf("prompt should go to msgs, got %q", msgs.String())
+	}
+	if strings.Contains(msgs.String(), "s3cret") {
+		t.Error("password echo leaked into msgs")
+	}
+}
+
+func TestStream_ExitError(t *testing.T) {
+	var out, msgs bytes.Buffer
+	err := Stream(fake7z(t), []byte("wrong"), []string{"x", "-so", "a.7z"}, &out, &msgs)
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+		t.Fatalf("expected ExitError with code 2, got %v", err)
+	}
+	if err.Error() != "7z exited with code 2 (Fatal error)" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if out.Len() != 0 {
+		t.Errorf("nothing should reach stdout on failure, got %q", out.String())
+	}
+	if !strings.Contains(msgs.String(), "Wrong password") {
+		t.Errorf("7z error should be in msgs, got %q", msgs.String())
+	}
+}
+
+func TestStreamIn_PasswordOnTerminal(t *testing.T) {
+	// Like "7z a -si": the prompt and the password go through the
+	// controlling terminal, the data through stdin.
+	out := filepath.Join(t.TempDir(), "data")
+	script := `#!/bin/sh
+printf 'Enter password (will not be echoed):'
+read -r pw < /dev/tty
+if [ "$pw" != "s3cret" ]; then
+  echo "ERROR: Wrong password"
+  exit 2
+fi
+cat > "` + out + `"
+`
+	bin := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs bytes.Buffer
+	if err := StreamIn(bin, []byte("s3cret"), []string{"a", "-sidata"}, strings.NewReader("payload\n"), &msgs); err != nil {
+		t.Fatalf("StreamIn: %v (messages: %q)", err, msgs.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "payload\n" {
+		t.Errorf("stdin data = %q, want only the payload", data)
+	}
+
+	err = StreamIn(bin, []byte("wrong"), nil, strings.NewReader(""), &msgs)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+		t.Fatalf("expected ExitError with code 2, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"sync/atomic"
@@ -20,14 +21,29 @@ const DefaultTimeout = 4 * time.Hour
 // Run executes a 7z command with secure password input via PTY.
 // Uses DefaultTimeout. For custom timeouts use RunWithTimeout.
 func Run(binaryPath string, password []byte, args []string) error {
-	_, err := runWithTimeoutInternal(context.Background(), binaryPath, password, args, DefaultTimeout, false)
+	_, err := runWithTimeoutInternal(context.Background(), "", binaryPath, password, args, DefaultTimeout, os.Stdout)
 	return err
 }
 
 // RunWithTimeout executes a 7z command with a context deadline.
 // The process is forcefully killed if the deadline is exceeded.
 func RunWithTimeout(ctx context.Context, binaryPath string, password []byte, args []string, timeout time.Duration) error {
-	_, err := runWithTimeoutInternal(ctx, binaryPath, password, args, timeout, false)
+	_, err := runWithTimeoutInternal(ctx, "", binaryPath, password, args, timeout, os.Stdout)
+	return err
+}
+
+// RunQuiet executes a 7z command like Run but discards all 7z output.
+// Used for internal steps (e.g. re-packing) where progress output would only
+// be noise between the caller's own status messages.
+func RunQuiet(binaryPath string, password []byte, args []string) error {
+	_, err := runWithTimeoutInternal(context.Background(), "", binaryPath, password, args, DefaultTimeout, nil)
+	return err
+}
+
+// RunInDir is like Run but starts 7z in dir, so relative paths in args (and
+// in list files) are stored relative to dir.
+func RunInDir(dir, binaryPath string, password []byte, args []string) error {
+	_, err := runWithTimeoutInternal(context.Background(), dir, binaryPath, password, args, DefaultTimeout, os.Stdout)
 	return err
 }
 
@@ -43,7 +59,23 @@ const (
 // VerifyPassword performs a silent test using 7-zip's list command to check header decryption.
 func VerifyPassword(binaryPath string, password []byte, archivePath string) (PasswordMatch, error) {
 	args := []string{"l", "-slt", "-ba", archivePath}
-	prompted, err := runWithTimeoutInternal(context.Background(), binaryPath, password, args, DefaultTimeout, true)
+	prompted, err := runWithTimeoutInternal(context.Background(), "", binaryPath, password, args, DefaultTimeout, nil)
+	if err == nil {
+		if prompted {
+			return MatchCorrect, nil
+		}
+		return MatchUnencrypted, nil
+	}
+	return MatchFailed, err
+}
+
+// VerifyPasswordFull is like VerifyPassword but runs a full test ("7z t")
+// instead of a listing. Archives whose headers are not encrypted (ZIP, or 7z
+// created without -mhe=on) only ask for a password when file data is read,
+// so VerifyPassword reports them as MatchUnencrypted.
+func VerifyPasswordFull(binaryPath string, password []byte, archivePath string) (PasswordMatch, error) {
+	args := []string{"t", "-y", archivePath}
+	prompted, err := runWithTimeoutInternal(context.Background(), "", binaryPath, password, args, DefaultTimeout, nil)
 	if err == nil {
 		if prompted {
 			return MatchCorrect, nil
@@ -53,14 +85,30 @@ func VerifyPassword(binaryPath string, password []byte, archivePath string) (Pas
 	return MatchFailed, err
 }
 
+// VerifyIntegrity runs a silent full test ("7z t") of the archive.
+// Unlike VerifyPassword, which only decrypts the headers, this decrypts and
+// checksums every file, so it proves the data is readable with password.
+func VerifyIntegrity(binaryPath string, password []byte, archivePath string) error {
+	args := []string{"t", "-y", archivePath}
+	_, err := runWithTimeoutInternal(context.Background(), "", binaryPath, password, args, DefaultTimeout, nil)
+	return err
+}
+
 // runWithTimeoutInternal returns (passwordWasPrompted, error).
 // passwordWasPrompted is true when 7z actually asked for a password,
 // false when the archive is unencrypted and 7z never prompted.
-func runWithTimeoutInternal(ctx context.Context, binaryPath string, password []byte, args []string, timeout time.Duration, silent bool) (bool, error) {
+// 7z runs in dir (empty: the current directory).
+// 7z output (minus the echoed password) is copied to out; nil discards it.
+func runWithTimeoutInternal(ctx context.Context, dir, binaryPath string, password []byte, args []string, timeout time.Duration, out io.Writer) (bool, error) {
+	if out == nil {
+		out = io.Discard
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Dir = dir
 
 	// Force English locale to detect prompts reliably regardless of user locale
 	cmd.Env = append(os.Environ(), "LC_ALL=C")
@@ -83,7 +131,7 @@ func runWithTimeoutInternal(ctx context.Context, binaryPath string, password []b
 	var prompted atomic.Bool
 
 	go bridgeStdin(ctx, ptmx, passwordSent)
-	go processOutput(ptmx, password, passwordSent, silent, done, &prompted)
+	go processOutput(ptmx, password, passwordSent, out, done, &prompted)
 
 	errWait := cmd.Wait()
 	<-done
@@ -98,14 +146,28 @@ func runWithTimeoutInternal(ctx context.Context, binaryPath string, password []b
 	if errWait != nil {
 		var exitErr *exec.ExitError
 		if errors.As(errWait, &exitErr) {
-			code := exitErr.ExitCode()
-			return wasPrompted, fmt.Errorf("7z exited with code %d (%s)", code, sevenZipExitCodeDesc(code))
+			return wasPrompted, &ExitError{Code: exitErr.ExitCode()}
 		}
 	}
 
 	return wasPrompted, errWait
 }
 
+// ExitError reports that 7z finished with a non-zero exit code.
+// ExitCode lets callers pass the code on as the process exit status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("7z exited with code %d (%s)", e.Code, sevenZipExitCodeDesc(e.Code))
+}
+
+// ExitCode returns the 7z exit code.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
 // sevenZipExitCodeDesc returns a human-readable description for 7z exit codes.
 func sevenZipExitCodeDesc(code int) string {
 	switch code {
@@ -170,7 +232,7 @@ func bridgeStdin(ctx context.Context, ptmx *os.File, passwordSent <-chan struct{
 }
 
 // processOutput intercepts password prompts and suppresses token echo.
-func processOutput(ptmx *os.File, password []byte, passwordSent chan<- struct{}, silent bool, done chan<- error, prompted *atomic.Bool) {
+func processOutput(ptmx *os.File, password []byte, passwordSent chan<- struct{}, out io.Writer, done chan<- error, prompted *atomic.Bool) {
 	defer close(done)
 
 	buf := make([]byte, 32*1024) // 32 KB — large enough to avoid per-byte reads
@@ -195,9 +257,7 @@ func processOutput(ptmx *os.File, password []byte, passwordSent chan<- struct{},
 			if !suppressUntilNewline && (bytes.Contains(lowerChunk, []byte("enter password")) ||
 				bytes.Contains(lowerChunk, []byte("password:"))) {
 
-				if !silent {
-					_, _ = os.Stdout.Write(chunk)
-				}
+				_, _ = out.Write(chunk)
 
 				// Introduce a tiny delay so the OS PTY layer has time to apply tcsetattr (echo off).
 				// Heavily-loaded CI runners may drop or garble prompt bytes if written instantly.
@@ -221,16 +281,14 @@ func processOutput(ptmx *os.File, password []byte, passwordSent chan<- struct{},
 				nlIdx := bytes.IndexAny(chunk, "\n\r")
 				if nlIdx != -1 {
 					suppressUntilNewline = false
-					if nlIdx+1 < len(chunk) && !silent {
-						_, _ = os.Stdout.Write(chunk[nlIdx+1:])
+					if nlIdx+1 < len(chunk) {
+						_, _ = out.Write(chunk[nlIdx+1:])
 					}
 				}
 				continue
 			}
 
-			if !silent {
-				_, _ = os.Stdout.Write(chunk)
-			}
+			_, _ = out.Write(chunk)
 		}
 
 		if err != nil {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -244,3 +245,24 @@ func TestVerifyPassword_NonexistentArchive(t *testing.T) {
 		t.Error("expected error for nonexistent archive")
 	}
 }
+
+func TestRunInDir_WorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "pwd.txt")
+	script := filepath.Join(t.TempDir(), "fake7z")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\npwd > \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunInDir(dir, script, nil, []string{out}); err != nil {
+		t.Fatalf("RunInDir: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(dir)
+	if strings.TrimSpace(string(got)) != want {
+		t.Errorf("7z ran in %q, want %q", strings.TrimSpace(string(got)), want)
+	}
+}
@@ -0,0 +1,140 @@
+// Package shamir implements Shamir's secret sharing over GF(256).
+//
+// Each byte of the secret is shared independently with a random polynomial
+// of degree threshold-1 whose constant term is that byte. A share is the
+// polynomial evaluated at a non-zero x coordinate; any threshold shares
+// recover the secret by Lagrange interpolation at x = 0.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Share is one point of the sharing polynomials.
+type Share struct {
+	X byte   // Non-zero x coordinate, unique per share
+	Y []byte // Polynomial values, one per secret byte
+}
+
+// Split divides secret into n shares, any k of which reconstruct it.
+func Split(secret []byte, n, k int) ([]Share, error) {
+	switch {
+	case len(secret) == 0:
+		return nil, errors.New("secret is empty")
+	case k < 2:
+		return nil, fmt.Errorf("threshold must be at least 2, got %d", k)
+	case n < k:
+		return nil, fmt.Errorf("share count %d is smaller than threshold %d", n, k)
+	case n > 255:
+		return nil, fmt.Errorf("share count %d exceeds the maximum of 255", n)
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+
+	coeffs := make([]byte, k)
+	defer clear(coeffs)
+	for j, s := range secret {
+		coeffs[0] = s
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, fmt.Errorf("failed to read random coefficients: %w", err)
+		}
+		for i := range shares {
+			shares[i].Y[j] = evaluate(coeffs, shares[i].X)
+		}
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret from at least threshold shares.
+// Supplying fewer shares than the threshold yields a wrong secret, not an
+// error — callers must know the threshold (and should verify the result).
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+	size := len(shares[0].Y)
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if s.X == 0 {
+			return nil, errors.New("share has invalid x coordinate 0")
+		}
+		if seen[s.X] {
+			return nil, fmt.Errorf("duplicate share %d", s.X)
+		}
+		seen[s.X] = true
+		if len(s.Y) != size || size == 0 {
+			return nil, errors.New("shares have inconsistent lengths")
+		}
+	}
+
+	secret := make([]byte, size)
+	for j := range secret {
+		var value byte
+		for i, si := range shares {
+			// Lagrange basis polynomial for share i evaluated at x = 0
+			basis := byte(1)
+			for m, sm := range shares {
+				if m == i {
+					continue
+				}
+				basis = mul(basis, div(sm.X, sm.X^si.X))
+			}
+			value ^= mul(si.Y[j], basis)
+		}
+		secret[j] = value
+	}
+	return secret, nil
+}
+
+// evaluate computes the polynomial with the given coefficients at x
+// (Horner's method; coeffs[0] is the constant term).
+func evaluate(coeffs []byte, x byte) byte {
+	var result byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result = mul(result, x) ^ coeffs[i]
+	}
+	return result
+}
+
+// GF(256) arithmetic with the AES reduction polynomial x^8+x^4+x^3+x+1,
+// using log/exp tables over the generator 3.
+var expTable, logTable = buildTables()
+
+func buildTables() (exp [510]byte, log [256]byte) {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		exp[i+255] = x
+		log[x] = byte(i)
+		// Multiply by the generator 3: x*2 ^ x
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x = x2 ^ x
+	}
+	return exp, log
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func div(a, b byte) byte {
+	if b == 0 {
+		panic("shamir: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
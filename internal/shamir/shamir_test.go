@@ -0,0 +1,103 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombine_AllSubsets(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("got %d shares, want 5", len(shares))
+	}
+
+	// Every 3-of-5 subset must recover the secret
+	for a := 0; a < 5; a++ {
+		for b := a + 1; b < 5; b++ {
+			for c := b + 1; c < 5; c++ {
+				got, err := Combine([]Share{shares[c], shares[a], shares[b]})
+				if err != nil {
+					t.Fatalf("Combine(%d,%d,%d): %v", a, b, c, err)
+				}
+				if !bytes.Equal(got, secret) {
+					t.Errorf("Combine(%d,%d,%d) = %q, want %q", a, b, c, got, secret)
+				}
+			}
+		}
+	}
+}
+
+func TestCombine_BelowThreshold(t *testing.T) {
+	secret := []byte("top secret value")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Combine(shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got, secret) {
+		t.Error("two shares of a 3-of-5 split must not reveal the secret")
+	}
+}
+
+func TestSplit_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret []byte
+		n, k   int
+	}{
+		{"empty secret", nil, 5, 3},
+		{"threshold 1", []byte("x"), 5, 1},
+		{"n below k", []byte("x"), 2, 3},
+		{"too many shares", []byte("x"), 256, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Split(tt.secret, tt.n, tt.k); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestCombine_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		shares []Share
+	}{
+		{"too few", []Share{{X: 1, Y: []byte{1}}}},
+		{"zero x", []Share{{X: 0, Y: []byte{1}}, {X: 1, Y: []byte{2}}}},
+		{"duplicate x", []Share{{X: 1, Y: []byte{1}}, {X: 1, Y: []byte{2}}}},
+		{"length mismatch", []Share{{X: 1, Y: []byte{1}}, {X: 2, Y: []byte{2, 3}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Combine(tt.shares); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	// Known AES field products
+	if got := mul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("mul(0x57, 0x83) = %#x, want 0xc1", got)
+	}
+	if got := mul(0x57, 0x13); got != 0xfe {
+		t.Errorf("mul(0x57, 0x13) = %#x, want 0xfe", got)
+	}
+	for a := 1; a < 256; a++ {
+		for _, b := range []byte{1, 2, 0x53, 0xca, 0xff} {
+			if got := div(mul(byte(a), b), b); got != byte(a) {
+				t.Fatalf("div(mul(%#x, %#x), %#x) = %#x", a, b, b, got)
+			}
+		}
+	}
+}